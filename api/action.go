@@ -17,5 +17,12 @@ const (
 
 	ActionRollbackCanary ActionType = "rollback-canary"
 
+	ActionPromote    ActionType = "promote"
+	ActionSwitchBack ActionType = "switch-back"
+
+	ActionRunCronJob     ActionType = "run-cronjob"
+	ActionSuspendCronJob ActionType = "suspend-cronjob"
+	ActionResumeCronJob  ActionType = "resume-cronjob"
+
 	ActionUnknown ActionType = ""
 )
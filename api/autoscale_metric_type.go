@@ -0,0 +1,34 @@
+package api
+
+// AutoscaleMetricType is the kind of metric the horizontal pod autoscaler scales on besides cpu
+type AutoscaleMetricType string
+
+const (
+	AutoscaleMetricTypeMemory   AutoscaleMetricType = "memory"
+	AutoscaleMetricTypePods     AutoscaleMetricType = "pods"
+	AutoscaleMetricTypeObject   AutoscaleMetricType = "object"
+	AutoscaleMetricTypeExternal AutoscaleMetricType = "external"
+
+	AutoscaleMetricTypeUnknown AutoscaleMetricType = ""
+)
+
+// AutoscalePolicyType sets whether a scaling policy limits the change in number of pods or in percentage of the current pods
+type AutoscalePolicyType string
+
+const (
+	AutoscalePolicyTypePods    AutoscalePolicyType = "Pods"
+	AutoscalePolicyTypePercent AutoscalePolicyType = "Percent"
+
+	AutoscalePolicyTypeUnknown AutoscalePolicyType = ""
+)
+
+// AutoscaleSelectPolicy sets which of the scaling policies is used, or disables scaling in that direction
+type AutoscaleSelectPolicy string
+
+const (
+	AutoscaleSelectPolicyMax      AutoscaleSelectPolicy = "Max"
+	AutoscaleSelectPolicyMin      AutoscaleSelectPolicy = "Min"
+	AutoscaleSelectPolicyDisabled AutoscaleSelectPolicy = "Disabled"
+
+	AutoscaleSelectPolicyUnknown AutoscaleSelectPolicy = ""
+)
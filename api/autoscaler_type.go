@@ -0,0 +1,22 @@
+package api
+
+// AutoscalerType sets whether a horizontal pod autoscaler or a keda scaled object scales the deployment
+type AutoscalerType string
+
+const (
+	AutoscalerTypeHPA  AutoscalerType = "hpa"
+	AutoscalerTypeKeda AutoscalerType = "keda"
+
+	AutoscalerTypeUnknown AutoscalerType = ""
+)
+
+// KedaTriggerType is the keda scaler a trigger of the scaled object uses
+type KedaTriggerType string
+
+const (
+	KedaTriggerTypePrometheus KedaTriggerType = "prometheus"
+	KedaTriggerTypeGCPPubSub  KedaTriggerType = "gcp-pubsub"
+	KedaTriggerTypeCron       KedaTriggerType = "cron"
+
+	KedaTriggerTypeUnknown KedaTriggerType = ""
+)
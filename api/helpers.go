@@ -115,3 +115,8 @@ func httpRequestBody(method, url string, headers map[string]string) string {
 
 	return string(body)
 }
+
+// isZeroIntOrPercent returns true for an int-or-string like maxSurge that amounts to zero
+func isZeroIntOrPercent(value string) bool {
+	return strings.TrimSuffix(value, "%") == "0"
+}
@@ -6,9 +6,11 @@ const (
 	KindDeployment         Kind = "deployment"
 	KindHeadlessDeployment Kind = "headless-deployment"
 	KindStatefulset        Kind = "statefulset"
+	KindDaemonSet          Kind = "daemonset"
 	KindJob                Kind = "job"
 	KindCronJob            Kind = "cronjob"
 	KindConfig             Kind = "config"
+	KindSecret             Kind = "secret"
 	KindConfigToFile       Kind = "config-to-file"
 
 	KindUnknown Kind = ""
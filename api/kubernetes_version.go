@@ -0,0 +1,19 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var kubernetesVersionRegex = regexp.MustCompile(`^v?1\.(\d+)(\..*)?$`)
+
+// ParseKubernetesMinorVersion returns the minor version of a kubernetes 1.x version, like 21 for 1.21.14-gke.2100
+func ParseKubernetesMinorVersion(kubernetesVersion string) (int, error) {
+	matches := kubernetesVersionRegex.FindStringSubmatch(kubernetesVersion)
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("Kubernetes version %v isn't a valid 1.x version", kubernetesVersion)
+	}
+
+	return strconv.Atoi(matches[1])
+}
@@ -7,22 +7,27 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // Params is used to parameterize the deployment, set from custom properties in the manifest
 type Params struct {
 	// control params
-	Action                  ActionType      `json:"action,omitempty" yaml:"action,omitempty"`
-	Kind                    Kind            `json:"kind,omitempty" yaml:"kind,omitempty"`
-	DryRun                  bool            `json:"dryrun,omitempty" yaml:"dryrun,omitempty"`
-	ProgressDeadlineSeconds int             `json:"progressDeadlineSeconds,omitempty" yaml:"progressDeadlineSeconds,omitempty"`
-	BuildVersion            string          `json:"-" yaml:"-"`
-	ChaosProof              bool            `json:"chaosproof,omitempty" yaml:"chaosproof,omitempty"`
-	OperatingSystem         OperatingSystem `json:"os,omitempty" yaml:"os,omitempty"`
-	Manifests               ManifestsParams `json:"manifests,omitempty" yaml:"manifests,omitempty"`
-	TrustedIPRanges         []string        `json:"trustedips,omitempty" yaml:"trustedips,omitempty"`
+	Action                  ActionType        `json:"action,omitempty" yaml:"action,omitempty"`
+	Kind                    Kind              `json:"kind,omitempty" yaml:"kind,omitempty"`
+	DryRun                  bool              `json:"dryrun,omitempty" yaml:"dryrun,omitempty"`
+	ProgressDeadlineSeconds int               `json:"progressDeadlineSeconds,omitempty" yaml:"progressDeadlineSeconds,omitempty"`
+	BuildVersion            string            `json:"-" yaml:"-"`
+	ChaosProof              bool              `json:"chaosproof,omitempty" yaml:"chaosproof,omitempty"`
+	OperatingSystem         OperatingSystem   `json:"os,omitempty" yaml:"os,omitempty"`
+	Manifests               ManifestsParams   `json:"manifests,omitempty" yaml:"manifests,omitempty"`
+	TrustedIPRanges         []string          `json:"trustedips,omitempty" yaml:"trustedips,omitempty"`
+	UnknownParams           UnknownParamsMode `json:"unknownParams,omitempty" yaml:"unknownParams,omitempty"`
+	KubernetesVersion       string            `json:"kubernetesVersion,omitempty" yaml:"kubernetesVersion,omitempty"`
 
 	// app params
 	App                             string                 `json:"app,omitempty" yaml:"app,omitempty"`
@@ -32,7 +37,10 @@ type Params struct {
 	Completions                     int                    `json:"completions,omitempty" yaml:"completions,omitempty"`
 	Parallelism                     int                    `json:"parallelism,omitempty" yaml:"parallelism,omitempty"`
 	BackoffLimit                    *int                   `json:"backoffLimit,omitempty" yaml:"backoffLimit,omitempty"`
+	WaitForCompletion               bool                   `json:"waitForCompletion,omitempty" yaml:"waitForCompletion,omitempty"`
+	CompletionTimeout               string                 `json:"completionTimeout,omitempty" yaml:"completionTimeout,omitempty"`
 	ConcurrencyPolicy               string                 `json:"concurrencypolicy,omitempty" yaml:"concurrencypolicy,omitempty"`
+	Suspend                         *bool                  `json:"suspend,omitempty" yaml:"suspend,omitempty"`
 	PodManagementPolicy             string                 `json:"podManagementpolicy,omitempty" yaml:"podManagementpolicy,omitempty"`
 	Replicas                        int                    `json:"replicas,omitempty" yaml:"replicas,omitempty"`
 	StorageClass                    string                 `json:"storageclass,omitempty" yaml:"storageclass,omitempty"`
@@ -59,6 +67,9 @@ type Params struct {
 	Request                         RequestParams          `json:"request,omitempty" yaml:"request,omitempty"`
 	Secrets                         SecretsParams          `json:"secrets,omitempty" yaml:"secrets,omitempty"`
 	Configs                         ConfigsParams          `json:"configs,omitempty" yaml:"configs,omitempty"`
+	Shared                          SharedParams           `json:"shared,omitempty" yaml:"shared,omitempty"`
+	ImmutableConfigs                ImmutableConfigsParams `json:"immutableConfigs,omitempty" yaml:"immutableConfigs,omitempty"`
+	Inventory                       InventoryParams        `json:"inventory,omitempty" yaml:"inventory,omitempty"`
 	VolumeMounts                    []VolumeMountParams    `json:"volumemounts,omitempty" yaml:"volumemounts,omitempty"`
 	CertificateSecret               string                 `json:"certificatesecret,omitempty" yaml:"certificatesecret,omitempty"`
 	AllowHTTP                       bool                   `json:"allowhttp,omitempty" yaml:"allowhttp,omitempty"`
@@ -74,6 +85,7 @@ type Params struct {
 	ProbeService                           *bool                     `json:"probeService,omitempty" yaml:"probeService,omitempty"`
 	TopologyAwareHints                     *bool                     `json:"topologyAwareHints,omitempty" yaml:"topologyAwareHints,omitempty"`
 	Tolerations                            []*map[string]interface{} `json:"tolerations,omitempty" yaml:"tolerations,omitempty"`
+	NodeSelector                           map[string]string         `json:"nodeSelector,omitempty" yaml:"nodeSelector,omitempty"`
 
 	// container params
 	Container              ContainerParams           `json:"container,omitempty" yaml:"container,omitempty"`
@@ -85,6 +97,10 @@ type Params struct {
 	StrategyType           StrategyType              `json:"strategytype,omitempty" yaml:"strategytype,omitempty"`
 	AtomicID               string                    `json:"-" yaml:"-"`
 	RollingUpdate          RollingUpdateParams       `json:"rollingupdate,omitempty" yaml:"rollingupdate,omitempty"`
+	Canary                 CanaryParams              `json:"canary,omitempty" yaml:"canary,omitempty"`
+	RollbackOnFailure      bool                      `json:"rollbackOnFailure,omitempty" yaml:"rollbackOnFailure,omitempty"`
+	BlueGreen              BlueGreenParams           `json:"bluegreen,omitempty" yaml:"bluegreen,omitempty"`
+	Hooks                  HooksParams               `json:"hooks,omitempty" yaml:"hooks,omitempty"`
 
 	// set default image for sidecars
 	DefaultOpenrestySidecarImage     string `json:"defaultOpenrestySidecarImage,omitempty" yaml:"defaultOpenrestySidecarImage,omitempty"`
@@ -144,11 +160,68 @@ type MemoryParams struct {
 
 // AutoscaleParams controls autoscaling
 type AutoscaleParams struct {
-	Enabled       *bool                 `json:"enabled,omitempty" yaml:"enabled,omitempty"`
-	MinReplicas   int                   `json:"min,omitempty" yaml:"min,omitempty"`
-	MaxReplicas   int                   `json:"max,omitempty" yaml:"max,omitempty"`
-	CPUPercentage int                   `json:"cpu,omitempty" yaml:"cpu,omitempty"`
-	Safety        AutoscaleSafetyParams `json:"safety,omitempty" yaml:"safety,omitempty"`
+	Enabled       *bool                   `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Type          AutoscalerType          `json:"type,omitempty" yaml:"type,omitempty"`
+	MinReplicas   int                     `json:"min,omitempty" yaml:"min,omitempty"`
+	MaxReplicas   int                     `json:"max,omitempty" yaml:"max,omitempty"`
+	CPUPercentage int                     `json:"cpu,omitempty" yaml:"cpu,omitempty"`
+	Safety        AutoscaleSafetyParams   `json:"safety,omitempty" yaml:"safety,omitempty"`
+	Metrics       []AutoscaleMetricParams `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+	Behavior      AutoscaleBehaviorParams `json:"behavior,omitempty" yaml:"behavior,omitempty"`
+	Keda          AutoscaleKedaParams     `json:"keda,omitempty" yaml:"keda,omitempty"`
+}
+
+// AutoscaleKedaParams configures the keda scaled object that's used instead of the horizontal pod autoscaler for autoscale type keda
+type AutoscaleKedaParams struct {
+	PollingInterval int                          `json:"pollingInterval,omitempty" yaml:"pollingInterval,omitempty"`
+	CooldownPeriod  int                          `json:"cooldownPeriod,omitempty" yaml:"cooldownPeriod,omitempty"`
+	ScaleToZero     bool                         `json:"scaleToZero,omitempty" yaml:"scaleToZero,omitempty"`
+	Triggers        []AutoscaleKedaTriggerParams `json:"triggers,omitempty" yaml:"triggers,omitempty"`
+}
+
+// AutoscaleKedaTriggerParams is a keda trigger, with the metadata as documented for the keda scaler of its type
+type AutoscaleKedaTriggerParams struct {
+	Type              KedaTriggerType   `json:"type,omitempty" yaml:"type,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	AuthenticationRef string            `json:"authenticationRef,omitempty" yaml:"authenticationRef,omitempty"`
+}
+
+// AutoscaleMetricParams adds a metric to scale on besides the cpu percentage; the autoscaler uses the metric that results in the most replicas
+type AutoscaleMetricParams struct {
+	Type               AutoscaleMetricType         `json:"type,omitempty" yaml:"type,omitempty"`
+	Name               string                      `json:"name,omitempty" yaml:"name,omitempty"`
+	Selector           map[string]string           `json:"selector,omitempty" yaml:"selector,omitempty"`
+	Object             AutoscaleMetricObjectParams `json:"object,omitempty" yaml:"object,omitempty"`
+	AverageUtilization int                         `json:"averageUtilization,omitempty" yaml:"averageUtilization,omitempty"`
+	AverageValue       string                      `json:"averageValue,omitempty" yaml:"averageValue,omitempty"`
+	Value              string                      `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// AutoscaleMetricObjectParams is the kubernetes object an object metric describes, like an ingress for its requests per second
+type AutoscaleMetricObjectParams struct {
+	APIVersion string `json:"apiVersion,omitempty" yaml:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	Name       string `json:"name,omitempty" yaml:"name,omitempty"`
+}
+
+// AutoscaleBehaviorParams limits how fast the autoscaler scales up and down
+type AutoscaleBehaviorParams struct {
+	ScaleUp   *AutoscaleScalingRulesParams `json:"scaleUp,omitempty" yaml:"scaleUp,omitempty"`
+	ScaleDown *AutoscaleScalingRulesParams `json:"scaleDown,omitempty" yaml:"scaleDown,omitempty"`
+}
+
+// AutoscaleScalingRulesParams configures scaling in a single direction
+type AutoscaleScalingRulesParams struct {
+	StabilizationWindowSeconds *int                    `json:"stabilizationWindowSeconds,omitempty" yaml:"stabilizationWindowSeconds,omitempty"`
+	SelectPolicy               AutoscaleSelectPolicy   `json:"selectPolicy,omitempty" yaml:"selectPolicy,omitempty"`
+	Policies                   []AutoscalePolicyParams `json:"policies,omitempty" yaml:"policies,omitempty"`
+}
+
+// AutoscalePolicyParams allows a change of at most value pods or percent of the current pods within the period
+type AutoscalePolicyParams struct {
+	Type          AutoscalePolicyType `json:"type,omitempty" yaml:"type,omitempty"`
+	Value         int                 `json:"value,omitempty" yaml:"value,omitempty"`
+	PeriodSeconds int                 `json:"periodSeconds,omitempty" yaml:"periodSeconds,omitempty"`
 }
 
 type VPAParams struct {
@@ -226,6 +299,91 @@ type RollingUpdateParams struct {
 	Timeout        string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
 }
 
+// CanaryParams configures a progressive canary release, shifting traffic to the canary in steps before promoting it to stable
+type CanaryParams struct {
+	Steps    []CanaryStepParams   `json:"steps,omitempty" yaml:"steps,omitempty"`
+	Analysis CanaryAnalysisParams `json:"analysis,omitempty" yaml:"analysis,omitempty"`
+}
+
+// CanaryAnalysisParams configures automated analysis of the canary against the stable track using Prometheus queries
+type CanaryAnalysisParams struct {
+	PrometheusURL string               `json:"prometheusURL,omitempty" yaml:"prometheusURL,omitempty"`
+	Interval      string               `json:"interval,omitempty" yaml:"interval,omitempty"`
+	Iterations    int                  `json:"iterations,omitempty" yaml:"iterations,omitempty"`
+	Metrics       []CanaryMetricParams `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+}
+
+// IntervalDuration returns the parsed interval between analysis iterations, or zero if it isn't set or invalid
+func (a CanaryAnalysisParams) IntervalDuration() time.Duration {
+	interval, err := time.ParseDuration(a.Interval)
+	if err != nil {
+		return 0
+	}
+	return interval
+}
+
+// CanaryMetricParams defines a PromQL query evaluated for both the canary and stable track; the query is a go template with access to .App, .Namespace, .Track, .Version and .ReleaseID
+type CanaryMetricParams struct {
+	Name     string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Query    string   `json:"query,omitempty" yaml:"query,omitempty"`
+	MaxRatio float64  `json:"maxRatio,omitempty" yaml:"maxRatio,omitempty"`
+	Max      *float64 `json:"max,omitempty" yaml:"max,omitempty"`
+}
+
+// CanaryStepParams sets the percentage of traffic routed to the canary and how long to hold it before moving on to the next step
+type CanaryStepParams struct {
+	Weight int    `json:"weight,omitempty" yaml:"weight,omitempty"`
+	Pause  string `json:"pause,omitempty" yaml:"pause,omitempty"`
+}
+
+// PauseDuration returns the parsed pause of the step, or zero if it isn't set or invalid
+func (s CanaryStepParams) PauseDuration() time.Duration {
+	pause, err := time.ParseDuration(s.Pause)
+	if err != nil {
+		return 0
+	}
+	return pause
+}
+
+// BlueGreenParams configures the BlueGreen strategy type, which deploys a new color next to the active one and switches the service over to it when promoted
+type BlueGreenParams struct {
+	PreviewHosts []string `json:"previewHosts,omitempty" yaml:"previewHosts,omitempty"`
+	AutoPromote  *bool    `json:"autoPromote,omitempty" yaml:"autoPromote,omitempty"`
+	KeepPrevious string   `json:"keepPrevious,omitempty" yaml:"keepPrevious,omitempty"`
+}
+
+// KeepPreviousDuration returns the parsed time the previous color is kept around to switch back to, or zero if it isn't set or invalid
+func (b BlueGreenParams) KeepPreviousDuration() time.Duration {
+	keepPrevious, err := time.ParseDuration(b.KeepPrevious)
+	if err != nil {
+		return 0
+	}
+	return keepPrevious
+}
+
+// HooksParams configures jobs that run with the image, env, secrets, configs and service account of the application container before and after a deployment rolls out
+type HooksParams struct {
+	PreDeploy  *HookParams `json:"preDeploy,omitempty" yaml:"preDeploy,omitempty"`
+	PostDeploy *HookParams `json:"postDeploy,omitempty" yaml:"postDeploy,omitempty"`
+}
+
+// HookParams sets the command a hook job runs and how long the release waits for it to complete
+type HookParams struct {
+	Command      []string `json:"command,omitempty" yaml:"command,omitempty"`
+	Args         []string `json:"args,omitempty" yaml:"args,omitempty"`
+	Timeout      string   `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	BackoffLimit *int     `json:"backoffLimit,omitempty" yaml:"backoffLimit,omitempty"`
+}
+
+// TimeoutDuration returns the parsed time to wait for the hook job to complete, or zero if it isn't set or invalid
+func (h HookParams) TimeoutDuration() time.Duration {
+	timeout, err := time.ParseDuration(h.Timeout)
+	if err != nil {
+		return 0
+	}
+	return timeout
+}
+
 // ManifestsParams can be used to override or add additional manifests located in the application repository
 type ManifestsParams struct {
 	Files []string               `json:"files,omitempty" yaml:"files,omitempty"`
@@ -247,6 +405,24 @@ type ConfigsParams struct {
 	RenderedFileContent map[string]string      `json:"-" yaml:"-"`
 }
 
+// SharedParams controls the configmap of kind config or secret of kind secret, which other applications in the namespace can mount
+type SharedParams struct {
+	Name               string   `json:"name,omitempty" yaml:"name,omitempty"`
+	AllowKeyRemoval    bool     `json:"allowKeyRemoval,omitempty" yaml:"allowKeyRemoval,omitempty"`
+	RestartDeployments []string `json:"restartDeployments,omitempty" yaml:"restartDeployments,omitempty"`
+}
+
+// ImmutableConfigsParams makes every release create immutable configmaps and secrets named after a hash of their content, instead of updating them in place
+type ImmutableConfigsParams struct {
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Keep    int  `json:"keep,omitempty" yaml:"keep,omitempty"`
+}
+
+// InventoryParams controls the inventory of objects a release rendered, which the next release uses to delete the objects it no longer renders
+type InventoryParams struct {
+	Prune *bool `json:"prune,omitempty" yaml:"prune,omitempty"`
+}
+
 // VolumeMountParams allows additional mounts for already existing volumes, secrets, etc
 type VolumeMountParams struct {
 	Name      string                 `json:"name,omitempty" yaml:"name,omitempty"`
@@ -286,6 +462,11 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 		p.OperatingSystem = OperatingSystemLinux
 	}
 
+	// default unknown parameters to only log a warning
+	if p.UnknownParams == UnknownParamsUnknown {
+		p.UnknownParams = UnknownParamsWarning
+	}
+
 	// default app to estafette app label if no override in stage params
 	if p.App == "" && appLabel == "" && gitName != "" {
 		p.App = gitName
@@ -294,6 +475,11 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 		p.App = appLabel
 	}
 
+	// a shared secret is named after the app, so other applications can mount it without knowing about tracks or suffixes
+	if p.Kind == KindSecret && p.Shared.Name == "" {
+		p.Shared.Name = p.App
+	}
+
 	if p.ProgressDeadlineSeconds <= 0 {
 		p.ProgressDeadlineSeconds = 600
 	}
@@ -425,6 +611,9 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 	if p.Autoscale.CPUPercentage <= 0 {
 		p.Autoscale.CPUPercentage = 80
 	}
+	if p.Autoscale.Type == AutoscalerTypeUnknown {
+		p.Autoscale.Type = AutoscalerTypeHPA
+	}
 
 	if p.Autoscale.Safety.PromQuery == "" {
 		p.Autoscale.Safety.PromQuery = fmt.Sprintf("sum(rate(nginx_http_requests_total{app='%v'}[5m])) by (app)", p.App)
@@ -496,7 +685,7 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 
 	// set readiness probe defaults
 	if p.Container.ReadinessProbe.Enabled == nil {
-		if p.Kind == KindHeadlessDeployment {
+		if p.Kind == KindHeadlessDeployment || p.Kind == KindDaemonSet {
 			p.Container.ReadinessProbe.Enabled = &falseValue
 		} else {
 			p.Container.ReadinessProbe.Enabled = &trueValue
@@ -655,13 +844,31 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 	if p.StrategyType == StrategyTypeUnknown {
 		p.StrategyType = StrategyTypeRollingUpdate
 	}
-	if p.StrategyType == StrategyTypeAtomicUpdate {
+	if p.StrategyType == StrategyTypeAtomicUpdate || p.StrategyType == StrategyTypeBlueGreen {
 		p.AtomicID = releaseID
 		if p.AtomicID == "" {
 			p.AtomicID = p.BuildVersion
 		}
 	}
 
+	// keep as many configs and secrets as the workloads keep revisions to roll back to
+	if p.ImmutableConfigs.Enabled && p.ImmutableConfigs.Keep == 0 {
+		p.ImmutableConfigs.Keep = 10
+	}
+
+	if p.Inventory.Prune == nil {
+		p.Inventory.Prune = &trueValue
+	}
+
+	if p.Kind == KindDaemonSet {
+		// a daemonset runs a single pod per node, so replace them one node at a time like kubernetes does by default
+		if p.RollingUpdate.MaxSurge == "" {
+			p.RollingUpdate.MaxSurge = "0"
+		}
+		if p.RollingUpdate.MaxUnavailable == "" {
+			p.RollingUpdate.MaxUnavailable = "1"
+		}
+	}
 	if p.RollingUpdate.MaxSurge == "" {
 		p.RollingUpdate.MaxSurge = "25%"
 	}
@@ -672,6 +879,37 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 		p.RollingUpdate.Timeout = "5m"
 	}
 
+	if p.StrategyType == StrategyTypeBlueGreen {
+		if p.BlueGreen.AutoPromote == nil {
+			p.BlueGreen.AutoPromote = &trueValue
+		}
+		if p.BlueGreen.KeepPrevious == "" {
+			p.BlueGreen.KeepPrevious = "1h"
+		}
+	}
+
+	for _, hook := range []*HookParams{p.Hooks.PreDeploy, p.Hooks.PostDeploy} {
+		if hook == nil {
+			continue
+		}
+		if hook.Timeout == "" {
+			hook.Timeout = "10m"
+		}
+		if hook.BackoffLimit == nil || *hook.BackoffLimit < 0 {
+			defaultHookBackoffLimit := 0
+			hook.BackoffLimit = &defaultHookBackoffLimit
+		}
+	}
+
+	if len(p.Canary.Analysis.Metrics) > 0 {
+		if p.Canary.Analysis.Interval == "" {
+			p.Canary.Analysis.Interval = "1m"
+		}
+		if p.Canary.Analysis.Iterations == 0 {
+			p.Canary.Analysis.Iterations = 5
+		}
+	}
+
 	if p.Replicas == 0 && p.StrategyType == StrategyTypeRecreate {
 		p.Replicas = 1
 	}
@@ -723,6 +961,9 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 		defaultBackoffLimit := 6
 		p.BackoffLimit = &defaultBackoffLimit
 	}
+	if p.WaitForCompletion && p.CompletionTimeout == "" {
+		p.CompletionTimeout = "30m"
+	}
 
 	if p.Kind == KindStatefulset {
 		if p.PodManagementPolicy == "" {
@@ -751,6 +992,26 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 
 }
 
+// UseImmutableConfigs returns true if configs and secrets are created per release with a content hash in their name, so a rollback restores them as well
+func (p *Params) UseImmutableConfigs() bool {
+	return p.ImmutableConfigs.Enabled && p.Kind != KindConfig && p.Kind != KindSecret && p.Kind != KindConfigToFile
+}
+
+// UseInventoryPruning returns true if a release deletes the objects the previous release of its track rendered and it no longer does;
+// atomic updates and blue-green releases leave the objects of earlier releases to their own cleanup, and canaries share objects with the stable track
+func (p *Params) UseInventoryPruning() bool {
+	if p.Inventory.Prune == nil || !*p.Inventory.Prune || p.StrategyType == StrategyTypeAtomicUpdate || p.StrategyType == StrategyTypeBlueGreen {
+		return false
+	}
+
+	return p.Action == ActionDeploySimple || p.Action == ActionDiffSimple || p.Action == ActionDeployStable || p.Action == ActionDiffStable
+}
+
+// UsesKedaAutoscaler returns true if a keda scaled object scales the deployment instead of a horizontal pod autoscaler
+func (p *Params) UsesKedaAutoscaler() bool {
+	return p.Autoscale.Enabled != nil && *p.Autoscale.Enabled && p.Autoscale.Type == AutoscalerTypeKeda
+}
+
 func (p *Params) HasSecrets() bool {
 	if len(p.Secrets.Keys) > 0 {
 		return true
@@ -769,6 +1030,44 @@ func (p *Params) HasSecrets() bool {
 	return false
 }
 
+// UseProgressiveCanary returns true if traffic is shifted to the canary in steps using nginx ingress canary weights
+func (p *Params) UseProgressiveCanary() bool {
+	return p.Kind == KindDeployment && len(p.Canary.Steps) > 0
+}
+
+// UseRollbackOnFailure returns true if a failed deploy-simple release gets restored to the previous deployment revision and configs
+func (p *Params) UseRollbackOnFailure() bool {
+	return p.RollbackOnFailure && p.Action == ActionDeploySimple && (p.Kind == KindDeployment || p.Kind == KindHeadlessDeployment)
+}
+
+// CompletionTimeoutDuration returns the parsed time to wait for a job to complete, or zero if it isn't set or invalid
+func (p *Params) CompletionTimeoutDuration() time.Duration {
+	timeout, err := time.ParseDuration(p.CompletionTimeout)
+	if err != nil {
+		return 0
+	}
+	return timeout
+}
+
+// UsePreDeployHook returns true if a hook job runs before the manifests of a deploy action get applied
+func (p *Params) UsePreDeployHook() bool {
+	return p.Hooks.PreDeploy != nil && p.isDeployAction()
+}
+
+// UsePostDeployHook returns true if a hook job runs after the workload of a deploy action has rolled out
+func (p *Params) UsePostDeployHook() bool {
+	return p.Hooks.PostDeploy != nil && p.isDeployAction()
+}
+
+func (p *Params) isDeployAction() bool {
+	return p.Action == ActionDeploySimple || p.Action == ActionDeployCanary || p.Action == ActionDeployStable
+}
+
+// UseCanaryAnalysis returns true if the canary gets analyzed against the stable track before it's allowed to be promoted
+func (p *Params) UseCanaryAnalysis() bool {
+	return p.Kind == KindDeployment && len(p.Canary.Analysis.Metrics) > 0
+}
+
 func (p *Params) initializeSidecarDefaults(sidecar *SidecarParams) {
 	switch sidecar.Type {
 	case SidecarTypeOpenresty:
@@ -831,247 +1130,397 @@ func (p *Params) ValidateRequiredProperties() (bool, []error, []string) {
 
 	errors := []error{}
 	warnings := []string{}
+	for _, finding := range p.Validate() {
+		if finding.Severity == SeverityWarning {
+			warnings = append(warnings, finding.Error())
+			continue
+		}
+		errors = append(errors, finding)
+	}
+
+	return len(errors) == 0, errors, warnings
+}
+
+// Validate returns the errors and warnings for the parameters, each for the parameter it applies to and with a hint how to fix it
+func (p *Params) Validate() (findings []ValidationError) {
+
+	findings = []ValidationError{}
 
 	// validate app params
 	if p.App == "" {
-		errors = append(errors, fmt.Errorf("Application name is required; either define an app label or use app property on this stage"))
+		findings = append(findings, newValidationError("app", "Application name is required; either define an app label or use app property on this stage"))
 	}
 	if p.Namespace == "" {
-		errors = append(errors, fmt.Errorf("Namespace is required; either use credentials with a defaultNamespace or set it via namespace property on this stage"))
+		findings = append(findings, newValidationError("namespace", "Namespace is required; either use credentials with a defaultNamespace or set it via namespace property on this stage"))
+	}
+	if _, err := ParseKubernetesMinorVersion(p.KubernetesVersion); p.KubernetesVersion != "" && err != nil {
+		findings = append(findings, newValidationError("kubernetesVersion", "Kubernetes version %v is invalid; set it via kubernetesVersion property on this stage to a version like 1.25 or 1.25.8-gke.1000", p.KubernetesVersion))
+	}
+	if p.UnknownParams != UnknownParamsUnknown && p.UnknownParams != UnknownParamsWarning && p.UnknownParams != UnknownParamsError {
+		findings = append(findings, newValidationError("unknownParams", "Unknown params %v is not supported; set it via unknownParams property on this stage; allowed values are warning or error", p.UnknownParams))
 	}
 
-	if p.Action == ActionRollbackCanary || p.Kind == KindConfig || p.Kind == KindConfigToFile {
+	if p.Kind == KindSecret {
+		if len(p.Secrets.Keys) == 0 {
+			findings = append(findings, newValidationError("secrets.keys", "Secrets keys are required for kind secret; set them via secrets.keys property on this stage"))
+		}
+		if p.Action != ActionDeploySimple && p.Action != ActionDiffSimple && p.Action != ActionDelete && p.Action != ActionDiffDelete {
+			findings = append(findings, newValidationError("action", "Action %v can't be used with kind secret, which is shared by other applications instead of having a canary and stable track; use action deploy-simple", p.Action))
+		}
+	}
+	if p.ImmutableConfigs.Enabled && (p.Kind == KindConfig || p.Kind == KindSecret) {
+		findings = append(findings, newValidationError("immutableConfigs.enabled", "Immutable configs can't be used with kind %v, since other applications mount its configmap or secret by name; remove immutableConfigs from this stage", p.Kind))
+	}
+	if p.ImmutableConfigs.Keep < 0 {
+		findings = append(findings, newValidationError("immutableConfigs.keep", "Immutable configs keep %v is invalid; set it via immutableConfigs.keep property on this stage to the number of previous configmaps and secrets to keep", p.ImmutableConfigs.Keep))
+	}
+	if p.Kind != KindConfig && p.Kind != KindSecret && (p.Shared.Name != "" || p.Shared.AllowKeyRemoval || len(p.Shared.RestartDeployments) > 0) {
+		findings = append(findings, newValidationError("shared", "Shared properties only apply to kind config and secret; remove the shared property from this stage or use one of those kinds"))
+	}
+
+	if p.Action == ActionRollbackCanary || p.Kind == KindConfig || p.Kind == KindSecret || p.Kind == KindConfigToFile {
 		// the above properties are all you need for a rollback
-		return len(errors) == 0, errors, warnings
+		return findings
 	}
 
 	// validate container params
 	if p.Container.ImageRepository == "" {
-		errors = append(errors, fmt.Errorf("Image repository is required; set it via container.repository property on this stage"))
+		findings = append(findings, newValidationError("container.repository", "Image repository is required; set it via container.repository property on this stage"))
 	}
 	if p.Container.ImageName == "" {
-		errors = append(errors, fmt.Errorf("Image name is required; set it via container.name property on this stage"))
+		findings = append(findings, newValidationError("container.name", "Image name is required; set it via container.name property on this stage"))
 	}
 	if p.Container.ImageTag == "" {
-		errors = append(errors, fmt.Errorf("Image tag is required; set it via container.tag property on this stage"))
+		findings = append(findings, newValidationError("container.tag", "Image tag is required; set it via container.tag property on this stage"))
 	}
 	if p.Container.ImagePullPolicy == "" {
-		errors = append(errors, fmt.Errorf("Image pull policy is required; set it via container.imagePullPolicy property on this stage; allowed values are IfNotPresent or Always"))
+		findings = append(findings, newValidationError("container.imagePullPolicy", "Image pull policy is required; set it via container.imagePullPolicy property on this stage; allowed values are IfNotPresent or Always"))
 	}
 
 	// validate cpu params
 	if p.Container.CPU.Request == "" {
-		errors = append(errors, fmt.Errorf("Cpu request is required; set it via container.cpu.request property on this stage"))
+		findings = append(findings, newValidationError("container.cpu.request", "Cpu request is required; set it via container.cpu.request property on this stage"))
 	}
 
 	// validate memory params
 	if p.Container.Memory.Request == "" {
-		errors = append(errors, fmt.Errorf("Memory request is required; set it via container.memory.request property on this stage"))
+		findings = append(findings, newValidationError("container.memory.request", "Memory request is required; set it via container.memory.request property on this stage"))
 	}
 	if p.Container.Memory.Limit == "" {
-		errors = append(errors, fmt.Errorf("Memory limit is required; set it via container.memory.limit property on this stage"))
+		findings = append(findings, newValidationError("container.memory.limit", "Memory limit is required; set it via container.memory.limit property on this stage"))
 	}
 
 	// validate params for rollingupdate
 	if p.StrategyType == StrategyTypeUnknown {
-		errors = append(errors, fmt.Errorf("StrategyType is required; set it via strategytype property on this stage; valid values are RollingUpdate, Recreate or AtomicUpdate"))
+		findings = append(findings, newValidationError("strategytype", "StrategyType is required; set it via strategytype property on this stage; valid values are RollingUpdate, Recreate, AtomicUpdate or BlueGreen"))
 	}
 	if p.StrategyType == StrategyTypeAtomicUpdate && p.Action != ActionDeploySimple {
-		errors = append(errors, fmt.Errorf("StrategyType: AtomicUpdate can't be used in combination with other actions than deploy-simple as this would allow multiple versions to be served. Please use action: deploy-simple"))
+		findings = append(findings, newValidationError("strategytype", "StrategyType: AtomicUpdate can't be used in combination with other actions than deploy-simple as this would allow multiple versions to be served. Please use action: deploy-simple"))
+	}
+	if p.StrategyType == StrategyTypeBlueGreen {
+		if p.Kind != KindDeployment {
+			findings = append(findings, newValidationError("strategytype", "StrategyType: BlueGreen switches traffic with the service selector and can only be used with kind: deployment"))
+		}
+		if p.Action != ActionDeploySimple && p.Action != ActionDiffSimple && p.Action != ActionPromote && p.Action != ActionSwitchBack {
+			findings = append(findings, newValidationError("action", "StrategyType: BlueGreen can't be used in combination with other actions than deploy-simple, promote or switch-back"))
+		}
+		if _, err := time.ParseDuration(p.BlueGreen.KeepPrevious); err != nil {
+			findings = append(findings, newValidationError("bluegreen.keepPrevious", "BlueGreen keepPrevious %v is invalid; set bluegreen.keepPrevious to a duration like 1h", p.BlueGreen.KeepPrevious))
+		}
+		if len(p.BlueGreen.PreviewHosts) > 0 && p.Visibility != VisibilityPrivate && p.Visibility != VisibilityPublicWhitelist && p.Visibility != VisibilityApigee {
+			findings = append(findings, newValidationError("bluegreen.previewHosts", "BlueGreen preview hosts are served with an nginx ingress; use visibility private, public-whitelist or apigee or remove the bluegreen.previewHosts property"))
+		}
+	}
+	if (p.Action == ActionPromote || p.Action == ActionSwitchBack) && p.StrategyType != StrategyTypeBlueGreen {
+		findings = append(findings, newValidationError("action", "Action %v can only be used with strategytype: BlueGreen", p.Action))
+	}
+	for i, hook := range []*HookParams{p.Hooks.PreDeploy, p.Hooks.PostDeploy} {
+		if hook == nil {
+			continue
+		}
+		name := []string{"preDeploy", "postDeploy"}[i]
+		if p.Kind != KindDeployment && p.Kind != KindHeadlessDeployment && p.Kind != KindStatefulset {
+			findings = append(findings, newValidationError("hooks."+name, "Hook %v can only be used with kind deployment, headless-deployment or statefulset", name))
+		}
+		if len(hook.Command) == 0 && len(hook.Args) == 0 {
+			findings = append(findings, newValidationError("hooks."+name+".command", "Hook %v needs a command or args to run; set it via hooks.%v.command or hooks.%v.args property on this stage", name, name, name))
+		}
+		if _, err := time.ParseDuration(hook.Timeout); err != nil {
+			findings = append(findings, newValidationError("hooks."+name+".timeout", "Hook %v timeout %v is invalid; set hooks.%v.timeout to a duration like 10m", name, hook.Timeout, name))
+		}
 	}
 	if p.RollingUpdate.MaxSurge == "" {
-		errors = append(errors, fmt.Errorf("Rollingupdate max surge is required; set it via rollingupdate.maxsurge property on this stage"))
+		findings = append(findings, newValidationError("rollingupdate.maxsurge", "Rollingupdate max surge is required; set it via rollingupdate.maxsurge property on this stage"))
 	}
 	if p.RollingUpdate.MaxSurge == "" {
-		errors = append(errors, fmt.Errorf("Rollingupdate max surge is required; set it via rollingupdate.maxsurge property on this stage"))
+		findings = append(findings, newValidationError("rollingupdate.maxsurge", "Rollingupdate max surge is required; set it via rollingupdate.maxsurge property on this stage"))
 	}
 	if p.RollingUpdate.MaxUnavailable == "" {
-		errors = append(errors, fmt.Errorf("Rollingupdate max unavailable is required; set it via rollingupdate.maxunavailable property on this stage"))
+		findings = append(findings, newValidationError("rollingupdate.maxunavailable", "Rollingupdate max unavailable is required; set it via rollingupdate.maxunavailable property on this stage"))
+	}
+
+	if (p.Action == ActionRunCronJob || p.Action == ActionSuspendCronJob || p.Action == ActionResumeCronJob) && p.Kind != KindCronJob {
+		findings = append(findings, newValidationError("action", "Action %v can only be used with kind cronjob", p.Action))
+	}
+	if p.WaitForCompletion {
+		if p.Kind != KindJob && !(p.Kind == KindCronJob && p.Action == ActionRunCronJob) {
+			findings = append(findings, newValidationError("waitForCompletion", "WaitForCompletion can only be used with kind job or with action run-cronjob"))
+		}
+		if _, err := time.ParseDuration(p.CompletionTimeout); err != nil {
+			findings = append(findings, newValidationError("completionTimeout", "CompletionTimeout %v is invalid; set completionTimeout to a duration like 30m", p.CompletionTimeout))
+		}
 	}
 
 	if p.Kind == KindJob || p.Kind == KindCronJob {
 		if p.Kind == KindCronJob {
 			if p.Schedule == "" {
-				errors = append(errors, fmt.Errorf("Schedule is required for a cronjob; set it via schedule property on this stage"))
+				findings = append(findings, newValidationError("schedule", "Schedule is required for a cronjob; set it via schedule property on this stage"))
 			}
 
 			if p.ConcurrencyPolicy != "Allow" && p.ConcurrencyPolicy != "Forbid" && p.ConcurrencyPolicy != "Replace" {
-				errors = append(errors, fmt.Errorf("ConcurrencyPolicy is invalid; allowed values for concurrencypolicy property are Allow, Forbid or Replace"))
+				findings = append(findings, newValidationError("concurrencypolicy", "ConcurrencyPolicy is invalid; allowed values for concurrencypolicy property are Allow, Forbid or Replace"))
 			}
 		}
 
 		// the above properties are all you need for a worker
-		return len(errors) == 0, errors, warnings
+		return findings
 	}
 
 	if p.Kind == KindStatefulset {
 		if p.PodManagementPolicy != "OrderedReady" && p.PodManagementPolicy != "Parallel" {
-			errors = append(errors, fmt.Errorf("PodManagementPolicy is required for a statefulset; allowed values for podmanagementpolicy property are OrderedReady or Parallel"))
+			findings = append(findings, newValidationError("podManagementpolicy", "PodManagementPolicy is required for a statefulset; allowed values for podmanagementpolicy property are OrderedReady or Parallel"))
 		}
 		if p.StorageClass == "" {
-			errors = append(errors, fmt.Errorf("StorageClass is required for a statefulset; set it via storageclass property on this stage"))
+			findings = append(findings, newValidationError("storageclass", "StorageClass is required for a statefulset; set it via storageclass property on this stage"))
 		}
 		if p.StorageSize == "" {
-			errors = append(errors, fmt.Errorf("StorageSize is required for a statefulset; set it via storagesize property on this stage"))
+			findings = append(findings, newValidationError("storagesize", "StorageSize is required for a statefulset; set it via storagesize property on this stage"))
 		}
 		if p.StorageMountPath == "" {
-			errors = append(errors, fmt.Errorf("StorageMountPath is required for a statefulset; set it via storagemountpath property on this stage"))
+			findings = append(findings, newValidationError("storagemountpath", "StorageMountPath is required for a statefulset; set it via storagemountpath property on this stage"))
+		}
+	}
+	if p.Kind == KindDaemonSet {
+		if p.Action != ActionDeploySimple && p.Action != ActionDiffSimple && p.Action != ActionDelete && p.Action != ActionDiffDelete {
+			findings = append(findings, newValidationError("action", "Action %v can't be used with kind daemonset, which runs a pod on every node instead of a canary and stable track; use action deploy-simple", p.Action))
+		}
+		if p.StrategyType != StrategyTypeRollingUpdate {
+			findings = append(findings, newValidationError("strategytype", "StrategyType %v can't be used with kind daemonset; use RollingUpdate and tune it with rollingupdate.maxunavailable and rollingupdate.maxsurge", p.StrategyType))
+		}
+		if isZeroIntOrPercent(p.RollingUpdate.MaxSurge) && isZeroIntOrPercent(p.RollingUpdate.MaxUnavailable) {
+			findings = append(findings, newValidationError("rollingupdate.maxunavailable", "Rollingupdate max surge and max unavailable can't both be 0 for a daemonset; set rollingupdate.maxunavailable or rollingupdate.maxsurge to at least 1"))
 		}
 	}
 	// validate params with respect to incoming requests
 	if p.Kind == KindDeployment {
 		if p.Visibility == VisibilityUnknown || (p.Visibility != VisibilityPrivate && p.Visibility != VisibilityPublic && p.Visibility != VisibilityIAP && p.Visibility != VisibilityESP && p.Visibility != VisibilityESPv2 && p.Visibility != VisibilityPublicWhitelist && p.Visibility != VisibilityApigee) {
-			errors = append(errors, fmt.Errorf("Visibility property is required; set it via visibility property on this stage; allowed values are private, iap, esp, public-whitelist, public or apigee"))
+			findings = append(findings, newValidationError("visibility", "Visibility property is required; set it via visibility property on this stage; allowed values are private, iap, esp, public-whitelist, public or apigee"))
 		}
 		if p.Visibility == VisibilityPublic {
-			warnings = append(warnings, "Visibility public is deprecated, please use esp or apigee.")
+			findings = append(findings, newValidationWarning("visibility", "Visibility public is deprecated, please use esp or apigee."))
 		}
 		if p.Visibility == VisibilityIAP && p.IapOauthCredentialsClientID == "" {
-			errors = append(errors, fmt.Errorf("With visibility 'iap' property iapOauthClientID is required; set it via iapOauthClientID property on this stage"))
+			findings = append(findings, newValidationError("iapOauthClientID", "With visibility 'iap' property iapOauthClientID is required; set it via iapOauthClientID property on this stage"))
 		}
 		if p.Visibility == VisibilityIAP && p.IapOauthCredentialsClientSecret == "" {
-			errors = append(errors, fmt.Errorf("With visibility 'iap' property iapOauthClientSecret is required; set it via iapOauthClientSecret property on this stage"))
+			findings = append(findings, newValidationError("iapOauthClientSecret", "With visibility 'iap' property iapOauthClientSecret is required; set it via iapOauthClientSecret property on this stage"))
 		}
 
 		if (p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2) && (!p.UseGoogleCloudCredentials && (p.WorkloadIdentity == nil || !*p.WorkloadIdentity)) {
-			errors = append(errors, fmt.Errorf("With visibility 'esp' property useGoogleCloudCredentials or workloadIdentity is required; set useGoogleCloudCredentials: true or workloadIdentity: true on this stage"))
+			findings = append(findings, newValidationError("useGoogleCloudCredentials", "With visibility 'esp' property useGoogleCloudCredentials or workloadIdentity is required; set useGoogleCloudCredentials: true or workloadIdentity: true on this stage"))
 		}
 		if (p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2) && ((p.DisableServiceAccountKeyRotation == nil || !*p.DisableServiceAccountKeyRotation) && (p.WorkloadIdentity == nil || !*p.WorkloadIdentity)) {
-			errors = append(errors, fmt.Errorf("With visibility 'esp' property disableServiceAccountKeyRotation is required; set disableServiceAccountKeyRotation: true on this stage"))
+			findings = append(findings, newValidationError("disableServiceAccountKeyRotation", "With visibility 'esp' property disableServiceAccountKeyRotation is required; set disableServiceAccountKeyRotation: true on this stage"))
 		}
 		if (p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2) && (p.EspEndpointsProjectID == "") {
-			errors = append(errors, fmt.Errorf("With visibility 'esp' property espEndpointsProjectID is required; provide id of the 'endpoints' project"))
+			findings = append(findings, newValidationError("espEndpointsProjectID", "With visibility 'esp' property espEndpointsProjectID is required; provide id of the 'endpoints' project"))
 		}
 		if (p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2) && p.EspOpenAPIYamlPath == "" {
-			errors = append(errors, fmt.Errorf("With visibility 'esp' property espOpenapiYamlPath is required; set espOpenapiYamlPath to the path towards openapi.yaml"))
+			findings = append(findings, newValidationError("espOpenapiYamlPath", "With visibility 'esp' property espOpenapiYamlPath is required; set espOpenapiYamlPath to the path towards openapi.yaml"))
 		}
 		if p.EspServiceTypeClusterIP && (p.Visibility != VisibilityESP && p.Visibility != VisibilityESPv2) {
-			errors = append(errors, fmt.Errorf("With EspServiceTypeClusterIP set to true, visibility needs to be set to 'esp' or 'espv2'"))
+			findings = append(findings, newValidationError("espServiceTypeClusterIP", "With EspServiceTypeClusterIP set to true, visibility needs to be set to 'esp' or 'espv2'"))
 		}
 		if (p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2) && len(p.Hosts) < 1 {
-			errors = append(errors, fmt.Errorf("With visibility 'esp' property at least one host is required. Set it via hosts array property on this stage"))
+			findings = append(findings, newValidationError("hosts", "With visibility 'esp' property at least one host is required. Set it via hosts array property on this stage"))
 		}
 
 		if p.Visibility == VisibilityApigee && p.Request.AuthSecret == "" {
-			errors = append(errors, fmt.Errorf("With visibility 'apigee' property authsecret is required; set it via authsecret property for request on this stage"))
+			findings = append(findings, newValidationError("request.authsecret", "With visibility 'apigee' property authsecret is required; set it via authsecret property for request on this stage"))
 		}
 
 		if len(p.Hosts) == 0 {
-			errors = append(errors, fmt.Errorf("At least one host is required; set it via hosts array property on this stage"))
+			findings = append(findings, newValidationError("hosts", "At least one host is required; set it via hosts array property on this stage"))
+		}
+
+		if len(p.Canary.Steps) > 0 {
+			if p.Visibility != VisibilityPrivate && p.Visibility != VisibilityPublicWhitelist && p.Visibility != VisibilityApigee {
+				findings = append(findings, newValidationError("canary.steps", "Canary steps shift traffic with nginx ingress canary weights; use visibility private, public-whitelist or apigee or remove the canary.steps property"))
+			}
+			previousWeight := 0
+			for i, step := range p.Canary.Steps {
+				if step.Weight <= 0 || step.Weight > 100 {
+					findings = append(findings, newValidationError(fmt.Sprintf("canary.steps[%v].weight", i), "Canary step %v has weight %v; set canary.steps[].weight to a percentage larger than 0 and at most 100", i+1, step.Weight))
+				} else if step.Weight < previousWeight {
+					findings = append(findings, newValidationError(fmt.Sprintf("canary.steps[%v].weight", i), "Canary step %v has weight %v, which is lower than the previous step; canary.steps[].weight can't decrease", i+1, step.Weight))
+				}
+				if step.Weight > previousWeight {
+					previousWeight = step.Weight
+				}
+				if _, err := time.ParseDuration(step.Pause); step.Pause != "" && err != nil {
+					findings = append(findings, newValidationError(fmt.Sprintf("canary.steps[%v].pause", i), "Canary step %v has invalid pause %v; set canary.steps[].pause to a duration like 5m", i+1, step.Pause))
+				}
+			}
+		}
+
+		if len(p.Canary.Analysis.Metrics) > 0 {
+			if p.Canary.Analysis.PrometheusURL == "" {
+				findings = append(findings, newValidationError("canary.analysis.prometheusURL", "Canary analysis requires a Prometheus server; set it via canary.analysis.prometheusURL property on this stage"))
+			}
+			if _, err := time.ParseDuration(p.Canary.Analysis.Interval); err != nil {
+				findings = append(findings, newValidationError("canary.analysis.interval", "Canary analysis interval %v is invalid; set canary.analysis.interval to a duration like 1m", p.Canary.Analysis.Interval))
+			}
+			if p.Canary.Analysis.Iterations < 0 {
+				findings = append(findings, newValidationError("canary.analysis.iterations", "Canary analysis iterations %v is invalid; set canary.analysis.iterations to a number larger than 0", p.Canary.Analysis.Iterations))
+			}
+			for i, metric := range p.Canary.Analysis.Metrics {
+				if metric.Name == "" {
+					findings = append(findings, newValidationError(fmt.Sprintf("canary.analysis.metrics[%v].name", i), "Canary analysis metric %v has no name; set canary.analysis.metrics[].name", i+1))
+				}
+				if metric.Query == "" {
+					findings = append(findings, newValidationError(fmt.Sprintf("canary.analysis.metrics[%v].query", i), "Canary analysis metric %v has no query; set canary.analysis.metrics[].query to a PromQL query", i+1))
+				} else if _, err := template.New(metric.Name).Parse(metric.Query); err != nil {
+					findings = append(findings, newValidationError(fmt.Sprintf("canary.analysis.metrics[%v].query", i), "Canary analysis metric %v has an invalid query template: %v", i+1, err))
+				}
+				if metric.MaxRatio <= 0 && metric.Max == nil {
+					findings = append(findings, newValidationError(fmt.Sprintf("canary.analysis.metrics[%v].maxRatio", i), "Canary analysis metric %v has no threshold; set canary.analysis.metrics[].maxRatio and/or canary.analysis.metrics[].max", i+1))
+				}
+			}
 		}
 		for _, host := range p.Hosts {
 			if len(host) > 253 {
-				errors = append(errors, fmt.Errorf("Host %v is longer than the allowed 253 characters, which is invalid for DNS; please shorten your host", host))
+				findings = append(findings, newValidationError("hosts", "Host %v is longer than the allowed 253 characters, which is invalid for DNS; please shorten your host", host))
 				break
 			}
 
 			matchesInvalidChars, _ := regexp.MatchString("[^a-zA-Z0-9-.]", host)
 			if matchesInvalidChars {
-				errors = append(errors, fmt.Errorf("Host %v has invalid characters; only a-z, 0-9, - and . are allowed; please fix your host", host))
+				findings = append(findings, newValidationError("hosts", "Host %v has invalid characters; only a-z, 0-9, - and . are allowed; please fix your host", host))
 			}
 
 			hostLabels := strings.Split(host, ".")
 			for _, label := range hostLabels {
 				if len(label) > 63 {
-					errors = append(errors, fmt.Errorf("Host %v has label %v - the parts between dots - that is longer than the allowed 63 characters, which is invalid for DNS; please shorten your host label", host, label))
+					findings = append(findings, newValidationError("hosts", "Host %v has label %v - the parts between dots - that is longer than the allowed 63 characters, which is invalid for DNS; please shorten your host label", host, label))
 				}
 			}
 		}
 
 		for _, host := range p.InternalHosts {
 			if len(host) > 253 {
-				errors = append(errors, fmt.Errorf("Internal host %v is longer than the allowed 253 characters, which is invalid for DNS; please shorten your host", host))
+				findings = append(findings, newValidationError("internalhosts", "Internal host %v is longer than the allowed 253 characters, which is invalid for DNS; please shorten your host", host))
 				break
 			}
 
 			matchesInvalidChars, _ := regexp.MatchString("[^a-zA-Z0-9-.]", host)
 			if matchesInvalidChars {
-				errors = append(errors, fmt.Errorf("Internal host %v has invalid characters; only a-z, 0-9, - and . are allowed; please fix your host", host))
+				findings = append(findings, newValidationError("internalhosts", "Internal host %v has invalid characters; only a-z, 0-9, - and . are allowed; please fix your host", host))
 			}
 
 			hostLabels := strings.Split(host, ".")
 			for _, label := range hostLabels {
 				if len(label) > 63 {
-					errors = append(errors, fmt.Errorf("Internal host %v has label %v - the parts between dots - that is longer than the allowed 63 characters, which is invalid for DNS; please shorten your host label", host, label))
+					findings = append(findings, newValidationError("internalhosts", "Internal host %v has label %v - the parts between dots - that is longer than the allowed 63 characters, which is invalid for DNS; please shorten your host label", host, label))
 				}
 			}
 		}
 	}
 
 	if p.Basepath == "" {
-		errors = append(errors, fmt.Errorf("Basepath property is required; set it via basepath property on this stage"))
+		findings = append(findings, newValidationError("basepath", "Basepath property is required; set it via basepath property on this stage"))
 	}
 	if p.Container.Port <= 0 {
-		errors = append(errors, fmt.Errorf("Container port must be larger than zero; set it via container.port property on this stage"))
+		findings = append(findings, newValidationError("container.port", "Container port must be larger than zero; set it via container.port property on this stage"))
 	}
 
 	// validate autoscale params
 	if p.Autoscale.MinReplicas <= 0 {
-		errors = append(errors, fmt.Errorf("Autoscaling min replicas must be larger than zero; set it via autoscale.min property on this stage"))
+		findings = append(findings, newValidationError("autoscale.min", "Autoscaling min replicas must be larger than zero; set it via autoscale.min property on this stage"))
 	}
 	if p.Autoscale.MaxReplicas <= 0 {
-		errors = append(errors, fmt.Errorf("Autoscaling max replicas must be larger than zero; set it via autoscale.max property on this stage"))
+		findings = append(findings, newValidationError("autoscale.max", "Autoscaling max replicas must be larger than zero; set it via autoscale.max property on this stage"))
 	}
 	if p.Autoscale.CPUPercentage <= 0 {
-		errors = append(errors, fmt.Errorf("Autoscaling cpu percentage must be larger than zero; set it via autoscale.cpu property on this stage"))
+		findings = append(findings, newValidationError("autoscale.cpu", "Autoscaling cpu percentage must be larger than zero; set it via autoscale.cpu property on this stage"))
+	}
+	for i, metric := range p.Autoscale.Metrics {
+		findings = p.validateAutoscaleMetric(metric, fmt.Sprintf("autoscale.metrics[%v]", i), findings)
+	}
+	if p.Autoscale.Type != AutoscalerTypeUnknown && p.Autoscale.Type != AutoscalerTypeHPA && p.Autoscale.Type != AutoscalerTypeKeda {
+		findings = append(findings, newValidationError("autoscale.type", "Autoscaler type %v is not supported; set it via autoscale.type property on this stage; allowed values are hpa or keda", p.Autoscale.Type))
+	}
+	if p.Autoscale.Type == AutoscalerTypeKeda {
+		findings = p.validateKedaAutoscaler(findings)
 	}
+	findings = p.validateAutoscaleScalingRules(p.Autoscale.Behavior.ScaleUp, "autoscale.behavior.scaleUp", findings)
+	findings = p.validateAutoscaleScalingRules(p.Autoscale.Behavior.ScaleDown, "autoscale.behavior.scaleDown", findings)
 
 	// validate liveness params
 	if p.Container.LivenessProbe.Path == "" {
-		errors = append(errors, fmt.Errorf("Liveness path is required; set it via container.liveness.path property on this stage"))
+		findings = append(findings, newValidationError("container.liveness.path", "Liveness path is required; set it via container.liveness.path property on this stage"))
 	}
 	if p.Container.LivenessProbe.Port <= 0 {
-		errors = append(errors, fmt.Errorf("Liveness port must be larger than zero; set it via container.liveness.port property on this stage"))
+		findings = append(findings, newValidationError("container.liveness.port", "Liveness port must be larger than zero; set it via container.liveness.port property on this stage"))
 	}
 	if p.Container.LivenessProbe.InitialDelaySeconds <= 0 {
-		errors = append(errors, fmt.Errorf("Liveness initial delay must be larger than zero; set it via container.liveness.delay property on this stage"))
+		findings = append(findings, newValidationError("container.liveness.delay", "Liveness initial delay must be larger than zero; set it via container.liveness.delay property on this stage"))
 	}
 	if p.Container.LivenessProbe.TimeoutSeconds <= 0 {
-		errors = append(errors, fmt.Errorf("Liveness timeout must be larger than zero; set it via container.liveness.timeout property on this stage"))
+		findings = append(findings, newValidationError("container.liveness.timeout", "Liveness timeout must be larger than zero; set it via container.liveness.timeout property on this stage"))
 	}
 	if p.Container.LivenessProbe.PeriodSeconds <= 0 {
-		errors = append(errors, fmt.Errorf("Liveness period must be larger than zero; set it via container.liveness.period property on this stage"))
+		findings = append(findings, newValidationError("container.liveness.period", "Liveness period must be larger than zero; set it via container.liveness.period property on this stage"))
 	}
 
 	// validate readiness params
 	if p.Container.ReadinessProbe.Path == "" {
-		errors = append(errors, fmt.Errorf("Readiness path is required; set it via container.readiness.path property on this stage"))
+		findings = append(findings, newValidationError("container.readiness.path", "Readiness path is required; set it via container.readiness.path property on this stage"))
 	}
 	if p.Container.ReadinessProbe.Port <= 0 {
-		errors = append(errors, fmt.Errorf("Readiness port must be larger than zero; set it via container.readiness.port property on this stage"))
+		findings = append(findings, newValidationError("container.readiness.port", "Readiness port must be larger than zero; set it via container.readiness.port property on this stage"))
 	}
 	if p.Container.ReadinessProbe.TimeoutSeconds <= 0 {
-		errors = append(errors, fmt.Errorf("Readiness timeout must be larger than zero; set it via container.readiness.timeout property on this stage"))
+		findings = append(findings, newValidationError("container.readiness.timeout", "Readiness timeout must be larger than zero; set it via container.readiness.timeout property on this stage"))
 	}
 	if p.Container.ReadinessProbe.PeriodSeconds <= 0 {
-		errors = append(errors, fmt.Errorf("Readiness period must be larger than zero; set it via container.liveness.period property on this stage"))
+		findings = append(findings, newValidationError("container.readiness.period", "Readiness period must be larger than zero; set it via container.liveness.period property on this stage"))
 	}
 
 	// validate metrics params
 	if p.Container.Metrics.Scrape == nil {
-		errors = append(errors, fmt.Errorf("Metrics scrape is required; set it via container.metrics.scrape property on this stage; allowed values are true or false"))
+		findings = append(findings, newValidationError("container.metrics.scrape", "Metrics scrape is required; set it via container.metrics.scrape property on this stage; allowed values are true or false"))
 	}
 	if p.Container.Metrics.Scrape != nil && *p.Container.Metrics.Scrape {
 		if p.Container.Metrics.Path == "" {
-			errors = append(errors, fmt.Errorf("Metrics path is required; set it via container.metrics.path property on this stage"))
+			findings = append(findings, newValidationError("container.metrics.path", "Metrics path is required; set it via container.metrics.path property on this stage"))
 		}
 		if p.Container.Metrics.Port <= 0 {
-			errors = append(errors, fmt.Errorf("Metrics port must be larger than zero; set it via container.metrics.port property on this stage"))
+			findings = append(findings, newValidationError("container.metrics.port", "Metrics port must be larger than zero; set it via container.metrics.port property on this stage"))
 		}
 	}
 
 	// The "sidecar" field is deprecated, so it can be empty. But if it's specified, then we validate it.
 	if p.Sidecar.Type != "" && p.Sidecar.Type != "none" {
-		errors = p.validateSidecar(&p.Sidecar, errors)
-		warnings = append(warnings, "The sidecar field is deprecated, the sidecars list should be used instead.")
+		findings = p.validateSidecar(&p.Sidecar, "sidecar", findings)
+		findings = append(findings, newValidationWarning("sidecar", "The sidecar field is deprecated, the sidecars list should be used instead."))
 	}
 
 	// check if openresty was defined as deprecated sidecar type
 	hasOpenrestySidecar := p.Sidecar.Type == SidecarTypeOpenresty
 
 	// validate sidecars params
-	for _, sidecar := range p.Sidecars {
-		errors = p.validateSidecar(sidecar, errors)
+	for i, sidecar := range p.Sidecars {
+		findings = p.validateSidecar(sidecar, fmt.Sprintf("sidecars[%v]", i), findings)
 		if sidecar.Type == SidecarTypeOpenresty {
 			hasOpenrestySidecar = true
 		}
@@ -1079,55 +1528,160 @@ func (p *Params) ValidateRequiredProperties() (bool, []error, []string) {
 
 	// openresty sidecar cannot be added in combination with port 443
 	if hasOpenrestySidecar && p.Container.Port == 443 {
-		errors = append(errors, fmt.Errorf("Container port can't be 443 if an openresty sidecar is injected"))
+		findings = append(findings, newValidationError("container.port", "Container port can't be 443 if an openresty sidecar is injected"))
 	}
 
 	// validate load balance algorithm
 	if p.Request.LoadBalanceAlgorithm != "" && p.Request.LoadBalanceAlgorithm != "ewma" && p.Request.LoadBalanceAlgorithm != "round_robin" {
-		errors = append(errors, fmt.Errorf("Load balance algorithm is invalid; leave it empty or set request.loadbalance property on this stage to 'ewma' or 'round_robin'"))
+		findings = append(findings, newValidationError("request.loadbalance", "Load balance algorithm is invalid; leave it empty or set request.loadbalance property on this stage to 'ewma' or 'round_robin'"))
 	}
 
 	// check for visibility esp if openapi.yaml exists
 	if _, err := os.Stat(p.EspOpenAPIYamlPath); (p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2) && os.IsNotExist(err) {
-		errors = append(errors, fmt.Errorf("When using visibility: esp make sure to set clone: true and have openapi.yaml available in the working directory"))
+		findings = append(findings, newValidationError("espOpenapiYamlPath", "When using visibility: esp make sure to set clone: true and have openapi.yaml available in the working directory"))
 	}
 
-	return len(errors) == 0, errors, warnings
+	return findings
+}
+
+func (p *Params) validateAutoscaleMetric(metric AutoscaleMetricParams, field string, findings []ValidationError) []ValidationError {
+	switch metric.Type {
+	case AutoscaleMetricTypeMemory:
+		if metric.AverageUtilization <= 0 && metric.AverageValue == "" {
+			findings = append(findings, newValidationError(field+".averageUtilization", "Memory metric needs a target; set autoscale.metrics[].averageUtilization to a percentage of the memory request or autoscale.metrics[].averageValue to a quantity like 512Mi"))
+		}
+	case AutoscaleMetricTypePods, AutoscaleMetricTypeObject, AutoscaleMetricTypeExternal:
+		if metric.Name == "" {
+			findings = append(findings, newValidationError(field+".name", "The name of the %v metric is required; set it via autoscale.metrics[].name", metric.Type))
+		}
+		if metric.AverageUtilization != 0 {
+			findings = append(findings, newValidationError(field+".averageUtilization", "Average utilization only applies to memory metrics; set autoscale.metrics[].averageValue or autoscale.metrics[].value instead"))
+		}
+		if metric.Type == AutoscaleMetricTypePods && (metric.AverageValue == "" || metric.Value != "") {
+			findings = append(findings, newValidationError(field+".averageValue", "Pods metric needs an average value per pod as target; set it via autoscale.metrics[].averageValue"))
+		}
+		if metric.Type != AutoscaleMetricTypePods && metric.AverageValue == "" && metric.Value == "" {
+			findings = append(findings, newValidationError(field+".value", "The %v metric needs a target; set autoscale.metrics[].value for the total or autoscale.metrics[].averageValue for the value per pod", metric.Type))
+		}
+		if metric.Type == AutoscaleMetricTypeObject && (metric.Object.Kind == "" || metric.Object.Name == "") {
+			findings = append(findings, newValidationError(field+".object", "Object metric needs the object it describes; set autoscale.metrics[].object.kind and autoscale.metrics[].object.name, and autoscale.metrics[].object.apiVersion for other than core kinds"))
+		}
+	case AutoscaleMetricTypeUnknown:
+		findings = append(findings, newValidationError(field+".type", "The autoscale metric type is empty; set autoscale.metrics[].type; allowed values are memory, pods, object or external"))
+	default:
+		findings = append(findings, newValidationError(field+".type", "Autoscale metric type %v is not supported; set autoscale.metrics[].type; allowed values are memory, pods, object or external; cpu is set with autoscale.cpu", metric.Type))
+	}
+
+	if metric.AverageValue != "" && metric.Value != "" {
+		findings = append(findings, newValidationError(field+".value", "Autoscale metric has both value and averageValue; set only one of autoscale.metrics[].value and autoscale.metrics[].averageValue"))
+	}
+	if _, err := resource.ParseQuantity(metric.AverageValue); metric.AverageValue != "" && err != nil {
+		findings = append(findings, newValidationError(field+".averageValue", "Autoscale metric average value %v is not a valid quantity; set autoscale.metrics[].averageValue to a number or quantity like 100m or 512Mi", metric.AverageValue))
+	}
+	if _, err := resource.ParseQuantity(metric.Value); metric.Value != "" && err != nil {
+		findings = append(findings, newValidationError(field+".value", "Autoscale metric value %v is not a valid quantity; set autoscale.metrics[].value to a number or quantity like 100m or 10k", metric.Value))
+	}
+
+	return findings
+}
+
+// kedaTriggerRequiredMetadata are the metadata keys the keda scalers need to scale
+var kedaTriggerRequiredMetadata = map[KedaTriggerType][]string{
+	KedaTriggerTypePrometheus: {"serverAddress", "query", "threshold"},
+	KedaTriggerTypeGCPPubSub:  {"subscriptionName"},
+	KedaTriggerTypeCron:       {"timezone", "start", "end", "desiredReplicas"},
+}
+
+func (p *Params) validateKedaAutoscaler(findings []ValidationError) []ValidationError {
+	if len(p.Autoscale.Keda.Triggers) == 0 {
+		findings = append(findings, newValidationError("autoscale.keda.triggers", "Keda autoscaler needs at least one trigger; set autoscale.keda.triggers with type prometheus, gcp-pubsub or cron"))
+	}
+	if len(p.Autoscale.Metrics) > 0 {
+		findings = append(findings, newValidationError("autoscale.metrics", "Autoscale metrics only apply to autoscale type hpa; set autoscale.keda.triggers instead"))
+	}
+	if p.Autoscale.Safety.Enabled {
+		findings = append(findings, newValidationError("autoscale.safety.enabled", "Autoscale safety only applies to autoscale type hpa; add a prometheus trigger to autoscale.keda.triggers instead"))
+	}
+
+	for i, trigger := range p.Autoscale.Keda.Triggers {
+		field := fmt.Sprintf("autoscale.keda.triggers[%v]", i)
+		requiredMetadata, ok := kedaTriggerRequiredMetadata[trigger.Type]
+		if !ok {
+			findings = append(findings, newValidationError(field+".type", "Keda trigger type %v is not supported; set autoscale.keda.triggers[].type; allowed values are prometheus, gcp-pubsub or cron", trigger.Type))
+			continue
+		}
+		for _, key := range requiredMetadata {
+			if trigger.Metadata[key] == "" {
+				findings = append(findings, newValidationError(field+".metadata."+key, "Keda %v trigger needs metadata %v; set it via autoscale.keda.triggers[].metadata.%v", trigger.Type, key, key))
+			}
+		}
+		if trigger.Type == KedaTriggerTypeGCPPubSub && trigger.AuthenticationRef == "" && trigger.Metadata["credentialsFromEnv"] == "" && trigger.Metadata["credentialsFromEnvFile"] == "" {
+			findings = append(findings, newValidationError(field+".authenticationRef", "Keda gcp-pubsub trigger needs credentials; set autoscale.keda.triggers[].authenticationRef to a TriggerAuthentication, for example with workload identity"))
+		}
+	}
+
+	return findings
+}
+
+func (p *Params) validateAutoscaleScalingRules(rules *AutoscaleScalingRulesParams, field string, findings []ValidationError) []ValidationError {
+	if rules == nil {
+		return findings
+	}
+
+	if rules.StabilizationWindowSeconds != nil && (*rules.StabilizationWindowSeconds < 0 || *rules.StabilizationWindowSeconds > 3600) {
+		findings = append(findings, newValidationError(field+".stabilizationWindowSeconds", "Stabilization window of %v seconds is invalid; set it via %v.stabilizationWindowSeconds to at least 0 and at most 3600", *rules.StabilizationWindowSeconds, field))
+	}
+	if rules.SelectPolicy != AutoscaleSelectPolicyUnknown && rules.SelectPolicy != AutoscaleSelectPolicyMax && rules.SelectPolicy != AutoscaleSelectPolicyMin && rules.SelectPolicy != AutoscaleSelectPolicyDisabled {
+		findings = append(findings, newValidationError(field+".selectPolicy", "Select policy %v is not supported; set it via %v.selectPolicy; allowed values are Max, Min or Disabled", rules.SelectPolicy, field))
+	}
+	for i, policy := range rules.Policies {
+		if policy.Type != AutoscalePolicyTypePods && policy.Type != AutoscalePolicyTypePercent {
+			findings = append(findings, newValidationError(fmt.Sprintf("%v.policies[%v].type", field, i), "Scaling policy type %v is not supported; set it via %v.policies[].type; allowed values are Pods or Percent", policy.Type, field))
+		}
+		if policy.Value <= 0 {
+			findings = append(findings, newValidationError(fmt.Sprintf("%v.policies[%v].value", field, i), "Scaling policy value must be larger than zero; set it via %v.policies[].value", field))
+		}
+		if policy.PeriodSeconds <= 0 || policy.PeriodSeconds > 1800 {
+			findings = append(findings, newValidationError(fmt.Sprintf("%v.policies[%v].periodSeconds", field, i), "Scaling policy period of %v seconds is invalid; set it via %v.policies[].periodSeconds to larger than 0 and at most 1800", policy.PeriodSeconds, field))
+		}
+	}
+
+	return findings
 }
 
-func (p *Params) validateSidecar(sidecar *SidecarParams, errors []error) []error {
+func (p *Params) validateSidecar(sidecar *SidecarParams, field string, findings []ValidationError) []ValidationError {
 	switch sidecar.Type {
 	case SidecarTypeOpenresty:
 		break
 	case SidecarTypeCloudSQLProxy:
 		if sidecar.DbInstanceConnectionName == "" {
-			errors = append(errors, fmt.Errorf("The name of the DB instance used by this Cloud SQL Proxy is required; set it via sidecar.dbinstanceconnectionname property on this stage"))
+			findings = append(findings, newValidationError(field+".dbinstanceconnectionname", "The name of the DB instance used by this Cloud SQL Proxy is required; set it via sidecar.dbinstanceconnectionname property on this stage"))
 		}
 		if sidecar.SQLProxyPort == 0 {
-			errors = append(errors, fmt.Errorf("The port on which the Cloud SQL Proxy listens is required; set it via sidecar.sqlproxyport property on this stage"))
+			findings = append(findings, newValidationError(field+".sqlproxyport", "The port on which the Cloud SQL Proxy listens is required; set it via sidecar.sqlproxyport property on this stage"))
 		}
 	case SidecarTypeUnknown:
-		errors = append(errors, fmt.Errorf("The sidecar type is empty; set a type"))
+		findings = append(findings, newValidationError(field+".type", "The sidecar type is empty; set a type"))
 	}
 
 	if sidecar.Image == "" {
-		errors = append(errors, fmt.Errorf("Sidecar image is required; set it via sidecar.image property on this stage"))
+		findings = append(findings, newValidationError(field+".image", "Sidecar image is required; set it via sidecar.image property on this stage"))
 	}
 
 	// validate sidecar cpu params
 	if sidecar.CPU.Request == "" {
-		errors = append(errors, fmt.Errorf("Sidecar cpu request is required; set it via sidecar.cpu.request property on this stage"))
+		findings = append(findings, newValidationError(field+".cpu.request", "Sidecar cpu request is required; set it via sidecar.cpu.request property on this stage"))
 	}
 
 	// validate sidecar memory params
 	if sidecar.Memory.Request == "" {
-		errors = append(errors, fmt.Errorf("Sidecar memory request is required; set it via sidecar.memory.request property on this stage"))
+		findings = append(findings, newValidationError(field+".memory.request", "Sidecar memory request is required; set it via sidecar.memory.request property on this stage"))
 	}
 	if sidecar.Memory.Limit == "" {
-		errors = append(errors, fmt.Errorf("Sidecar memory limit is required; set it via sidecar.memory.limit property on this stage"))
+		findings = append(findings, newValidationError(field+".memory.limit", "Sidecar memory limit is required; set it via sidecar.memory.limit property on this stage"))
 	}
 
-	return errors
+	return findings
 }
 
 // ReplaceSidecarTagsWithDigest replaces image tags for sidecars with a digest
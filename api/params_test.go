@@ -879,6 +879,30 @@ func TestSetDefaults(t *testing.T) {
 		assert.Equal(t, 50, params.Autoscale.MaxReplicas)
 	})
 
+	t.Run("DefaultsAutoscaleTypeToHPAIfEmpty", func(t *testing.T) {
+
+		params := Params{}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, AutoscalerTypeHPA, params.Autoscale.Type)
+	})
+
+	t.Run("KeepsAutoscaleTypeIfKeda", func(t *testing.T) {
+
+		params := Params{
+			Autoscale: AutoscaleParams{
+				Type: AutoscalerTypeKeda,
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, AutoscalerTypeKeda, params.Autoscale.Type)
+	})
+
 	t.Run("DefaultsAutoscaleCPUPercentageTo80IfZero", func(t *testing.T) {
 
 		params := Params{
@@ -2183,6 +2207,96 @@ func TestSetDefaults(t *testing.T) {
 		assert.Equal(t, "/api", params.Basepath)
 	})
 
+	t.Run("DefaultsRollingUpdateToOneUnavailablePodWithoutSurgeIfKindIsDaemonSet", func(t *testing.T) {
+
+		params := Params{
+			Kind: KindDaemonSet,
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "0", params.RollingUpdate.MaxSurge)
+		assert.Equal(t, "1", params.RollingUpdate.MaxUnavailable)
+	})
+
+	t.Run("DefaultsReadinessEnabledToFalseIfKindIsDaemonSet", func(t *testing.T) {
+
+		params := Params{
+			Kind: KindDaemonSet,
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, false, *params.Container.ReadinessProbe.Enabled)
+	})
+
+	t.Run("DefaultsSharedNameToAppIfKindIsSecret", func(t *testing.T) {
+
+		params := Params{
+			Kind: KindSecret,
+			App:  "shared-credentials",
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "shared-credentials", params.Shared.Name)
+	})
+
+	t.Run("DoesNotDefaultSharedNameIfKindIsConfig", func(t *testing.T) {
+
+		params := Params{
+			Kind: KindConfig,
+			App:  "shared-configs",
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "", params.Shared.Name)
+	})
+
+	t.Run("DefaultsImmutableConfigsKeepTo10IfEnabled", func(t *testing.T) {
+
+		params := Params{
+			ImmutableConfigs: ImmutableConfigsParams{
+				Enabled: true,
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, 10, params.ImmutableConfigs.Keep)
+	})
+
+	t.Run("DefaultsInventoryPruneToTrue", func(t *testing.T) {
+
+		params := Params{}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.True(t, *params.Inventory.Prune)
+	})
+
+	t.Run("KeepsInventoryPruneIfFalse", func(t *testing.T) {
+
+		falseValue := false
+		params := Params{
+			Inventory: InventoryParams{
+				Prune: &falseValue,
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.False(t, *params.Inventory.Prune)
+	})
+
 	t.Run("DefaultsRollingUpdateMaxSurgeTo25PercentIfEmpty", func(t *testing.T) {
 
 		params := Params{
@@ -2267,6 +2381,88 @@ func TestSetDefaults(t *testing.T) {
 		assert.Equal(t, "10m", params.RollingUpdate.Timeout)
 	})
 
+	t.Run("DefaultsCompletionTimeoutIfWaitForCompletionIsEnabled", func(t *testing.T) {
+
+		params := Params{
+			Kind:              KindJob,
+			WaitForCompletion: true,
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "30m", params.CompletionTimeout)
+	})
+
+	t.Run("DefaultsHookTimeoutAndBackoffLimit", func(t *testing.T) {
+
+		backoffLimit := 2
+		params := Params{
+			Hooks: HooksParams{
+				PreDeploy:  &HookParams{},
+				PostDeploy: &HookParams{Timeout: "1m", BackoffLimit: &backoffLimit},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "10m", params.Hooks.PreDeploy.Timeout)
+		assert.Equal(t, 0, *params.Hooks.PreDeploy.BackoffLimit)
+		assert.Equal(t, "1m", params.Hooks.PostDeploy.Timeout)
+		assert.Equal(t, 2, *params.Hooks.PostDeploy.BackoffLimit)
+	})
+
+	t.Run("DefaultsBlueGreenAutoPromoteAndKeepPreviousIfStrategyTypeIsBlueGreen", func(t *testing.T) {
+
+		params := Params{
+			StrategyType: StrategyTypeBlueGreen,
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "4", map[string]string{})
+
+		assert.True(t, *params.BlueGreen.AutoPromote)
+		assert.Equal(t, "1h", params.BlueGreen.KeepPrevious)
+		assert.Equal(t, "4", params.AtomicID)
+	})
+
+	t.Run("DefaultsCanaryAnalysisIntervalAndIterationsIfMetricsAreSet", func(t *testing.T) {
+
+		params := Params{
+			Canary: CanaryParams{
+				Analysis: CanaryAnalysisParams{
+					Metrics: []CanaryMetricParams{{Name: "errors"}},
+				},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "1m", params.Canary.Analysis.Interval)
+		assert.Equal(t, 5, params.Canary.Analysis.Iterations)
+	})
+
+	t.Run("KeepsCanaryAnalysisIntervalAndIterationsIfNotEmpty", func(t *testing.T) {
+
+		params := Params{
+			Canary: CanaryParams{
+				Analysis: CanaryAnalysisParams{
+					Interval:   "30s",
+					Iterations: 10,
+					Metrics:    []CanaryMetricParams{{Name: "errors"}},
+				},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "30s", params.Canary.Analysis.Interval)
+		assert.Equal(t, 10, params.Canary.Analysis.Iterations)
+	})
+
 	t.Run("SetBuildVersionToBuildVersion", func(t *testing.T) {
 
 		params := Params{}
@@ -3161,34 +3357,39 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfLivenessPathIsEmpty", func(t *testing.T) {
+	t.Run("ReturnsTrueIfAutoscaleMetricsAreValid", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.Path = ""
+		params.Autoscale.Metrics = []AutoscaleMetricParams{
+			{Type: AutoscaleMetricTypeMemory, AverageUtilization: 75},
+			{Type: AutoscaleMetricTypePods, Name: "http_requests_per_second", AverageValue: "100"},
+			{Type: AutoscaleMetricTypeObject, Name: "requests-per-second", Object: AutoscaleMetricObjectParams{APIVersion: "networking.k8s.io/v1", Kind: "Ingress", Name: "myapp"}, Value: "2k"},
+			{Type: AutoscaleMetricTypeExternal, Name: "pubsub.googleapis.com|subscription|num_undelivered_messages", Selector: map[string]string{"resource.labels.subscription_id": "mysubscription"}, AverageValue: "50"},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsTrueIfLivenessPathIsNotEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscaleMetricTypeIsCPU", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.Path = "/liveness"
+		params.Autoscale.Metrics = []AutoscaleMetricParams{{Type: "cpu", AverageUtilization: 75}}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfLivenessProbePortIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscaleMemoryMetricHasNoTarget", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.Port = 0
+		params.Autoscale.Metrics = []AutoscaleMetricParams{{Type: AutoscaleMetricTypeMemory}}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3197,22 +3398,22 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfLivenessProbePortIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscalePodsMetricHasNoName", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.Port = 5000
+		params.Autoscale.Metrics = []AutoscaleMetricParams{{Type: AutoscaleMetricTypePods, AverageValue: "100"}}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfLivenessInitialDelaySecondsIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscalePodsMetricHasValueInsteadOfAverageValue", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.InitialDelaySeconds = 0
+		params.Autoscale.Metrics = []AutoscaleMetricParams{{Type: AutoscaleMetricTypePods, Name: "http_requests_per_second", Value: "100"}}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3221,22 +3422,22 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfLivenessInitialDelaySecondsIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscaleObjectMetricHasNoObject", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.InitialDelaySeconds = 30
+		params.Autoscale.Metrics = []AutoscaleMetricParams{{Type: AutoscaleMetricTypeObject, Name: "requests-per-second", Value: "2k"}}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfLivenessTimeoutSecondsIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscaleExternalMetricHasBothValueAndAverageValue", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.TimeoutSeconds = 0
+		params.Autoscale.Metrics = []AutoscaleMetricParams{{Type: AutoscaleMetricTypeExternal, Name: "num_undelivered_messages", Value: "100", AverageValue: "50"}}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3245,46 +3446,55 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfLivenessTimeoutSecondsIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscaleMetricValueIsNotAQuantity", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.TimeoutSeconds = 2
+		params.Autoscale.Metrics = []AutoscaleMetricParams{{Type: AutoscaleMetricTypeExternal, Name: "num_undelivered_messages", Value: "lots"}}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfReadinessProbePathIsEmpty", func(t *testing.T) {
+	t.Run("ReturnsTrueIfAutoscaleBehaviorIsValid", func(t *testing.T) {
 
+		zero := 0
 		params := validParams
-		params.Container.ReadinessProbe.Path = ""
+		params.Autoscale.Behavior = AutoscaleBehaviorParams{
+			ScaleUp:   &AutoscaleScalingRulesParams{StabilizationWindowSeconds: &zero, Policies: []AutoscalePolicyParams{{Type: AutoscalePolicyTypePercent, Value: 100, PeriodSeconds: 15}}},
+			ScaleDown: &AutoscaleScalingRulesParams{SelectPolicy: AutoscaleSelectPolicyDisabled},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsTrueIfReadinessProbePathIsNotEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscaleBehaviorPolicyIsInvalid", func(t *testing.T) {
 
 		params := validParams
-		params.Container.ReadinessProbe.Path = "/readiness"
+		params.Autoscale.Behavior = AutoscaleBehaviorParams{
+			ScaleDown: &AutoscaleScalingRulesParams{Policies: []AutoscalePolicyParams{{Type: "Replicas", Value: 0, PeriodSeconds: 3600}}},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.Equal(t, 3, len(errors))
 	})
 
-	t.Run("ReturnsFalseIfReadinessProbePortIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscaleBehaviorStabilizationWindowIsLongerThanAnHour", func(t *testing.T) {
 
+		window := 7200
 		params := validParams
-		params.Container.ReadinessProbe.Port = 0
+		params.Autoscale.Behavior = AutoscaleBehaviorParams{
+			ScaleDown: &AutoscaleScalingRulesParams{StabilizationWindowSeconds: &window},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3293,10 +3503,15 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfReadinessProbePortIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsTrueIfKedaAutoscalerIsValid", func(t *testing.T) {
 
 		params := validParams
-		params.Container.ReadinessProbe.Port = 5000
+		params.Autoscale.Type = AutoscalerTypeKeda
+		params.Autoscale.Keda.Triggers = []AutoscaleKedaTriggerParams{
+			{Type: KedaTriggerTypePrometheus, Metadata: map[string]string{"serverAddress": "http://prometheus", "query": "sum(rate(http_requests_total[1m]))", "threshold": "100"}},
+			{Type: KedaTriggerTypeGCPPubSub, Metadata: map[string]string{"subscriptionName": "mysubscription"}, AuthenticationRef: "gcp-workload-identity"},
+			{Type: KedaTriggerTypeCron, Metadata: map[string]string{"timezone": "Europe/Amsterdam", "start": "0 8 * * *", "end": "0 18 * * *", "desiredReplicas": "5"}},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3305,10 +3520,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfReadinessProbeTimeoutSecondsIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscalerTypeIsUnknown", func(t *testing.T) {
 
 		params := validParams
-		params.Container.ReadinessProbe.TimeoutSeconds = 0
+		params.Autoscale.Type = "vpa"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3317,22 +3532,25 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfReadinessProbeTimeoutSecondsIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsFalseIfKedaAutoscalerHasNoTriggers", func(t *testing.T) {
 
 		params := validParams
-		params.Container.ReadinessProbe.TimeoutSeconds = 2
+		params.Autoscale.Type = AutoscalerTypeKeda
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfMetricsPathIsEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfKedaTriggerMissesRequiredMetadata", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Path = ""
+		params.Autoscale.Type = AutoscalerTypeKeda
+		params.Autoscale.Keda.Triggers = []AutoscaleKedaTriggerParams{
+			{Type: KedaTriggerTypePrometheus, Metadata: map[string]string{"serverAddress": "http://prometheus", "query": "sum(rate(http_requests_total[1m]))"}},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3341,35 +3559,41 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfMetricsPathIsNotEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfKedaPubSubTriggerHasNoCredentials", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Path = "/metrics"
+		params.Autoscale.Type = AutoscalerTypeKeda
+		params.Autoscale.Keda.Triggers = []AutoscaleKedaTriggerParams{
+			{Type: KedaTriggerTypeGCPPubSub, Metadata: map[string]string{"subscriptionName": "mysubscription"}},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfMetricsPathIsEmptyButScrapeIsFalse", func(t *testing.T) {
+	t.Run("ReturnsFalseIfKedaAutoscalerHasMetrics", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Scrape = &falseValue
-		params.Container.Metrics.Path = ""
+		params.Autoscale.Type = AutoscalerTypeKeda
+		params.Autoscale.Keda.Triggers = []AutoscaleKedaTriggerParams{
+			{Type: KedaTriggerTypeCron, Metadata: map[string]string{"timezone": "Europe/Amsterdam", "start": "0 8 * * *", "end": "0 18 * * *", "desiredReplicas": "5"}},
+		}
+		params.Autoscale.Metrics = []AutoscaleMetricParams{{Type: AutoscaleMetricTypeMemory, AverageUtilization: 75}}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfMetricsPortIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfLivenessPathIsEmpty", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Port = 0
+		params.Container.LivenessProbe.Path = ""
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3378,10 +3602,10 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfMetricsPortIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsTrueIfLivenessPathIsNotEmpty", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Port = 5000
+		params.Container.LivenessProbe.Path = "/liveness"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3390,47 +3614,46 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsTrueIfMetricsPortIsZeroOrLessButScrapeIsFalse", func(t *testing.T) {
+	t.Run("ReturnsFalseIfLivenessProbePortIsZeroOrLess", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Scrape = &falseValue
-		params.Container.Metrics.Port = 0
+		params.Container.LivenessProbe.Port = 0
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfMetricsScrapeIsEmpty", func(t *testing.T) {
+	t.Run("ReturnsTrueIfLivenessProbePortIsLargerThanZero", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Scrape = nil
+		params.Container.LivenessProbe.Port = 5000
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsTrueIfMetricsScrapeIsTrue", func(t *testing.T) {
+	t.Run("ReturnsFalseIfLivenessInitialDelaySecondsIsZeroOrLess", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Scrape = &trueValue
+		params.Container.LivenessProbe.InitialDelaySeconds = 0
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfMetricsScrapeIsFalse", func(t *testing.T) {
+	t.Run("ReturnsTrueIfLivenessInitialDelaySecondsIsLargerThanZero", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Scrape = &falseValue
+		params.Container.LivenessProbe.InitialDelaySeconds = 30
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3439,7 +3662,213 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseSidecarTypeIsEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfLivenessTimeoutSecondsIsZeroOrLess", func(t *testing.T) {
+
+		params := validParams
+		params.Container.LivenessProbe.TimeoutSeconds = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfLivenessTimeoutSecondsIsLargerThanZero", func(t *testing.T) {
+
+		params := validParams
+		params.Container.LivenessProbe.TimeoutSeconds = 2
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfReadinessProbePathIsEmpty", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ReadinessProbe.Path = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfReadinessProbePathIsNotEmpty", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ReadinessProbe.Path = "/readiness"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfReadinessProbePortIsZeroOrLess", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ReadinessProbe.Port = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfReadinessProbePortIsLargerThanZero", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ReadinessProbe.Port = 5000
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfReadinessProbeTimeoutSecondsIsZeroOrLess", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ReadinessProbe.TimeoutSeconds = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfReadinessProbeTimeoutSecondsIsLargerThanZero", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ReadinessProbe.TimeoutSeconds = 2
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfMetricsPathIsEmpty", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Path = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsPathIsNotEmpty", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Path = "/metrics"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsPathIsEmptyButScrapeIsFalse", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Scrape = &falseValue
+		params.Container.Metrics.Path = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfMetricsPortIsZeroOrLess", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Port = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsPortIsLargerThanZero", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Port = 5000
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsPortIsZeroOrLessButScrapeIsFalse", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Scrape = &falseValue
+		params.Container.Metrics.Port = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfMetricsScrapeIsEmpty", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Scrape = nil
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsScrapeIsTrue", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Scrape = &trueValue
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsScrapeIsFalse", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Scrape = &falseValue
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseSidecarTypeIsEmpty", func(t *testing.T) {
 
 		params := validParams
 
@@ -3807,24 +4236,11 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfPodManagementPolicyIsInvalidAndKindIsStatefulset", func(t *testing.T) {
-
-		params := validParams
-		params.Kind = KindStatefulset
-		params.PodManagementPolicy = "InvalidPolicy"
-
-		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
-
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
-	})
-
-	t.Run("ReturnsTrueIfPodManagementPolicyIsValidAndKindIsStatefulset", func(t *testing.T) {
+	t.Run("ReturnsTrueIfKindIsDaemonSet", func(t *testing.T) {
 
 		params := validParams
-		params.Kind = KindStatefulset
-		params.PodManagementPolicy = "Parallel"
+		params.Kind = KindDaemonSet
+		params.NodeSelector = map[string]string{"cloud.google.com/gke-nodepool": "monitoring"}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3833,11 +4249,11 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfStorageClassIsEmptyAndKindIsStatefulset", func(t *testing.T) {
+	t.Run("ReturnsFalseIfActionIsDeployCanaryAndKindIsDaemonSet", func(t *testing.T) {
 
 		params := validParams
-		params.Kind = KindStatefulset
-		params.StorageClass = ""
+		params.Kind = KindDaemonSet
+		params.Action = ActionDeployCanary
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3846,24 +4262,25 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfStorageClassIsSetAndKindIsStatefulset", func(t *testing.T) {
+	t.Run("ReturnsFalseIfStrategyTypeIsRecreateAndKindIsDaemonSet", func(t *testing.T) {
 
 		params := validParams
-		params.Kind = KindStatefulset
-		params.StorageClass = "standard"
+		params.Kind = KindDaemonSet
+		params.StrategyType = StrategyTypeRecreate
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfStorageSizeIsEmptyAndKindIsStatefulset", func(t *testing.T) {
+	t.Run("ReturnsFalseIfRollingUpdateMaxSurgeAndMaxUnavailableAreZeroAndKindIsDaemonSet", func(t *testing.T) {
 
 		params := validParams
-		params.Kind = KindStatefulset
-		params.StorageSize = ""
+		params.Kind = KindDaemonSet
+		params.RollingUpdate.MaxSurge = "0%"
+		params.RollingUpdate.MaxUnavailable = "0"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3872,11 +4289,15 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfStorageSizeIsSetAndKindIsStatefulset", func(t *testing.T) {
+	t.Run("ReturnsTrueIfKindIsSecretWithSecretKeys", func(t *testing.T) {
 
 		params := validParams
-		params.Kind = KindStatefulset
-		params.StorageSize = "1Gi"
+		params.Kind = KindSecret
+		params.Container = ContainerParams{}
+		params.Secrets.Keys = map[string]interface{}{
+			"password": "c2VjcmV0",
+		}
+		params.Shared.RestartDeployments = []string{"myapp"}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3885,10 +4306,11 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfLoadBalanceAlgorithmIsNonValidValue", func(t *testing.T) {
+	t.Run("ReturnsFalseIfKindIsSecretWithoutSecretKeys", func(t *testing.T) {
 
 		params := validParams
-		params.Request.LoadBalanceAlgorithm = "leastconn"
+		params.Kind = KindSecret
+		params.Secrets.Keys = nil
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3897,34 +4319,180 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfLoadBalanceAlgorithmIsEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfActionIsDeployCanaryAndKindIsSecret", func(t *testing.T) {
 
 		params := validParams
-		params.Request.LoadBalanceAlgorithm = ""
+		params.Kind = KindSecret
+		params.Action = ActionDeployCanary
+		params.Secrets.Keys = map[string]interface{}{
+			"password": "c2VjcmV0",
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfLoadBalanceAlgorithmIsEwma", func(t *testing.T) {
+	t.Run("ReturnsFalseIfSharedRestartDeploymentsIsSetAndKindIsDeployment", func(t *testing.T) {
 
 		params := validParams
-		params.Request.LoadBalanceAlgorithm = "ewma"
+		params.Shared.RestartDeployments = []string{"myotherapp"}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfLoadBalanceAlgorithmIsRoundRobin", func(t *testing.T) {
+	t.Run("ReturnsFalseIfImmutableConfigsIsEnabledAndKindIsSecret", func(t *testing.T) {
 
 		params := validParams
-		params.Request.LoadBalanceAlgorithm = "round_robin"
+		params.Kind = KindSecret
+		params.Secrets.Keys = map[string]interface{}{
+			"password": "c2VjcmV0",
+		}
+		params.ImmutableConfigs.Enabled = true
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfImmutableConfigsKeepIsNegative", func(t *testing.T) {
+
+		params := validParams
+		params.ImmutableConfigs = ImmutableConfigsParams{Enabled: true, Keep: -1}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfPodManagementPolicyIsInvalidAndKindIsStatefulset", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindStatefulset
+		params.PodManagementPolicy = "InvalidPolicy"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfPodManagementPolicyIsValidAndKindIsStatefulset", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindStatefulset
+		params.PodManagementPolicy = "Parallel"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfStorageClassIsEmptyAndKindIsStatefulset", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindStatefulset
+		params.StorageClass = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfStorageClassIsSetAndKindIsStatefulset", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindStatefulset
+		params.StorageClass = "standard"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfStorageSizeIsEmptyAndKindIsStatefulset", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindStatefulset
+		params.StorageSize = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfStorageSizeIsSetAndKindIsStatefulset", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindStatefulset
+		params.StorageSize = "1Gi"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfLoadBalanceAlgorithmIsNonValidValue", func(t *testing.T) {
+
+		params := validParams
+		params.Request.LoadBalanceAlgorithm = "leastconn"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfLoadBalanceAlgorithmIsEmpty", func(t *testing.T) {
+
+		params := validParams
+		params.Request.LoadBalanceAlgorithm = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfLoadBalanceAlgorithmIsEwma", func(t *testing.T) {
+
+		params := validParams
+		params.Request.LoadBalanceAlgorithm = "ewma"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfLoadBalanceAlgorithmIsRoundRobin", func(t *testing.T) {
+
+		params := validParams
+		params.Request.LoadBalanceAlgorithm = "round_robin"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3971,6 +4539,384 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.False(t, valid)
 		assert.Equal(t, error_string, stringInErrorSlice(error_string, errors))
 	})
+
+	t.Run("ReturnsTrueIfCanaryStepsAreValid", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Canary.Steps = []CanaryStepParams{{Weight: 10, Pause: "5m"}, {Weight: 50, Pause: "10m"}, {Weight: 100}}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfCanaryStepWeightIsOutOfRange", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Canary.Steps = []CanaryStepParams{{Weight: 0}, {Weight: 101}}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 2, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfCanaryStepWeightDecreases", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Canary.Steps = []CanaryStepParams{{Weight: 50}, {Weight: 10}}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfCanaryStepPauseIsInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Canary.Steps = []CanaryStepParams{{Weight: 10, Pause: "5 minutes"}}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfCanaryStepsAreSetWithoutNginxIngress", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Visibility = VisibilityIAP
+		params.IapOauthCredentialsClientID = "abc"
+		params.IapOauthCredentialsClientSecret = "def"
+		params.Canary.Steps = []CanaryStepParams{{Weight: 10}}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("ReturnsTrueIfWaitForCompletionIsUsedWithKindJob", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindJob
+		params.WaitForCompletion = true
+		params.CompletionTimeout = "30m"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfWaitForCompletionIsUsedWithKindCronJob", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindCronJob
+		params.Schedule = "*/5 * * * *"
+		params.ConcurrencyPolicy = "Forbid"
+		params.WaitForCompletion = true
+		params.CompletionTimeout = "30m"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("ReturnsTrueIfWaitForCompletionIsUsedWithActionRunCronJob", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindCronJob
+		params.Action = ActionRunCronJob
+		params.Schedule = "*/5 * * * *"
+		params.ConcurrencyPolicy = "Forbid"
+		params.WaitForCompletion = true
+		params.CompletionTimeout = "30m"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfActionIsRunCronJobWithoutKindCronJob", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Action = ActionRunCronJob
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfActionIsSuspendOrResumeCronJobWithoutKindCronJob", func(t *testing.T) {
+
+		for _, action := range []ActionType{ActionSuspendCronJob, ActionResumeCronJob} {
+			params := validParams
+			params.Kind = KindJob
+			params.Action = action
+
+			// act
+			valid, errors, _ := params.ValidateRequiredProperties()
+
+			assert.False(t, valid)
+			assert.Equal(t, 1, len(errors))
+		}
+	})
+
+	t.Run("ReturnsFalseIfCompletionTimeoutIsInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindJob
+		params.WaitForCompletion = true
+		params.CompletionTimeout = "half an hour"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("ReturnsTrueIfHooksAreValid", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Hooks = HooksParams{
+			PreDeploy:  &HookParams{Command: []string{"/migrate"}, Timeout: "10m"},
+			PostDeploy: &HookParams{Args: []string{"smoke-test"}, Timeout: "1m"},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfHookHasNoCommandOrArgs", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Hooks = HooksParams{
+			PreDeploy: &HookParams{Timeout: "10m"},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfHookTimeoutIsInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Hooks = HooksParams{
+			PostDeploy: &HookParams{Command: []string{"/smoke-test"}, Timeout: "ten minutes"},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfHookIsUsedWithKindJob", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindJob
+		params.Hooks = HooksParams{
+			PreDeploy: &HookParams{Command: []string{"/migrate"}, Timeout: "10m"},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("ReturnsTrueIfBlueGreenIsValid", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.StrategyType = StrategyTypeBlueGreen
+		params.BlueGreen = BlueGreenParams{PreviewHosts: []string{"gke-preview.estafette.io"}, KeepPrevious: "2h"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfActionIsSwitchBackAndStrategyTypeIsBlueGreen", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Action = ActionSwitchBack
+		params.StrategyType = StrategyTypeBlueGreen
+		params.BlueGreen = BlueGreenParams{KeepPrevious: "1h"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfBlueGreenIsUsedWithCanaryActions", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Action = ActionDeployCanary
+		params.StrategyType = StrategyTypeBlueGreen
+		params.BlueGreen = BlueGreenParams{KeepPrevious: "1h"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfBlueGreenKeepPreviousIsInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.StrategyType = StrategyTypeBlueGreen
+		params.BlueGreen = BlueGreenParams{KeepPrevious: "an hour"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfActionIsPromoteWithoutBlueGreen", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Action = ActionPromote
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("ReturnsTrueIfCanaryAnalysisIsValid", func(t *testing.T) {
+
+		max := 0.05
+		params := validParams
+		params.Kind = KindDeployment
+		params.Canary.Analysis = CanaryAnalysisParams{
+			PrometheusURL: "http://prometheus.monitoring.svc",
+			Interval:      "1m",
+			Iterations:    5,
+			Metrics: []CanaryMetricParams{
+				{Name: "errors", Query: `sum(rate(http_errors_total{app="{{.App}}",track="{{.Track}}"}[1m]))`, MaxRatio: 1.5},
+				{Name: "latency", Query: `histogram_quantile(0.95, sum(rate(http_request_duration_seconds_bucket{track="{{.Track}}"}[1m])) by (le))`, Max: &max},
+			},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfCanaryAnalysisHasNoPrometheusURL", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Canary.Analysis = CanaryAnalysisParams{
+			Interval: "1m",
+			Metrics:  []CanaryMetricParams{{Name: "errors", Query: "sum(rate(http_errors_total[1m]))", MaxRatio: 1.5}},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfCanaryAnalysisMetricQueryIsInvalidTemplate", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Canary.Analysis = CanaryAnalysisParams{
+			PrometheusURL: "http://prometheus.monitoring.svc",
+			Interval:      "1m",
+			Metrics:       []CanaryMetricParams{{Name: "errors", Query: `sum(rate(http_errors_total{track="{{.Track"}[1m]))`, MaxRatio: 1.5}},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfCanaryAnalysisMetricHasNoThreshold", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Canary.Analysis = CanaryAnalysisParams{
+			PrometheusURL: "http://prometheus.monitoring.svc",
+			Interval:      "1m",
+			Metrics:       []CanaryMetricParams{{Name: "errors", Query: "sum(rate(http_errors_total[1m]))"}},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("ReturnsFalseIfCanaryAnalysisIntervalIsInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Canary.Analysis = CanaryAnalysisParams{
+			PrometheusURL: "http://prometheus.monitoring.svc",
+			Interval:      "every minute",
+			Metrics:       []CanaryMetricParams{{Name: "errors", Query: "sum(rate(http_errors_total[1m]))", MaxRatio: 1.5}},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+	})
 }
 
 func TestReplaceSidecarTagsWithDigest(t *testing.T) {
@@ -4108,3 +5054,104 @@ func TestReplaceSidecarTagsWithDigest(t *testing.T) {
 		assert.Equal(t, true, *params.DNS.UseExternalDNS)
 	})
 }
+
+func TestUsePreDeployHook(t *testing.T) {
+
+	t.Run("ReturnsTrueForDeployActions", func(t *testing.T) {
+
+		for _, action := range []ActionType{ActionDeploySimple, ActionDeployCanary, ActionDeployStable} {
+			params := Params{
+				Action: action,
+				Hooks:  HooksParams{PreDeploy: &HookParams{}},
+			}
+
+			// act
+			usePreDeployHook := params.UsePreDeployHook()
+
+			assert.True(t, usePreDeployHook, string(action))
+		}
+	})
+
+	t.Run("ReturnsFalseForOtherActions", func(t *testing.T) {
+
+		for _, action := range []ActionType{ActionDiffSimple, ActionRestartSimple, ActionRollbackCanary} {
+			params := Params{
+				Action: action,
+				Hooks:  HooksParams{PreDeploy: &HookParams{}},
+			}
+
+			// act
+			usePreDeployHook := params.UsePreDeployHook()
+
+			assert.False(t, usePreDeployHook, string(action))
+		}
+	})
+}
+
+func TestUseInventoryPruning(t *testing.T) {
+
+	trueValue := true
+	falseValue := false
+
+	t.Run("ReturnsTrueForSimpleAndStableActions", func(t *testing.T) {
+
+		for _, action := range []ActionType{ActionDeploySimple, ActionDiffSimple, ActionDeployStable, ActionDiffStable} {
+			params := Params{
+				Action:       action,
+				StrategyType: StrategyTypeRollingUpdate,
+				Inventory:    InventoryParams{Prune: &trueValue},
+			}
+
+			// act
+			useInventoryPruning := params.UseInventoryPruning()
+
+			assert.True(t, useInventoryPruning, string(action))
+		}
+	})
+
+	t.Run("ReturnsFalseForCanaryAndOtherActions", func(t *testing.T) {
+
+		for _, action := range []ActionType{ActionDeployCanary, ActionDiffCanary, ActionRestartSimple, ActionDelete} {
+			params := Params{
+				Action:       action,
+				StrategyType: StrategyTypeRollingUpdate,
+				Inventory:    InventoryParams{Prune: &trueValue},
+			}
+
+			// act
+			useInventoryPruning := params.UseInventoryPruning()
+
+			assert.False(t, useInventoryPruning, string(action))
+		}
+	})
+
+	t.Run("ReturnsFalseForAtomicUpdateAndBlueGreen", func(t *testing.T) {
+
+		for _, strategyType := range []StrategyType{StrategyTypeAtomicUpdate, StrategyTypeBlueGreen} {
+			params := Params{
+				Action:       ActionDeploySimple,
+				StrategyType: strategyType,
+				Inventory:    InventoryParams{Prune: &trueValue},
+			}
+
+			// act
+			useInventoryPruning := params.UseInventoryPruning()
+
+			assert.False(t, useInventoryPruning, string(strategyType))
+		}
+	})
+
+	t.Run("ReturnsFalseIfPruneIsDisabled", func(t *testing.T) {
+
+		params := Params{
+			Action:       ActionDeploySimple,
+			StrategyType: StrategyTypeRollingUpdate,
+			Inventory:    InventoryParams{Prune: &falseValue},
+		}
+
+		// act
+		useInventoryPruning := params.UseInventoryPruning()
+
+		assert.False(t, useInventoryPruning)
+	})
+}
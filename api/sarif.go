@@ -0,0 +1,165 @@
+package api
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SarifLog is the subset of the SARIF 2.1.0 format needed to report validation findings to code scanning tools and editors
+type SarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SarifRun `json:"runs"`
+}
+
+type SarifRun struct {
+	Tool    SarifTool     `json:"tool"`
+	Results []SarifResult `json:"results"`
+}
+
+type SarifTool struct {
+	Driver SarifDriver `json:"driver"`
+}
+
+type SarifDriver struct {
+	Name           string `json:"name"`
+	Version        string `json:"version,omitempty"`
+	InformationURI string `json:"informationUri"`
+}
+
+type SarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    SarifMessage      `json:"message"`
+	Locations  []SarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type SarifMessage struct {
+	Text string `json:"text"`
+}
+
+type SarifLocation struct {
+	PhysicalLocation SarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []SarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type SarifPhysicalLocation struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+	Region           *SarifRegion          `json:"region,omitempty"`
+}
+
+type SarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type SarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+type SarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+var fieldIndexRegex = regexp.MustCompile(`\[\d+\]`)
+
+// NewSarifLog converts the findings into a SARIF log, locating each finding's field in the params yaml at uri
+func NewSarifLog(findings []ValidationError, uri string, paramsYAML []byte, toolVersion string) SarifLog {
+
+	var root yaml.Node
+	_ = yaml.Unmarshal(paramsYAML, &root)
+
+	results := []SarifResult{}
+	for _, finding := range findings {
+		result := SarifResult{
+			// the rule is the field without list indices, so findings for different list items group together
+			RuleID:  fieldIndexRegex.ReplaceAllString(finding.Field, "[]"),
+			Level:   string(finding.Severity),
+			Message: SarifMessage{Text: finding.Error()},
+			Locations: []SarifLocation{
+				{
+					PhysicalLocation: SarifPhysicalLocation{
+						ArtifactLocation: SarifArtifactLocation{URI: uri},
+					},
+					LogicalLocations: []SarifLogicalLocation{{FullyQualifiedName: finding.Field}},
+				},
+			},
+		}
+		if line, column := findFieldInYAML(&root, finding.Field); line > 0 {
+			result.Locations[0].PhysicalLocation.Region = &SarifRegion{StartLine: line, StartColumn: column}
+		}
+		if finding.Hint != "" {
+			result.Properties = map[string]string{"hint": finding.Hint}
+		}
+		results = append(results, result)
+	}
+
+	return SarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []SarifRun{
+			{
+				Tool: SarifTool{
+					Driver: SarifDriver{
+						Name:           "estafette-extension-gke",
+						Version:        toolVersion,
+						InformationURI: "https://github.com/estafette/estafette-extension-gke",
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+}
+
+// findFieldInYAML returns the position of the deepest part of a field path like canary.steps[1].weight that's set in the yaml;
+// fields that are missing altogether are reported at the start of the document
+func findFieldInYAML(root *yaml.Node, field string) (line, column int) {
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		return 0, 0
+	}
+
+	node := root.Content[0]
+	line, column = node.Line, node.Column
+	for _, part := range strings.Split(field, ".") {
+		key := part
+		index := -1
+		if i := strings.Index(part, "["); i > 0 && strings.HasSuffix(part, "]") {
+			key = part[:i]
+			index, _ = strconv.Atoi(part[i+1 : len(part)-1])
+		}
+
+		value := mappingValue(node, key)
+		if value == nil {
+			return
+		}
+		node = value
+		line, column = node.Line, node.Column
+		if index >= 0 {
+			if node.Kind != yaml.SequenceNode || index >= len(node.Content) {
+				return
+			}
+			node = node.Content[index]
+			line, column = node.Line, node.Column
+		}
+	}
+
+	return
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if strings.EqualFold(node.Content[i].Value, key) {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
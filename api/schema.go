@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// enumValues lists the allowed values for the string types that are used as enums in the parameters
+var enumValues = map[reflect.Type][]string{
+	reflect.TypeOf(ActionType("")):            enumStrings(ActionDeploySimple, ActionDeployCanary, ActionDeployStable, ActionRestartSimple, ActionRestartCanary, ActionRestartStable, ActionDiffSimple, ActionDiffCanary, ActionDiffStable, ActionDiffDelete, ActionDelete, ActionRollbackCanary, ActionPromote, ActionSwitchBack, ActionRunCronJob, ActionSuspendCronJob, ActionResumeCronJob),
+	reflect.TypeOf(Kind("")):                  enumStrings(KindDeployment, KindHeadlessDeployment, KindStatefulset, KindDaemonSet, KindJob, KindCronJob, KindConfig, KindSecret, KindConfigToFile),
+	reflect.TypeOf(Visibility("")):            enumStrings(VisibilityPrivate, VisibilityPublic, VisibilityPublicWhitelist, VisibilityESP, VisibilityESPv2, VisibilityIAP, VisibilityApigee),
+	reflect.TypeOf(SidecarType("")):           enumStrings(SidecarTypeOpenresty, SidecarTypeESP, SidecarTypeESPv2, SidecarTypeCloudSQLProxy, SidecarTypeIstio),
+	reflect.TypeOf(StrategyType("")):          enumStrings(StrategyTypeRollingUpdate, StrategyTypeRecreate, StrategyTypeAtomicUpdate, StrategyTypeBlueGreen),
+	reflect.TypeOf(UpdateMode("")):            enumStrings(UpdateModeOff, UpdateModeInitial, UpdateModeRecreate, UpdateModeAuto),
+	reflect.TypeOf(OperatingSystem("")):       enumStrings(OperatingSystemLinux, OperatingSystemWindows),
+	reflect.TypeOf(UnknownParamsMode("")):     enumStrings(UnknownParamsWarning, UnknownParamsError),
+	reflect.TypeOf(AutoscaleMetricType("")):   enumStrings(AutoscaleMetricTypeMemory, AutoscaleMetricTypePods, AutoscaleMetricTypeObject, AutoscaleMetricTypeExternal),
+	reflect.TypeOf(AutoscalePolicyType("")):   enumStrings(AutoscalePolicyTypePods, AutoscalePolicyTypePercent),
+	reflect.TypeOf(AutoscaleSelectPolicy("")): enumStrings(AutoscaleSelectPolicyMax, AutoscaleSelectPolicyMin, AutoscaleSelectPolicyDisabled),
+	reflect.TypeOf(AutoscalerType("")):        enumStrings(AutoscalerTypeHPA, AutoscalerTypeKeda),
+	reflect.TypeOf(KedaTriggerType("")):       enumStrings(KedaTriggerTypePrometheus, KedaTriggerTypeGCPPubSub, KedaTriggerTypeCron),
+}
+
+// GenerateParamsSchema returns a JSON Schema for the stage parameters, generated from the yaml tags of Params and its nested types;
+// the stage itself allows other properties like image and when, so only nested objects are closed for unknown properties
+func GenerateParamsSchema() ([]byte, error) {
+
+	schema := schemaForType(reflect.TypeOf(Params{}))
+	delete(schema, "additionalProperties")
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "Estafette extension gke stage parameters"
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+func schemaForType(t reflect.Type) map[string]interface{} {
+
+	if values, ok := enumValues[t]; ok {
+		return map[string]interface{}{"type": "string", "enum": values}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaForType(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.Map:
+		schema := map[string]interface{}{"type": "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			schema["additionalProperties"] = schemaForType(t.Elem())
+		}
+		return schema
+	case reflect.Struct:
+		return schemaForStruct(t)
+	}
+
+	// interface{} accepts any value
+	return map[string]interface{}{}
+}
+
+func schemaForStruct(t reflect.Type) map[string]interface{} {
+
+	properties := map[string]interface{}{}
+	additionalProperties := false
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name, options := parseYAMLTag(field)
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(options, "inline") {
+			// an inline map collects any unknown property
+			additionalProperties = true
+			continue
+		}
+
+		properties[name] = schemaForType(field.Type)
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": additionalProperties,
+	}
+}
+
+// parseYAMLTag returns the name yaml.v2 uses for the field, which is the lowercased field name if the tag doesn't set one
+func parseYAMLTag(field reflect.StructField) (name, options string) {
+	tag := field.Tag.Get("yaml")
+	parts := strings.SplitN(tag, ",", 2)
+	name = parts[0]
+	if len(parts) > 1 {
+		options = parts[1]
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+
+	return
+}
+
+func enumStrings(values ...interface{}) []string {
+	strs := []string{}
+	for _, v := range values {
+		strs = append(strs, reflect.ValueOf(v).String())
+	}
+
+	return strs
+}
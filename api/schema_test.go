@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateParamsSchema(t *testing.T) {
+
+	t.Run("MatchesCheckedInSchema", func(t *testing.T) {
+
+		checkedInSchema, err := ioutil.ReadFile("../params.schema.json")
+		assert.Nil(t, err)
+
+		// act
+		schema, err := GenerateParamsSchema()
+
+		assert.Nil(t, err)
+		assert.Equal(t, string(checkedInSchema), string(schema), "params.schema.json is out of date; run go generate in the root of the repository to update it")
+	})
+
+	t.Run("UsesYAMLTagsForPropertyNames", func(t *testing.T) {
+
+		// act
+		schema := generateTestSchema(t)
+
+		container := schema.Properties["container"]
+		assert.Contains(t, container.Properties, "repository")
+		assert.Contains(t, container.Properties, "imagePullPolicy")
+		assert.NotContains(t, schema.Properties, "BuildVersion")
+		assert.NotContains(t, schema.Properties, "buildversion")
+	})
+
+	t.Run("IncludesEnumValuesWithoutUnknownValue", func(t *testing.T) {
+
+		// act
+		schema := generateTestSchema(t)
+
+		assert.Equal(t, []string{"deployment", "headless-deployment", "statefulset", "daemonset", "job", "cronjob", "config", "secret", "config-to-file"}, schema.Properties["kind"].Enum)
+		assert.Equal(t, []string{"RollingUpdate", "Recreate", "AtomicUpdate", "BlueGreen"}, schema.Properties["strategytype"].Enum)
+		assert.Equal(t, []string{"Off", "Initial", "Recreate", "Auto"}, schema.Properties["vpa"].Properties["updateMode"].Enum)
+	})
+
+	t.Run("AllowsCustomPropertiesForSidecars", func(t *testing.T) {
+
+		// act
+		schema := generateTestSchema(t)
+
+		assert.Equal(t, true, schema.Properties["sidecars"].Items.AdditionalProperties)
+		assert.Equal(t, false, schema.Properties["container"].AdditionalProperties)
+	})
+}
+
+type testSchema struct {
+	Type                 string                `json:"type"`
+	Enum                 []string              `json:"enum"`
+	Properties           map[string]testSchema `json:"properties"`
+	Items                *testSchema           `json:"items"`
+	AdditionalProperties interface{}           `json:"additionalProperties"`
+}
+
+func generateTestSchema(t *testing.T) testSchema {
+	data, err := GenerateParamsSchema()
+	assert.Nil(t, err)
+
+	var schema testSchema
+	err = json.Unmarshal(data, &schema)
+	assert.Nil(t, err)
+
+	return schema
+}
@@ -4,6 +4,7 @@ package api
 type TemplateData struct {
 	Name                            string
 	NameWithTrack                   string
+	JobName                         string
 	Namespace                       string
 	Schedule                        string
 	RestartPolicy                   string
@@ -12,6 +13,7 @@ type TemplateData struct {
 	BackoffLimit                    int
 	ProgressDeadlineSeconds         int
 	ConcurrencyPolicy               string
+	Suspend                         bool
 	Labels                          map[string]string
 	PodLabels                       map[string]string
 	AppLabelSelector                string
@@ -46,6 +48,13 @@ type TemplateData struct {
 	HpaScalerRequestsPerReplica          string
 	HpaScalerDelta                       string
 	HpaScalerScaleDownMaxRatio           string
+	AutoscaleMetrics                     []AutoscaleMetricData
+	AutoscaleScaleUp                     *AutoscaleScalingRulesData
+	AutoscaleScaleDown                   *AutoscaleScalingRulesData
+	KedaPollingInterval                  int
+	KedaCooldownPeriod                   int
+	KedaScaleToZero                      bool
+	KedaTriggers                         []KedaTriggerData
 	VpaUpdateMode                        string
 	PreferPreemptibles                   bool
 	UseWindowsNodes                      bool
@@ -67,9 +76,14 @@ type TemplateData struct {
 	MountSslCertificate                  bool
 	MountApplicationSecrets              bool
 	Secrets                              map[string]interface{}
+	SecretsName                          string
+	SecretsChecksum                      string
+	ImmutableConfigs                     bool
 	SecretMountPath                      string
 	MountConfigmap                       bool
 	ConfigmapFiles                       map[string]string
+	ConfigmapName                        string
+	ConfigmapChecksum                    string
 	ConfigMountPath                      string
 	MountPayloadLogging                  bool
 	MountServiceAccountSecret            bool
@@ -110,6 +124,7 @@ type TemplateData struct {
 	NginxAuthTLSVerifyDepth              int
 	Tolerations                          []*map[string]interface{}
 	HasTolerations                       bool
+	NodeSelector                         map[string]string
 
 	IncludeReplicas                 bool
 	Replicas                        int
@@ -130,6 +145,58 @@ type TemplateData struct {
 
 	IncludeAtomicIDSelector bool
 	AtomicID                string
+
+	ServiceTrackSelector string
+	UseCanaryIngress     bool
+	CanaryWeight         int
+
+	APIVersions APIVersionsData
+}
+
+// APIVersionsData has the apiVersions to render for kinds whose apiVersion depends on the kubernetes version of the cluster
+type APIVersionsData struct {
+	PodDisruptionBudget     string
+	CronJob                 string
+	HorizontalPodAutoscaler string
+}
+
+// AutoscaleMetricData is a metric of the horizontal pod autoscaler, in the shape of the autoscaling/v2 api
+type AutoscaleMetricData struct {
+	// Type is Resource, Pods, Object or External
+	Type                      string
+	ResourceName              string
+	MetricName                string
+	MetricSelector            map[string]string
+	DescribedObjectAPIVersion string
+	DescribedObjectKind       string
+	DescribedObjectName       string
+	// TargetType is Utilization, AverageValue or Value
+	TargetType         string
+	AverageUtilization int
+	AverageValue       string
+	Value              string
+}
+
+// KedaTriggerData is a trigger of the keda scaled object
+type KedaTriggerData struct {
+	Type              string
+	Metadata          map[string]string
+	AuthenticationRef string
+}
+
+// AutoscaleScalingRulesData has the scaling behavior of the horizontal pod autoscaler in a single direction
+type AutoscaleScalingRulesData struct {
+	HasStabilizationWindowSeconds bool
+	StabilizationWindowSeconds    int
+	SelectPolicy                  string
+	Policies                      []AutoscalePolicyData
+}
+
+// AutoscalePolicyData is a single scaling policy
+type AutoscalePolicyData struct {
+	Type          string
+	Value         int
+	PeriodSeconds int
 }
 
 // ServiceData has data specific to service
@@ -162,6 +229,8 @@ type ContainerData struct {
 	PreStopSleepSeconds             int
 	ContainerSecurityContext        map[string]interface{}
 	ContainerLifeCycle              map[string]interface{}
+	Command                         []string
+	Args                            []string
 }
 
 // ProbeData has data specific to liveness and readiness probes
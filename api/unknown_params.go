@@ -0,0 +1,189 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnknownParamsMode sets whether unknown or misspelled parameters fail the release or are only logged
+type UnknownParamsMode string
+
+const (
+	UnknownParamsWarning UnknownParamsMode = "warning"
+	UnknownParamsError   UnknownParamsMode = "error"
+
+	UnknownParamsUnknown UnknownParamsMode = ""
+)
+
+// stageProperties are read from the stage by other parts of the extension than Params
+var stageProperties = []string{"credentials"}
+
+// FindUnknownParams returns a finding with the given severity for each property in the params yaml that doesn't map to a field of Params,
+// with a suggestion for the closest known property; properties of sidecars are allowed since they're passed on as custom properties
+func FindUnknownParams(paramsYAML []byte, severity Severity) ([]ValidationError, error) {
+
+	var root yaml.Node
+	err := yaml.Unmarshal(paramsYAML, &root)
+	if err != nil {
+		return nil, fmt.Errorf("Failed unmarshalling parameters: %w", err)
+	}
+
+	findings := []ValidationError{}
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		return findings, nil
+	}
+
+	return findUnknownParams(root.Content[0], reflect.TypeOf(Params{}), "", stageProperties, severity, findings), nil
+}
+
+func findUnknownParams(node *yaml.Node, t reflect.Type, field string, extraProperties []string, severity Severity, findings []ValidationError) []ValidationError {
+
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		if node.Kind == yaml.SequenceNode {
+			for i, item := range node.Content {
+				findings = findUnknownParams(item, t.Elem(), fmt.Sprintf("%v[%v]", field, i), nil, severity, findings)
+			}
+		}
+
+	case reflect.Map:
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				findings = findUnknownParams(node.Content[i+1], t.Elem(), joinField(field, node.Content[i].Value), nil, severity, findings)
+			}
+		}
+
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return findings
+		}
+
+		knownNames := []string{}
+		knownTypes := map[string]reflect.Type{}
+		allowsAnyProperty := false
+		for i := 0; i < t.NumField(); i++ {
+			structField := t.Field(i)
+			if structField.PkgPath != "" {
+				continue
+			}
+			name, options := parseYAMLTag(structField)
+			if name == "-" {
+				continue
+			}
+			if strings.Contains(options, "inline") {
+				allowsAnyProperty = true
+				continue
+			}
+			knownNames = append(knownNames, name)
+			knownTypes[name] = structField.Type
+		}
+		for _, name := range extraProperties {
+			knownNames = append(knownNames, name)
+			knownTypes[name] = reflect.TypeOf((*interface{})(nil)).Elem()
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if key == "<<" {
+				// merge keys are checked where the anchor is defined
+				continue
+			}
+			if knownType, ok := knownTypes[key]; ok {
+				findings = findUnknownParams(node.Content[i+1], knownType, joinField(field, key), nil, severity, findings)
+				continue
+			}
+			if allowsAnyProperty {
+				continue
+			}
+
+			var finding ValidationError
+			if suggestion := suggestParam(key, knownNames); suggestion != "" {
+				finding = newValidationError(joinField(field, key), "Parameter %v is unknown and ignored; did you mean %v?", joinField(field, key), joinField(field, suggestion))
+			} else {
+				finding = newValidationError(joinField(field, key), "Parameter %v is unknown and ignored; remove it or check the readme for the supported parameters", joinField(field, key))
+			}
+			finding.Severity = severity
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings
+}
+
+func joinField(field, key string) string {
+	if field == "" {
+		return key
+	}
+
+	return field + "." + key
+}
+
+// suggestParam returns the known name closest to the unknown name, if it's close enough to be a typo
+func suggestParam(name string, knownNames []string) string {
+
+	suggestion := ""
+	suggestionDistance := 0
+	for _, knownName := range knownNames {
+		distance := levenshteinDistance(strings.ToLower(name), strings.ToLower(knownName))
+		if suggestion == "" || distance < suggestionDistance {
+			suggestion = knownName
+			suggestionDistance = distance
+		}
+	}
+
+	maxDistance := len(name) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+	if suggestion == "" || suggestionDistance > maxDistance {
+		return ""
+	}
+
+	return suggestion
+}
+
+// levenshteinDistance returns the number of single character insertions, deletions and substitutions to change a into b
+func levenshteinDistance(a, b string) int {
+
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+func minInt(values ...int) int {
+	min := values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+	}
+
+	return min
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindUnknownParams(t *testing.T) {
+
+	t.Run("ReturnsNoFindingsIfAllParamsAreKnown", func(t *testing.T) {
+
+		paramsYAML := []byte(`credentials: gke-production
+app: myapp
+replicas: 3
+container:
+  repository: extensions
+  readiness:
+    path: /readiness
+sidecars:
+- type: openresty
+  image: estafette/openresty-sidecar:1.13.6.2-alpine
+  customProperty: value
+labels:
+  team: estafette
+`)
+
+		// act
+		findings, err := FindUnknownParams(paramsYAML, SeverityWarning)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(findings))
+	})
+
+	t.Run("ReturnsSuggestionForMisspelledParam", func(t *testing.T) {
+
+		paramsYAML := []byte(`app: myapp
+replicass: 3
+`)
+
+		// act
+		findings, err := FindUnknownParams(paramsYAML, SeverityWarning)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []ValidationError{
+			{
+				Field:    "replicass",
+				Severity: SeverityWarning,
+				Message:  "Parameter replicass is unknown and ignored",
+				Hint:     "did you mean replicas?",
+			},
+		}, findings)
+	})
+
+	t.Run("ReturnsFullPathOfNestedParam", func(t *testing.T) {
+
+		paramsYAML := []byte(`container:
+  readines:
+    path: /readiness
+canary:
+  steps:
+  - weigth: 10
+`)
+
+		// act
+		findings, err := FindUnknownParams(paramsYAML, SeverityError)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(findings))
+		assert.Equal(t, "container.readines", findings[0].Field)
+		assert.Equal(t, SeverityError, findings[0].Severity)
+		assert.Equal(t, "did you mean container.readiness?", findings[0].Hint)
+		assert.Equal(t, "canary.steps[0].weigth", findings[1].Field)
+		assert.Equal(t, "did you mean canary.steps[0].weight?", findings[1].Hint)
+	})
+
+	t.Run("ReturnsNoSuggestionIfNoParamIsClose", func(t *testing.T) {
+
+		paramsYAML := []byte(`somethingelse: true
+`)
+
+		// act
+		findings, err := FindUnknownParams(paramsYAML, SeverityWarning)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(findings))
+		assert.Equal(t, "remove it or check the readme for the supported parameters", findings[0].Hint)
+	})
+
+	t.Run("SuggestsParamWithDifferentCase", func(t *testing.T) {
+
+		paramsYAML := []byte(`waitforcompletion: true
+`)
+
+		// act
+		findings, err := FindUnknownParams(paramsYAML, SeverityWarning)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(findings))
+		assert.Equal(t, "did you mean waitForCompletion?", findings[0].Hint)
+	})
+
+	t.Run("ReturnsNoFindingsIfParamsYAMLIsEmpty", func(t *testing.T) {
+
+		// act
+		findings, err := FindUnknownParams([]byte{}, SeverityWarning)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(findings))
+	})
+}
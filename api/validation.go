@@ -0,0 +1,62 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity indicates whether a validation finding blocks a release
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// ValidationError is a validation finding for a single parameter
+type ValidationError struct {
+	Field    string   `json:"field"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Hint     string   `json:"hint,omitempty"`
+}
+
+func (e ValidationError) Error() string {
+	if e.Hint == "" {
+		return e.Message
+	}
+
+	return e.Message + "; " + e.Hint
+}
+
+// newValidationError formats the error like fmt.Errorf; the text after the first semicolon is used as hint how to fix it
+func newValidationError(field, format string, a ...interface{}) ValidationError {
+	message, hint := splitValidationMessage(fmt.Sprintf(format, a...))
+
+	return ValidationError{
+		Field:    field,
+		Severity: SeverityError,
+		Message:  message,
+		Hint:     hint,
+	}
+}
+
+func newValidationWarning(field, format string, a ...interface{}) ValidationError {
+	message, hint := splitValidationMessage(fmt.Sprintf(format, a...))
+
+	return ValidationError{
+		Field:    field,
+		Severity: SeverityWarning,
+		Message:  message,
+		Hint:     hint,
+	}
+}
+
+func splitValidationMessage(text string) (message, hint string) {
+	parts := strings.SplitN(text, "; ", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+
+	t.Run("ReturnsNoFindingsIfParamsAreValid", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar = SidecarParams{}
+
+		// act
+		findings := params.Validate()
+
+		assert.Equal(t, 0, len(findings))
+	})
+
+	t.Run("ReturnsFieldMessageAndHintForError", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar = SidecarParams{}
+		params.Container.ImageTag = ""
+
+		// act
+		findings := params.Validate()
+
+		assert.Equal(t, []ValidationError{
+			{
+				Field:    "container.tag",
+				Severity: SeverityError,
+				Message:  "Image tag is required",
+				Hint:     "set it via container.tag property on this stage",
+			},
+		}, findings)
+	})
+
+	t.Run("ReturnsIndexOfListItemInField", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar = SidecarParams{}
+		params.Kind = KindDeployment
+		params.Canary.Steps = []CanaryStepParams{{Weight: 10}, {Weight: 200}}
+
+		// act
+		findings := params.Validate()
+
+		assert.Equal(t, 1, len(findings))
+		assert.Equal(t, "canary.steps[1].weight", findings[0].Field)
+	})
+
+	t.Run("ReturnsWarningWithSeverityWarning", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar = SidecarParams{}
+		params.Kind = KindDeployment
+		params.Visibility = VisibilityPublic
+
+		// act
+		findings := params.Validate()
+
+		assert.Equal(t, 1, len(findings))
+		assert.Equal(t, "visibility", findings[0].Field)
+		assert.Equal(t, SeverityWarning, findings[0].Severity)
+	})
+
+	t.Run("ReturnsErrorForInvalidKubernetesVersion", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar = SidecarParams{}
+		params.Kind = KindDeployment
+		params.KubernetesVersion = "latest"
+
+		// act
+		findings := params.Validate()
+
+		assert.Equal(t, 1, len(findings))
+		assert.Equal(t, "kubernetesVersion", findings[0].Field)
+	})
+
+	t.Run("KeepsErrorTextOfValidateRequiredProperties", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ImageTag = ""
+
+		// act
+		_, errors, _ := params.ValidateRequiredProperties()
+
+		assert.Equal(t, 1, len(errors))
+		assert.EqualError(t, errors[0], "Image tag is required; set it via container.tag property on this stage")
+	})
+}
+
+func TestNewSarifLog(t *testing.T) {
+
+	paramsYAML := []byte(`app: myapp
+canary:
+  steps:
+  - weight: 10
+  - weight: 200
+`)
+
+	t.Run("LocatesFindingAtFieldInParamsYAML", func(t *testing.T) {
+
+		findings := []ValidationError{newValidationError("canary.steps[1].weight", "Canary step 2 has weight 200; set canary.steps[].weight to a percentage larger than 0 and at most 100")}
+
+		// act
+		sarifLog := NewSarifLog(findings, ".estafette.yaml", paramsYAML, "1.0.0")
+
+		assert.Equal(t, 1, len(sarifLog.Runs[0].Results))
+		result := sarifLog.Runs[0].Results[0]
+		assert.Equal(t, "canary.steps[].weight", result.RuleID)
+		assert.Equal(t, "error", result.Level)
+		assert.Equal(t, ".estafette.yaml", result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+		assert.Equal(t, &SarifRegion{StartLine: 5, StartColumn: 13}, result.Locations[0].PhysicalLocation.Region)
+		assert.Equal(t, "set canary.steps[].weight to a percentage larger than 0 and at most 100", result.Properties["hint"])
+	})
+
+	t.Run("LocatesMissingFieldAtNearestParent", func(t *testing.T) {
+
+		findings := []ValidationError{newValidationError("canary.analysis.prometheusURL", "Canary analysis requires a Prometheus server")}
+
+		// act
+		sarifLog := NewSarifLog(findings, ".estafette.yaml", paramsYAML, "1.0.0")
+
+		assert.Equal(t, &SarifRegion{StartLine: 3, StartColumn: 3}, sarifLog.Runs[0].Results[0].Locations[0].PhysicalLocation.Region)
+	})
+
+	t.Run("LeavesRegionEmptyIfParamsYAMLIsEmpty", func(t *testing.T) {
+
+		findings := []ValidationError{newValidationError("app", "Application name is required")}
+
+		// act
+		sarifLog := NewSarifLog(findings, ".estafette.yaml", []byte{}, "1.0.0")
+
+		assert.Nil(t, sarifLog.Runs[0].Results[0].Locations[0].PhysicalLocation.Region)
+	})
+}
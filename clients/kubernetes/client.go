@@ -0,0 +1,1015 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/rs/zerolog/log"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apimachinery/pkg/util/wait"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	// ErrNotFound is returned when the requested resource doesn't exist in the cluster
+	ErrNotFound = wrapError{msg: "The resource is not found"}
+
+	// ErrUnknownResource is returned when the cluster doesn't serve the kind or resource type
+	ErrUnknownResource = wrapError{msg: "The resource type is not known by the cluster"}
+
+	// ErrInvalidManifest is returned when a manifest can't be decoded into kubernetes objects
+	ErrInvalidManifest = wrapError{msg: "The manifest is invalid"}
+
+	// ErrRejected is returned when the api server refuses a create, update, patch or delete
+	ErrRejected = wrapError{msg: "The request is rejected by the api server"}
+
+	// ErrRolloutFailed is returned when a workload reports its rollout can't make progress
+	ErrRolloutFailed = wrapError{msg: "The rollout failed"}
+
+	// ErrTimeout is returned when waiting for a condition takes longer than allowed
+	ErrTimeout = wrapError{msg: "Timed out waiting for the condition"}
+
+	// ErrJobFailed is returned when a job reports it failed, because its pods exhausted the backoff limit or it exceeded its deadline
+	ErrJobFailed = wrapError{msg: "The job failed"}
+)
+
+const (
+	fieldManager = "estafette-extension-gke"
+
+	deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+)
+
+//go:generate mockgen -package=kubernetes -destination ./mock.go -source=client.go
+type Client interface {
+	Init(ctx context.Context, kubeContextName string) (err error)
+	Apply(ctx context.Context, namespace string, manifest []byte, dryRun bool) (err error)
+	Diff(ctx context.Context, namespace string, manifest []byte) (diff string, err error)
+	Delete(ctx context.Context, resource schema.GroupResource, namespace, name string, dryRun bool) (err error)
+	DeleteByLabelSelector(ctx context.Context, resources []schema.GroupResource, namespace, labelSelector string, dryRun bool) (err error)
+	WaitForDeletion(ctx context.Context, resource schema.GroupResource, namespace, name string, timeout time.Duration) (err error)
+	RemoveAnnotations(ctx context.Context, resource schema.GroupResource, namespace, name string, annotations ...string) (err error)
+	GetService(ctx context.Context, namespace, name string) (service *corev1.Service, err error)
+	PatchService(ctx context.Context, namespace, name string, patchType types.PatchType, patch []byte) (err error)
+	GetDeployment(ctx context.Context, namespace, name string) (deployment *appsv1.Deployment, err error)
+	ListDeployments(ctx context.Context, namespace, labelSelector string) (deployments []appsv1.Deployment, err error)
+	PatchDeployment(ctx context.Context, namespace, name string, patchType types.PatchType, patch []byte) (err error)
+	ScaleDeployment(ctx context.Context, namespace, name string, replicas int) (err error)
+	RestartDeployment(ctx context.Context, namespace, name string) (err error)
+	RollbackDeployment(ctx context.Context, namespace, name string, revision int64) (err error)
+	WaitForDeploymentRollout(ctx context.Context, namespace, name string, timeout time.Duration) (err error)
+	WaitForStatefulSetRollout(ctx context.Context, namespace, name string, timeout time.Duration) (err error)
+	WaitForDaemonSetRollout(ctx context.Context, namespace, name string, timeout time.Duration) (err error)
+	WaitForJobCompletion(ctx context.Context, namespace, name string, timeout time.Duration) (err error)
+	CreateJobFromCronJob(ctx context.Context, namespace, cronJobName, jobName string, labels map[string]string) (err error)
+	GetCronJob(ctx context.Context, namespace, name string) (cronJob *batchv1.CronJob, err error)
+	SuspendCronJob(ctx context.Context, namespace, name string, suspend bool) (err error)
+	GetPodDisruptionBudget(ctx context.Context, namespace, name string) (podDisruptionBudget *policyv1.PodDisruptionBudget, err error)
+	GetIngress(ctx context.Context, namespace, name string) (ingress *networkingv1.Ingress, err error)
+	GetConfigMap(ctx context.Context, namespace, name string) (configMap *corev1.ConfigMap, err error)
+	UpdateConfigMapData(ctx context.Context, namespace, name string, data map[string]string) (err error)
+	ListConfigMaps(ctx context.Context, namespace, labelSelector string) (configMaps []corev1.ConfigMap, err error)
+	GetSecret(ctx context.Context, namespace, name string) (secret *corev1.Secret, err error)
+	UpdateSecretData(ctx context.Context, namespace, name string, data map[string][]byte) (err error)
+	ListSecrets(ctx context.Context, namespace, labelSelector string) (secrets []corev1.Secret, err error)
+	PrintResources(ctx context.Context, resources []schema.GroupResource, namespace, labelSelector string) (err error)
+	PrintPodLogs(ctx context.Context, namespace, labelSelector, container string, tailLines int64) (err error)
+	StreamJobLogs(ctx context.Context, namespace, name, container string) (err error)
+	ManifestObjects(manifest []byte, labelSelector string) (references []ObjectReference, err error)
+	Prune(ctx context.Context, namespace string, references []ObjectReference, labelSelector string, dryRun bool) (err error)
+}
+
+// NewClient returns a new kubernetes.Client; call Init once the kube config for the cluster has been written
+func NewClient(ctx context.Context) (Client, error) {
+	return &client{
+		out:          os.Stdout,
+		pollInterval: 2 * time.Second,
+	}, nil
+}
+
+func newClientWithClientsets(kubeClientset k8s.Interface, dynamicClient dynamic.Interface, restMapper meta.RESTMapper) *client {
+	return &client{
+		kubeClientset: kubeClientset,
+		dynamicClient: dynamicClient,
+		restMapper:    restMapper,
+		out:           os.Stdout,
+		pollInterval:  2 * time.Second,
+	}
+}
+
+type client struct {
+	kubeClientset k8s.Interface
+	dynamicClient dynamic.Interface
+	restMapper    meta.RESTMapper
+	out           io.Writer
+	pollInterval  time.Duration
+}
+
+func (c *client) Init(ctx context.Context, kubeContextName string) (err error) {
+	log.Debug().Msgf("Creating kubernetes clients for context %v...", kubeContextName)
+
+	// load the kube config from the KUBECONFIG envvar or default location and select the context for the gke cluster
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	configOverrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContextName}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides).ClientConfig()
+	if err != nil {
+		return fmt.Errorf("Can't load kube config for context %v: %w", kubeContextName, err)
+	}
+
+	kubeClientset, err := k8s.NewForConfig(config)
+	if err != nil {
+		return
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return
+	}
+
+	c.kubeClientset = kubeClientset
+	c.dynamicClient = dynamicClient
+	c.restMapper = restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(kubeClientset.Discovery()))
+
+	return nil
+}
+
+func (c *client) Apply(ctx context.Context, namespace string, manifest []byte, dryRun bool) (err error) {
+	objects, err := decodeManifest(manifest)
+	if err != nil {
+		return
+	}
+
+	// namespaces created by this manifest don't exist yet during a dry run, so objects inside them can't be validated by the server
+	newNamespaces := map[string]bool{}
+
+	for _, obj := range objects {
+		resource, err := c.resourceInterfaceForObject(obj, namespace)
+		if err != nil {
+			return err
+		}
+
+		if dryRun && obj.GetKind() == "Namespace" {
+			if _, err := resource.Get(ctx, obj.GetName(), metav1.GetOptions{}); apierrors.IsNotFound(err) {
+				newNamespaces[obj.GetName()] = true
+			}
+		}
+
+		if !dryRun {
+			err = c.upgradeManagedFields(ctx, resource, obj.GetName())
+			if err != nil {
+				return fmt.Errorf("Can't take over fields of %v %v in namespace %v managed by kubectl: %w", strings.ToLower(obj.GetKind()), obj.GetName(), obj.GetNamespace(), c.substituteErrorsWithPredefinedErrors(err))
+			}
+		}
+
+		_, err = c.applyObject(ctx, resource, obj, dryRun)
+		if err != nil {
+			if dryRun && apierrors.IsNotFound(err) && newNamespaces[obj.GetNamespace()] {
+				log.Info().Msgf("%v %v can't be validated by the server, because namespace %v doesn't exist yet", strings.ToLower(obj.GetKind()), obj.GetName(), obj.GetNamespace())
+				continue
+			}
+			return fmt.Errorf("Can't apply %v %v in namespace %v: %w", strings.ToLower(obj.GetKind()), obj.GetName(), obj.GetNamespace(), c.substituteErrorsWithPredefinedErrors(err))
+		}
+
+		if dryRun {
+			log.Info().Msgf("%v/%v applied (dry run)", strings.ToLower(obj.GetKind()), obj.GetName())
+		} else {
+			log.Info().Msgf("%v/%v applied", strings.ToLower(obj.GetKind()), obj.GetName())
+		}
+	}
+
+	return nil
+}
+
+func (c *client) Diff(ctx context.Context, namespace string, manifest []byte) (diff string, err error) {
+	objects, err := decodeManifest(manifest)
+	if err != nil {
+		return
+	}
+
+	var diffBuilder strings.Builder
+	for _, obj := range objects {
+		resource, err := c.resourceInterfaceForObject(obj, namespace)
+		if err != nil {
+			return "", err
+		}
+
+		liveYAML := ""
+		live, err := resource.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return "", fmt.Errorf("Can't get %v %v in namespace %v: %w", strings.ToLower(obj.GetKind()), obj.GetName(), obj.GetNamespace(), c.substituteErrorsWithPredefinedErrors(err))
+		}
+		if err == nil {
+			liveYAML, err = c.toComparableYAML(live)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		// let the server merge the manifest with the live object, without persisting it
+		merged, err := c.applyObject(ctx, resource, obj, true)
+		if apierrors.IsNotFound(err) {
+			// the namespace doesn't exist yet, so the manifest itself is what will be created
+			merged, err = obj, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("Can't dry-run %v %v in namespace %v: %w", strings.ToLower(obj.GetKind()), obj.GetName(), obj.GetNamespace(), c.substituteErrorsWithPredefinedErrors(err))
+		}
+		mergedYAML, err := c.toComparableYAML(merged)
+		if err != nil {
+			return "", err
+		}
+
+		if liveYAML == mergedYAML {
+			continue
+		}
+
+		objectDiff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(liveYAML),
+			B:        difflib.SplitLines(mergedYAML),
+			FromFile: fmt.Sprintf("live/%v.%v.%v", strings.ToLower(obj.GetKind()), obj.GetNamespace(), obj.GetName()),
+			ToFile:   fmt.Sprintf("merged/%v.%v.%v", strings.ToLower(obj.GetKind()), obj.GetNamespace(), obj.GetName()),
+			Context:  3,
+		})
+		if err != nil {
+			return "", err
+		}
+		diffBuilder.WriteString(objectDiff)
+	}
+
+	return diffBuilder.String(), nil
+}
+
+func (c *client) Delete(ctx context.Context, resource schema.GroupResource, namespace, name string, dryRun bool) (err error) {
+	gvr, err := c.resolveResource(resource)
+	if err != nil {
+		return
+	}
+
+	err = c.dynamicClient.Resource(gvr).Namespace(namespace).Delete(ctx, name, c.deleteOptions(dryRun))
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Can't delete %v %v in namespace %v: %w", resource.String(), name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	c.logDeleted(resource, name, dryRun)
+
+	return nil
+}
+
+func (c *client) DeleteByLabelSelector(ctx context.Context, resources []schema.GroupResource, namespace, labelSelector string, dryRun bool) (err error) {
+	for _, resource := range resources {
+		gvr, err := c.resolveResource(resource)
+		if errors.Is(err, ErrUnknownResource) {
+			log.Debug().Msgf("Skipping deletion of %v, since the cluster doesn't serve it", resource.String())
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		list, err := c.dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			return fmt.Errorf("Can't list %v with labels %v in namespace %v: %w", resource.String(), labelSelector, namespace, c.substituteErrorsWithPredefinedErrors(err))
+		}
+
+		for _, item := range list.Items {
+			err = c.dynamicClient.Resource(gvr).Namespace(namespace).Delete(ctx, item.GetName(), c.deleteOptions(dryRun))
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("Can't delete %v %v in namespace %v: %w", resource.String(), item.GetName(), namespace, c.substituteErrorsWithPredefinedErrors(err))
+			}
+			c.logDeleted(resource, item.GetName(), dryRun)
+		}
+	}
+
+	return nil
+}
+
+func (c *client) WaitForDeletion(ctx context.Context, resource schema.GroupResource, namespace, name string, timeout time.Duration) (err error) {
+	gvr, err := c.resolveResource(resource)
+	if err != nil {
+		return
+	}
+
+	return c.waitFor(ctx, timeout, func(ctx context.Context) (bool, error) {
+		_, err := c.dynamicClient.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, c.substituteErrorsWithPredefinedErrors(err)
+		}
+		return false, nil
+	})
+}
+
+func (c *client) RemoveAnnotations(ctx context.Context, resource schema.GroupResource, namespace, name string, annotations ...string) (err error) {
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	gvr, err := c.resolveResource(resource)
+	if err != nil {
+		return
+	}
+
+	// a json merge patch with null values removes the keys and is a no-op for keys that don't exist
+	annotationsPatch := map[string]interface{}{}
+	for _, a := range annotations {
+		annotationsPatch[a] = nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotationsPatch,
+		},
+	})
+	if err != nil {
+		return
+	}
+
+	_, err = c.dynamicClient.Resource(gvr).Namespace(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: fieldManager})
+	if err != nil {
+		return fmt.Errorf("Can't remove annotations %v from %v %v in namespace %v: %w", strings.Join(annotations, ", "), resource.String(), name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	return nil
+}
+
+func (c *client) GetService(ctx context.Context, namespace, name string) (service *corev1.Service, err error) {
+	service, err = c.kubeClientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Can't get service %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	return
+}
+
+func (c *client) PatchService(ctx context.Context, namespace, name string, patchType types.PatchType, patch []byte) (err error) {
+	_, err = c.kubeClientset.CoreV1().Services(namespace).Patch(ctx, name, patchType, patch, metav1.PatchOptions{FieldManager: fieldManager})
+	if err != nil {
+		return fmt.Errorf("Can't patch service %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	return nil
+}
+
+func (c *client) GetDeployment(ctx context.Context, namespace, name string) (deployment *appsv1.Deployment, err error) {
+	deployment, err = c.kubeClientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Can't get deployment %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	return
+}
+
+func (c *client) ListDeployments(ctx context.Context, namespace, labelSelector string) (deployments []appsv1.Deployment, err error) {
+	list, err := c.kubeClientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, fmt.Errorf("Can't list deployments with labels %v in namespace %v: %w", labelSelector, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	// sort oldest first, so the most recent deployment is last
+	deployments = list.Items
+	sort.SliceStable(deployments, func(i, j int) bool {
+		return deployments[i].CreationTimestamp.Before(&deployments[j].CreationTimestamp)
+	})
+
+	return
+}
+
+func (c *client) PatchDeployment(ctx context.Context, namespace, name string, patchType types.PatchType, patch []byte) (err error) {
+	_, err = c.kubeClientset.AppsV1().Deployments(namespace).Patch(ctx, name, patchType, patch, metav1.PatchOptions{FieldManager: fieldManager})
+	if err != nil {
+		return fmt.Errorf("Can't patch deployment %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	return nil
+}
+
+func (c *client) ScaleDeployment(ctx context.Context, namespace, name string, replicas int) (err error) {
+	patch := []byte(fmt.Sprintf(`{"spec":{"replicas":%v}}`, replicas))
+	_, err = c.kubeClientset.AppsV1().Deployments(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: fieldManager})
+	if err != nil {
+		return fmt.Errorf("Can't scale deployment %v in namespace %v to %v replicas: %w", name, namespace, replicas, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	log.Info().Msgf("deployment.apps/%v scaled", name)
+
+	return nil
+}
+
+func (c *client) RestartDeployment(ctx context.Context, namespace, name string) (err error) {
+	// changing an annotation on the pod template triggers a new rollout, identical to kubectl rollout restart
+	patch := []byte(fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{"kubectl.kubernetes.io/restartedAt":"%v"}}}}}`, time.Now().Format(time.RFC3339)))
+	_, err = c.kubeClientset.AppsV1().Deployments(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{FieldManager: fieldManager})
+	if err != nil {
+		return fmt.Errorf("Can't restart deployment %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	log.Info().Msgf("deployment.apps/%v restarted", name)
+
+	return nil
+}
+
+func (c *client) RollbackDeployment(ctx context.Context, namespace, name string, revision int64) (err error) {
+	deployment, err := c.kubeClientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Can't get deployment %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return fmt.Errorf("Can't parse selector of deployment %v in namespace %v: %w", name, namespace, err)
+	}
+	replicaSets, err := c.kubeClientset.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return fmt.Errorf("Can't list replicasets of deployment %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	// copy the pod template of the replicaset for the revision back into the deployment, identical to kubectl rollout undo
+	for _, replicaSet := range replicaSets.Items {
+		if !metav1.IsControlledBy(&replicaSet, deployment) || replicaSet.Annotations[deploymentRevisionAnnotation] != strconv.FormatInt(revision, 10) {
+			continue
+		}
+
+		template := replicaSet.Spec.Template.DeepCopy()
+		delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+		deployment.Spec.Template = *template
+
+		_, err = c.kubeClientset.AppsV1().Deployments(namespace).Update(ctx, deployment, metav1.UpdateOptions{FieldManager: fieldManager})
+		if err != nil {
+			return fmt.Errorf("Can't roll back deployment %v in namespace %v to revision %v: %w", name, namespace, revision, c.substituteErrorsWithPredefinedErrors(err))
+		}
+
+		log.Info().Msgf("deployment.apps/%v rolled back to revision %v", name, revision)
+
+		return nil
+	}
+
+	return ErrNotFound.wrap(fmt.Errorf("Can't find replicaset for revision %v of deployment %v in namespace %v", revision, name, namespace))
+}
+
+func (c *client) WaitForDeploymentRollout(ctx context.Context, namespace, name string, timeout time.Duration) (err error) {
+	lastMessage := ""
+	return c.waitFor(ctx, timeout, func(ctx context.Context) (bool, error) {
+		deployment, err := c.kubeClientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("Can't get deployment %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+		}
+
+		done, message, err := deploymentRolloutStatus(deployment)
+		if message != lastMessage {
+			log.Info().Msg(message)
+			lastMessage = message
+		}
+
+		return done, err
+	})
+}
+
+func (c *client) WaitForStatefulSetRollout(ctx context.Context, namespace, name string, timeout time.Duration) (err error) {
+	lastMessage := ""
+	return c.waitFor(ctx, timeout, func(ctx context.Context) (bool, error) {
+		statefulSet, err := c.kubeClientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("Can't get statefulset %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+		}
+
+		done, message := statefulSetRolloutStatus(statefulSet)
+		if message != lastMessage {
+			log.Info().Msg(message)
+			lastMessage = message
+		}
+
+		return done, nil
+	})
+}
+
+func (c *client) WaitForDaemonSetRollout(ctx context.Context, namespace, name string, timeout time.Duration) (err error) {
+	lastMessage := ""
+	return c.waitFor(ctx, timeout, func(ctx context.Context) (bool, error) {
+		daemonSet, err := c.kubeClientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("Can't get daemonset %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+		}
+
+		done, message := daemonSetRolloutStatus(daemonSet)
+		if message != lastMessage {
+			log.Info().Msg(message)
+			lastMessage = message
+		}
+
+		return done, nil
+	})
+}
+
+func (c *client) WaitForJobCompletion(ctx context.Context, namespace, name string, timeout time.Duration) (err error) {
+	lastMessage := ""
+	return c.waitFor(ctx, timeout, func(ctx context.Context) (bool, error) {
+		job, err := c.kubeClientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("Can't get job %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+		}
+
+		done, message, err := jobCompletionStatus(job)
+		if message != lastMessage {
+			log.Info().Msg(message)
+			lastMessage = message
+		}
+
+		return done, err
+	})
+}
+
+func (c *client) GetCronJob(ctx context.Context, namespace, name string) (cronJob *batchv1.CronJob, err error) {
+	cronJob, err = c.kubeClientset.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Can't get cronjob %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	return
+}
+
+// SuspendCronJob only patches the suspend flag of the cronjob, leaving the rest of its spec untouched
+func (c *client) SuspendCronJob(ctx context.Context, namespace, name string, suspend bool) (err error) {
+	patch := []byte(fmt.Sprintf(`{"spec":{"suspend":%v}}`, suspend))
+	_, err = c.kubeClientset.BatchV1().CronJobs(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: fieldManager})
+	if err != nil {
+		return fmt.Errorf("Can't patch suspend to %v for cronjob %v in namespace %v: %w", suspend, name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	if suspend {
+		log.Info().Msgf("cronjob.batch/%v suspended", name)
+	} else {
+		log.Info().Msgf("cronjob.batch/%v resumed", name)
+	}
+
+	return nil
+}
+
+// CreateJobFromCronJob creates a one-off job from the job template of the cronjob, the way kubectl create job --from=cronjob/<name> does
+func (c *client) CreateJobFromCronJob(ctx context.Context, namespace, cronJobName, jobName string, labels map[string]string) (err error) {
+	cronJob, err := c.kubeClientset.BatchV1().CronJobs(namespace).Get(ctx, cronJobName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Can't get cronjob %v in namespace %v: %w", cronJobName, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	jobLabels := map[string]string{}
+	for k, v := range cronJob.Spec.JobTemplate.Labels {
+		jobLabels[k] = v
+	}
+	for k, v := range labels {
+		jobLabels[k] = v
+	}
+
+	// label the pods as well so they can be found by release id
+	jobSpec := cronJob.Spec.JobTemplate.Spec.DeepCopy()
+	if jobSpec.Template.Labels == nil {
+		jobSpec.Template.Labels = map[string]string{}
+	}
+	for k, v := range labels {
+		jobSpec.Template.Labels[k] = v
+	}
+
+	jobAnnotations := map[string]string{"cronjob.kubernetes.io/instantiate": "manual"}
+	for k, v := range cronJob.Spec.JobTemplate.Annotations {
+		jobAnnotations[k] = v
+	}
+
+	controller := true
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        jobName,
+			Namespace:   namespace,
+			Labels:      jobLabels,
+			Annotations: jobAnnotations,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "batch/v1",
+					Kind:       "CronJob",
+					Name:       cronJob.Name,
+					UID:        cronJob.UID,
+					Controller: &controller,
+				},
+			},
+		},
+		Spec: *jobSpec,
+	}
+
+	_, err = c.kubeClientset.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{FieldManager: fieldManager})
+	if err != nil {
+		return fmt.Errorf("Can't create job %v from cronjob %v in namespace %v: %w", jobName, cronJobName, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	log.Info().Msgf("job.batch/%v created from cronjob.batch/%v", jobName, cronJobName)
+
+	return nil
+}
+
+func (c *client) GetPodDisruptionBudget(ctx context.Context, namespace, name string) (podDisruptionBudget *policyv1.PodDisruptionBudget, err error) {
+	podDisruptionBudget, err = c.kubeClientset.PolicyV1().PodDisruptionBudgets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Can't get poddisruptionbudget %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	return
+}
+
+func (c *client) GetIngress(ctx context.Context, namespace, name string) (ingress *networkingv1.Ingress, err error) {
+	ingress, err = c.kubeClientset.NetworkingV1().Ingresses(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Can't get ingress %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	return
+}
+
+func (c *client) GetConfigMap(ctx context.Context, namespace, name string) (configMap *corev1.ConfigMap, err error) {
+	configMap, err = c.kubeClientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Can't get configmap %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	return
+}
+
+func (c *client) UpdateConfigMapData(ctx context.Context, namespace, name string, data map[string]string) (err error) {
+	configMap, err := c.GetConfigMap(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	configMap.Data = data
+	_, err = c.kubeClientset.CoreV1().ConfigMaps(namespace).Update(ctx, configMap, metav1.UpdateOptions{FieldManager: fieldManager})
+	if err != nil {
+		return fmt.Errorf("Can't update configmap %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	return nil
+}
+
+func (c *client) ListConfigMaps(ctx context.Context, namespace, labelSelector string) (configMaps []corev1.ConfigMap, err error) {
+	list, err := c.kubeClientset.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, fmt.Errorf("Can't list configmaps with labels %v in namespace %v: %w", labelSelector, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	// sort oldest first, so the most recent configmap is last
+	configMaps = list.Items
+	sort.SliceStable(configMaps, func(i, j int) bool {
+		return configMaps[i].CreationTimestamp.Before(&configMaps[j].CreationTimestamp)
+	})
+
+	return
+}
+
+func (c *client) GetSecret(ctx context.Context, namespace, name string) (secret *corev1.Secret, err error) {
+	secret, err = c.kubeClientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Can't get secret %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	return
+}
+
+func (c *client) UpdateSecretData(ctx context.Context, namespace, name string, data map[string][]byte) (err error) {
+	secret, err := c.GetSecret(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	secret.Data = data
+	_, err = c.kubeClientset.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{FieldManager: fieldManager})
+	if err != nil {
+		return fmt.Errorf("Can't update secret %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	return nil
+}
+
+func (c *client) ListSecrets(ctx context.Context, namespace, labelSelector string) (secrets []corev1.Secret, err error) {
+	list, err := c.kubeClientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, fmt.Errorf("Can't list secrets with labels %v in namespace %v: %w", labelSelector, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	// sort oldest first, so the most recent secret is last
+	secrets = list.Items
+	sort.SliceStable(secrets, func(i, j int) bool {
+		return secrets[i].CreationTimestamp.Before(&secrets[j].CreationTimestamp)
+	})
+
+	return
+}
+
+func (c *client) PrintResources(ctx context.Context, resources []schema.GroupResource, namespace, labelSelector string) (err error) {
+	writer := tabwriter.NewWriter(c.out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tAGE")
+
+	for _, resource := range resources {
+		gvr, err := c.resolveResource(resource)
+		if errors.Is(err, ErrUnknownResource) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		list, err := c.dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			return fmt.Errorf("Can't list %v with labels %v in namespace %v: %w", resource.String(), labelSelector, namespace, c.substituteErrorsWithPredefinedErrors(err))
+		}
+
+		for _, item := range list.Items {
+			age := duration.HumanDuration(time.Since(item.GetCreationTimestamp().Time))
+			fmt.Fprintf(writer, "%v/%v\t%v\n", resource.String(), item.GetName(), age)
+		}
+	}
+
+	return writer.Flush()
+}
+
+func (c *client) PrintPodLogs(ctx context.Context, namespace, labelSelector, container string, tailLines int64) (err error) {
+	pods, err := c.kubeClientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return fmt.Errorf("Can't list pods with labels %v in namespace %v: %w", labelSelector, namespace, c.substituteErrorsWithPredefinedErrors(err))
+	}
+
+	for _, pod := range pods.Items {
+		containers := []string{}
+		for _, ic := range pod.Spec.InitContainers {
+			containers = append(containers, ic.Name)
+		}
+		for _, co := range pod.Spec.Containers {
+			containers = append(containers, co.Name)
+		}
+
+		for _, co := range containers {
+			if container != "" && co != container {
+				continue
+			}
+
+			logOptions := &corev1.PodLogOptions{Container: co}
+			if tailLines > 0 {
+				logOptions.TailLines = &tailLines
+			}
+
+			stream, err := c.kubeClientset.CoreV1().Pods(namespace).GetLogs(pod.Name, logOptions).Stream(ctx)
+			if err != nil {
+				log.Info().Msgf("Can't get logs for container %v in pod %v: %v", co, pod.Name, err)
+				continue
+			}
+
+			fmt.Fprintf(c.out, "==> pod/%v container %v <==\n", pod.Name, co)
+			_, err = io.Copy(c.out, stream)
+			stream.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// StreamJobLogs follows the logs of every pod the job starts, one after the other, until the job has finished and all its pods have been streamed
+func (c *client) StreamJobLogs(ctx context.Context, namespace, name, container string) (err error) {
+	streamedPods := map[string]bool{}
+	for {
+		var pod *corev1.Pod
+		jobFinished := false
+		err = c.waitFor(ctx, 0, func(ctx context.Context) (bool, error) {
+			pods, err := c.kubeClientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("job-name=%v", name)})
+			if err != nil {
+				return false, fmt.Errorf("Can't list pods for job %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+			}
+
+			sort.Slice(pods.Items, func(i, j int) bool {
+				return pods.Items[i].CreationTimestamp.Before(&pods.Items[j].CreationTimestamp)
+			})
+			for i := range pods.Items {
+				if !streamedPods[pods.Items[i].Name] && pods.Items[i].Status.Phase != corev1.PodPending {
+					pod = &pods.Items[i]
+					return true, nil
+				}
+			}
+
+			job, err := c.kubeClientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return false, fmt.Errorf("Can't get job %v in namespace %v: %w", name, namespace, c.substituteErrorsWithPredefinedErrors(err))
+			}
+			done, _, failed := jobCompletionStatus(job)
+			jobFinished = done || failed != nil
+
+			return jobFinished, nil
+		})
+		if err != nil || jobFinished {
+			return err
+		}
+
+		streamedPods[pod.Name] = true
+
+		stream, err := c.kubeClientset.CoreV1().Pods(namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container, Follow: true}).Stream(ctx)
+		if err != nil {
+			log.Info().Msgf("Can't get logs for container %v in pod %v: %v", container, pod.Name, err)
+			continue
+		}
+
+		fmt.Fprintf(c.out, "==> pod/%v container %v <==\n", pod.Name, container)
+		_, err = io.Copy(c.out, stream)
+		stream.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func decodeManifest(manifest []byte) (objects []*unstructured.Unstructured, err error) {
+	decoder := yamlutil.NewYAMLOrJSONDecoder(bytes.NewReader(manifest), 4096)
+	for {
+		var object map[string]interface{}
+		err = decoder.Decode(&object)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, ErrInvalidManifest.wrap(err)
+		}
+
+		// skip empty documents, which result from templates that render nothing
+		if len(object) == 0 {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{Object: object}
+		if obj.GetKind() == "" || obj.GetAPIVersion() == "" || obj.GetName() == "" {
+			return nil, ErrInvalidManifest.wrap(fmt.Errorf("document %v is missing apiVersion, kind or metadata.name", len(objects)+1))
+		}
+
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+func (c *client) resolveResource(resource schema.GroupResource) (gvr schema.GroupVersionResource, err error) {
+	gvr, err = c.restMapper.ResourceFor(resource.WithVersion(""))
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return gvr, ErrUnknownResource.wrap(err)
+		}
+		return gvr, err
+	}
+
+	return gvr, nil
+}
+
+func (c *client) resourceInterfaceForObject(obj *unstructured.Unstructured, namespace string) (dynamic.ResourceInterface, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := c.restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, ErrUnknownResource.wrap(fmt.Errorf("%v %v: %w", gvk.String(), obj.GetName(), err))
+		}
+		return nil, err
+	}
+
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return c.dynamicClient.Resource(mapping.Resource), nil
+	}
+
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(namespace)
+	}
+
+	return c.dynamicClient.Resource(mapping.Resource).Namespace(obj.GetNamespace()), nil
+}
+
+func (c *client) applyObject(ctx context.Context, resource dynamic.ResourceInterface, obj *unstructured.Unstructured, dryRun bool) (*unstructured.Unstructured, error) {
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	// use server-side apply and take ownership of fields other managers set as well
+	force := true
+	options := metav1.PatchOptions{
+		FieldManager: fieldManager,
+		Force:        &force,
+	}
+	if dryRun {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+
+	return resource.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, options)
+}
+
+func (c *client) toComparableYAML(obj *unstructured.Unstructured) (string, error) {
+	obj = obj.DeepCopy()
+
+	// strip fields that are set by the server and change on every request
+	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "generation")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "uid")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "selfLink")
+	unstructured.RemoveNestedField(obj.Object, "status")
+
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func (c *client) deleteOptions(dryRun bool) metav1.DeleteOptions {
+	propagationPolicy := metav1.DeletePropagationBackground
+	options := metav1.DeleteOptions{
+		PropagationPolicy: &propagationPolicy,
+	}
+	if dryRun {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+
+	return options
+}
+
+func (c *client) logDeleted(resource schema.GroupResource, name string, dryRun bool) {
+	if dryRun {
+		log.Info().Msgf("%v/%v deleted (dry run)", resource.String(), name)
+	} else {
+		log.Info().Msgf("%v/%v deleted", resource.String(), name)
+	}
+}
+
+func (c *client) waitFor(ctx context.Context, timeout time.Duration, condition func(ctx context.Context) (bool, error)) error {
+	waitCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := wait.PollImmediateUntil(c.pollInterval, func() (bool, error) {
+		return condition(waitCtx)
+	}, waitCtx.Done())
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		return ErrTimeout.wrap(waitCtx.Err())
+	}
+
+	return err
+}
+
+func (c *client) substituteErrorsWithPredefinedErrors(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if apierrors.IsNotFound(err) {
+		return ErrNotFound.wrap(err)
+	}
+	if apierrors.IsInvalid(err) || apierrors.IsBadRequest(err) || apierrors.IsConflict(err) || apierrors.IsForbidden(err) || apierrors.IsAlreadyExists(err) {
+		return ErrRejected.wrap(err)
+	}
+	if apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err) {
+		return ErrTimeout.wrap(err)
+	}
+	if meta.IsNoMatchError(err) {
+		return ErrUnknownResource.wrap(err)
+	}
+
+	return err
+}
@@ -0,0 +1,1083 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestApply(t *testing.T) {
+
+	t.Run("CreatesAllObjectsInManifestInGivenNamespace", func(t *testing.T) {
+
+		client, dynamicClient := newDynamicTestClient()
+		manifest := []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: myapp-configs
+data:
+  config.yaml: |
+    key: value
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: myapp
+  namespace: other
+`)
+
+		// act
+		err := client.Apply(context.Background(), "mynamespace", manifest, false)
+
+		assert.Nil(t, err)
+		_, err = dynamicClient.Resource(corev1.SchemeGroupVersion.WithResource("configmaps")).Namespace("mynamespace").Get(context.Background(), "myapp-configs", metav1.GetOptions{})
+		assert.Nil(t, err)
+		_, err = dynamicClient.Resource(corev1.SchemeGroupVersion.WithResource("services")).Namespace("other").Get(context.Background(), "myapp", metav1.GetOptions{})
+		assert.Nil(t, err)
+	})
+
+	t.Run("SkipsEmptyDocuments", func(t *testing.T) {
+
+		client, _ := newDynamicTestClient()
+		manifest := []byte("---\n\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: myapp-configs\n---\n")
+
+		// act
+		err := client.Apply(context.Background(), "mynamespace", manifest, false)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("ReturnsErrInvalidManifestIfDocumentHasNoKind", func(t *testing.T) {
+
+		client, _ := newDynamicTestClient()
+		manifest := []byte("apiVersion: v1\nmetadata:\n  name: myapp\n")
+
+		// act
+		err := client.Apply(context.Background(), "mynamespace", manifest, false)
+
+		assert.True(t, errors.Is(err, ErrInvalidManifest))
+	})
+
+	t.Run("ReturnsErrUnknownResourceIfKindIsNotServedByCluster", func(t *testing.T) {
+
+		client, _ := newDynamicTestClient()
+		manifest := []byte("apiVersion: cloud.google.com/v1\nkind: BackendConfig\nmetadata:\n  name: myapp\n")
+
+		// act
+		err := client.Apply(context.Background(), "mynamespace", manifest, false)
+
+		assert.True(t, errors.Is(err, ErrUnknownResource))
+	})
+
+	t.Run("ReturnsErrRejectedIfServerRefusesObject", func(t *testing.T) {
+
+		client, dynamicClient := newDynamicTestClient()
+		dynamicClient.PrependReactor("create", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewInvalid(schema.GroupKind{Kind: "Service"}, "myapp", nil)
+		})
+		manifest := []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: myapp\n")
+
+		// act
+		err := client.Apply(context.Background(), "mynamespace", manifest, false)
+
+		assert.True(t, errors.Is(err, ErrRejected))
+	})
+
+	t.Run("TakesOverFieldsManagedByKubectlClientSideApplyBeforeApplying", func(t *testing.T) {
+
+		client, dynamicClient := newDynamicTestClient(testClientSideAppliedConfigMap())
+		manifest := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: myapp-configs\n  namespace: mynamespace\ndata:\n  a: value\n")
+
+		// act
+		err := client.Apply(context.Background(), "mynamespace", manifest, false)
+
+		assert.Nil(t, err)
+		var patches []k8stesting.PatchAction
+		for _, action := range dynamicClient.Actions() {
+			if patch, ok := action.(k8stesting.PatchAction); ok && patch.GetPatchType() == types.JSONPatchType {
+				patches = append(patches, patch)
+			}
+		}
+		if assert.Equal(t, 1, len(patches)) {
+			assert.Contains(t, string(patches[0].GetPatch()), `"manager":"estafette-extension-gke","operation":"Apply"`)
+			assert.NotContains(t, string(patches[0].GetPatch()), "kubectl-client-side-apply")
+		}
+	})
+}
+
+func TestMergeClientSideApplyFields(t *testing.T) {
+
+	t.Run("HandsFieldRemovedFromTemplateOverToServerSideApply", func(t *testing.T) {
+
+		// a release applied with kubectl set keys a and b, the first server-side apply only had key a
+		managedFields := []metav1.ManagedFieldsEntry{
+			testManagedFieldsEntry("kubectl-client-side-apply", metav1.ManagedFieldsOperationUpdate, `{"f:data":{".":{},"f:a":{},"f:b":{}}}`),
+			testManagedFieldsEntry("estafette-extension-gke", metav1.ManagedFieldsOperationApply, `{"f:data":{"f:a":{}}}`),
+		}
+
+		// act
+		upgraded, ok, err := mergeClientSideApplyFields(managedFields)
+
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		if assert.Equal(t, 1, len(upgraded)) {
+			assert.Equal(t, "estafette-extension-gke", upgraded[0].Manager)
+			assert.Equal(t, metav1.ManagedFieldsOperationApply, upgraded[0].Operation)
+			// owning key b lets the next apply without it remove it from the configmap
+			assert.JSONEq(t, `{"f:data":{".":{},"f:a":{},"f:b":{}}}`, string(upgraded[0].FieldsV1.Raw))
+		}
+	})
+
+	t.Run("TurnsClientSideApplyEntryIntoServerSideApplyEntryAndKeepsOtherManagers", func(t *testing.T) {
+
+		managedFields := []metav1.ManagedFieldsEntry{
+			testManagedFieldsEntry("kubectl-client-side-apply", metav1.ManagedFieldsOperationUpdate, `{"f:spec":{"f:replicas":{}}}`),
+			testManagedFieldsEntry("kube-controller-manager", metav1.ManagedFieldsOperationUpdate, `{"f:status":{"f:replicas":{}}}`),
+		}
+
+		// act
+		upgraded, ok, err := mergeClientSideApplyFields(managedFields)
+
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		if assert.Equal(t, 2, len(upgraded)) {
+			assert.Equal(t, "estafette-extension-gke", upgraded[0].Manager)
+			assert.Equal(t, metav1.ManagedFieldsOperationApply, upgraded[0].Operation)
+			assert.JSONEq(t, `{"f:spec":{"f:replicas":{}}}`, string(upgraded[0].FieldsV1.Raw))
+			assert.Equal(t, managedFields[1], upgraded[1])
+		}
+	})
+
+	t.Run("ReturnsFalseIfKubectlClientSideApplyDoesNotManageFields", func(t *testing.T) {
+
+		managedFields := []metav1.ManagedFieldsEntry{
+			testManagedFieldsEntry("estafette-extension-gke", metav1.ManagedFieldsOperationApply, `{"f:data":{"f:a":{}}}`),
+		}
+
+		// act
+		upgraded, ok, err := mergeClientSideApplyFields(managedFields)
+
+		assert.Nil(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, managedFields, upgraded)
+	})
+}
+
+func TestDiff(t *testing.T) {
+
+	t.Run("ReturnsDiffForNewObject", func(t *testing.T) {
+
+		client, _ := newDynamicTestClient()
+		manifest := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: myapp-configs\ndata:\n  key: value\n")
+
+		// act
+		diff, err := client.Diff(context.Background(), "mynamespace", manifest)
+
+		assert.Nil(t, err)
+		assert.Contains(t, diff, "+++ merged/configmap.mynamespace.myapp-configs")
+		assert.Contains(t, diff, "+  key: value")
+	})
+
+	t.Run("ReturnsEmptyDiffForUnchangedObject", func(t *testing.T) {
+
+		client, _ := newDynamicTestClient(&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp-configs", Namespace: "mynamespace"},
+			Data:       map[string]string{"key": "value"},
+		})
+		manifest := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: myapp-configs\n  namespace: mynamespace\ndata:\n  key: value\n")
+
+		// act
+		diff, err := client.Diff(context.Background(), "mynamespace", manifest)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "", diff)
+	})
+}
+
+func TestDelete(t *testing.T) {
+
+	t.Run("DeletesExistingObject", func(t *testing.T) {
+
+		client, dynamicClient := newDynamicTestClient(&corev1.Secret{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp-secrets", Namespace: "mynamespace"},
+		})
+
+		// act
+		err := client.Delete(context.Background(), Secrets, "mynamespace", "myapp-secrets", false)
+
+		assert.Nil(t, err)
+		_, err = dynamicClient.Resource(corev1.SchemeGroupVersion.WithResource("secrets")).Namespace("mynamespace").Get(context.Background(), "myapp-secrets", metav1.GetOptions{})
+		assert.True(t, apierrors.IsNotFound(err))
+	})
+
+	t.Run("IgnoresNotFound", func(t *testing.T) {
+
+		client, _ := newDynamicTestClient()
+
+		// act
+		err := client.Delete(context.Background(), Secrets, "mynamespace", "myapp-secrets", false)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("ReturnsErrUnknownResourceIfResourceIsNotServedByCluster", func(t *testing.T) {
+
+		client, _ := newDynamicTestClient()
+
+		// act
+		err := client.Delete(context.Background(), BackendConfigs, "mynamespace", "myapp", false)
+
+		assert.True(t, errors.Is(err, ErrUnknownResource))
+	})
+}
+
+func TestDeleteByLabelSelector(t *testing.T) {
+
+	t.Run("DeletesOnlyObjectsMatchingSelectorAndSkipsUnknownResources", func(t *testing.T) {
+
+		client, dynamicClient := newDynamicTestClient(
+			&corev1.ConfigMap{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: metav1.ObjectMeta{Name: "myapp-configs", Namespace: "mynamespace", Labels: map[string]string{"app": "myapp"}},
+			},
+			&corev1.ConfigMap{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: metav1.ObjectMeta{Name: "otherapp-configs", Namespace: "mynamespace", Labels: map[string]string{"app": "otherapp"}},
+			},
+		)
+
+		// act
+		err := client.DeleteByLabelSelector(context.Background(), []schema.GroupResource{ConfigMaps, BackendConfigs}, "mynamespace", "app=myapp", false)
+
+		assert.Nil(t, err)
+		list, err := dynamicClient.Resource(corev1.SchemeGroupVersion.WithResource("configmaps")).Namespace("mynamespace").List(context.Background(), metav1.ListOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(list.Items))
+		assert.Equal(t, "otherapp-configs", list.Items[0].GetName())
+	})
+}
+
+func TestPrune(t *testing.T) {
+
+	t.Run("DeletesOnlyReferencedObjectsThatStillMatchSelectorAndSkipsUnknownKinds", func(t *testing.T) {
+
+		client, dynamicClient := newDynamicTestClient(
+			&corev1.ConfigMap{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: metav1.ObjectMeta{Name: "myapp-removed", Namespace: "mynamespace", Labels: map[string]string{"app": "myapp"}},
+			},
+			&corev1.ConfigMap{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: metav1.ObjectMeta{Name: "myapp-taken-over", Namespace: "mynamespace", Labels: map[string]string{"app": "otherapp"}},
+			},
+		)
+		references := []ObjectReference{
+			{Kind: "ConfigMap", Name: "myapp-removed"},
+			{Kind: "ConfigMap", Name: "myapp-taken-over"},
+			{Kind: "ConfigMap", Name: "myapp-already-deleted"},
+			{Group: "cloud.google.com", Kind: "BackendConfig", Name: "myapp"},
+		}
+
+		// act
+		err := client.Prune(context.Background(), "mynamespace", references, "app=myapp", false)
+
+		assert.Nil(t, err)
+		list, err := dynamicClient.Resource(corev1.SchemeGroupVersion.WithResource("configmaps")).Namespace("mynamespace").List(context.Background(), metav1.ListOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(list.Items))
+		assert.Equal(t, "myapp-taken-over", list.Items[0].GetName())
+	})
+}
+
+func TestManifestObjects(t *testing.T) {
+
+	t.Run("ReturnsReferencesToObjectsMatchingSelector", func(t *testing.T) {
+
+		manifest := []byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: mynamespace
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myapp
+  labels:
+    app: myapp
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: myapp-configs
+  labels:
+    app: myapp
+`)
+
+		client, _ := newDynamicTestClient()
+
+		// act
+		references, err := client.ManifestObjects(manifest, "app=myapp")
+
+		assert.Nil(t, err)
+		assert.Equal(t, []ObjectReference{{Group: "apps", Kind: "Deployment", Name: "myapp"}, {Kind: "ConfigMap", Name: "myapp-configs"}}, references)
+	})
+}
+
+func TestInventory(t *testing.T) {
+
+	t.Run("FormatsReferencesSortedOnePerLine", func(t *testing.T) {
+
+		// act
+		inventory := FormatInventory([]ObjectReference{{Kind: "Service", Name: "myapp"}, {Group: "apps", Kind: "Deployment", Name: "myapp"}})
+
+		assert.Equal(t, "Deployment.apps/myapp\nService/myapp", inventory)
+	})
+
+	t.Run("ParsesFormattedInventory", func(t *testing.T) {
+
+		references := []ObjectReference{{Group: "networking.k8s.io", Kind: "Ingress", Name: "myapp"}, {Kind: "Service", Name: "myapp"}}
+
+		// act
+		parsed, err := ParseInventory(FormatInventory(references) + "\n")
+
+		assert.Nil(t, err)
+		assert.Equal(t, references, parsed)
+	})
+
+	t.Run("ReturnsErrorIfLineIsNotAReference", func(t *testing.T) {
+
+		// act
+		_, err := ParseInventory("Deployment.apps")
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestRemoveAnnotations(t *testing.T) {
+
+	t.Run("RemovesOnlyGivenAnnotations", func(t *testing.T) {
+
+		client, dynamicClient := newDynamicTestClient(&corev1.Service{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: "mynamespace", Annotations: map[string]string{
+				"estafette.io/cloudflare-dns":   "true",
+				"estafette.io/cloudflare-state": "{}",
+				"cloud.google.com/neg":          "{}",
+			}},
+		})
+
+		// act
+		err := client.RemoveAnnotations(context.Background(), Services, "mynamespace", "myapp", "estafette.io/cloudflare-dns", "estafette.io/cloudflare-state", "estafette.io/cloudflare-proxy")
+
+		assert.Nil(t, err)
+		service, err := dynamicClient.Resource(corev1.SchemeGroupVersion.WithResource("services")).Namespace("mynamespace").Get(context.Background(), "myapp", metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"cloud.google.com/neg": "{}"}, service.GetAnnotations())
+	})
+
+	t.Run("ReturnsErrNotFoundIfObjectDoesNotExist", func(t *testing.T) {
+
+		client, _ := newDynamicTestClient()
+
+		// act
+		err := client.RemoveAnnotations(context.Background(), Services, "mynamespace", "myapp", "cloud.google.com/neg")
+
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+}
+
+func TestGetService(t *testing.T) {
+
+	t.Run("ReturnsErrNotFoundIfServiceDoesNotExist", func(t *testing.T) {
+
+		client := newTypedTestClient()
+
+		// act
+		_, err := client.GetService(context.Background(), "mynamespace", "myapp")
+
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+}
+
+func TestListDeployments(t *testing.T) {
+
+	t.Run("ReturnsDeploymentsOrderedByCreationTimestamp", func(t *testing.T) {
+
+		now := time.Now()
+		client := newTypedTestClient(
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "myapp-newest", Namespace: "mynamespace", Labels: map[string]string{"app": "myapp"}, CreationTimestamp: metav1.NewTime(now)}},
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "myapp-oldest", Namespace: "mynamespace", Labels: map[string]string{"app": "myapp"}, CreationTimestamp: metav1.NewTime(now.Add(-2 * time.Hour))}},
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "otherapp", Namespace: "mynamespace", Labels: map[string]string{"app": "otherapp"}, CreationTimestamp: metav1.NewTime(now.Add(-1 * time.Hour))}},
+		)
+
+		// act
+		deployments, err := client.ListDeployments(context.Background(), "mynamespace", "app=myapp")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(deployments))
+		assert.Equal(t, "myapp-oldest", deployments[0].Name)
+		assert.Equal(t, "myapp-newest", deployments[1].Name)
+	})
+}
+
+func TestListConfigMaps(t *testing.T) {
+
+	t.Run("ReturnsConfigMapsOrderedByCreationTimestamp", func(t *testing.T) {
+
+		now := time.Now()
+		client := newTypedTestClient(
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "myapp-configs-newest", Namespace: "mynamespace", Labels: map[string]string{"app": "myapp"}, CreationTimestamp: metav1.NewTime(now)}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "myapp-configs-oldest", Namespace: "mynamespace", Labels: map[string]string{"app": "myapp"}, CreationTimestamp: metav1.NewTime(now.Add(-2 * time.Hour))}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "otherapp-configs", Namespace: "mynamespace", Labels: map[string]string{"app": "otherapp"}, CreationTimestamp: metav1.NewTime(now.Add(-1 * time.Hour))}},
+		)
+
+		// act
+		configMaps, err := client.ListConfigMaps(context.Background(), "mynamespace", "app=myapp")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(configMaps))
+		assert.Equal(t, "myapp-configs-oldest", configMaps[0].Name)
+		assert.Equal(t, "myapp-configs-newest", configMaps[1].Name)
+	})
+}
+
+func TestListSecrets(t *testing.T) {
+
+	t.Run("ReturnsSecretsOrderedByCreationTimestamp", func(t *testing.T) {
+
+		now := time.Now()
+		client := newTypedTestClient(
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "myapp-secrets-newest", Namespace: "mynamespace", Labels: map[string]string{"app": "myapp"}, CreationTimestamp: metav1.NewTime(now)}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "myapp-secrets-oldest", Namespace: "mynamespace", Labels: map[string]string{"app": "myapp"}, CreationTimestamp: metav1.NewTime(now.Add(-2 * time.Hour))}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "otherapp-secrets", Namespace: "mynamespace", Labels: map[string]string{"app": "otherapp"}, CreationTimestamp: metav1.NewTime(now.Add(-1 * time.Hour))}},
+		)
+
+		// act
+		secrets, err := client.ListSecrets(context.Background(), "mynamespace", "app=myapp")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(secrets))
+		assert.Equal(t, "myapp-secrets-oldest", secrets[0].Name)
+		assert.Equal(t, "myapp-secrets-newest", secrets[1].Name)
+	})
+}
+
+func TestScaleDeployment(t *testing.T) {
+
+	t.Run("SetsReplicas", func(t *testing.T) {
+
+		replicas := int32(3)
+		client := newTypedTestClient(&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp-canary", Namespace: "mynamespace"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		})
+
+		// act
+		err := client.ScaleDeployment(context.Background(), "mynamespace", "myapp-canary", 0)
+
+		assert.Nil(t, err)
+		deployment, err := client.GetDeployment(context.Background(), "mynamespace", "myapp-canary")
+		assert.Nil(t, err)
+		assert.Equal(t, int32(0), *deployment.Spec.Replicas)
+	})
+}
+
+func TestRestartDeployment(t *testing.T) {
+
+	t.Run("SetsRestartedAtAnnotationOnPodTemplate", func(t *testing.T) {
+
+		client := newTypedTestClient(&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: "mynamespace"},
+		})
+
+		// act
+		err := client.RestartDeployment(context.Background(), "mynamespace", "myapp")
+
+		assert.Nil(t, err)
+		deployment, err := client.GetDeployment(context.Background(), "mynamespace", "myapp")
+		assert.Nil(t, err)
+		assert.NotEmpty(t, deployment.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"])
+	})
+}
+
+func TestRollbackDeployment(t *testing.T) {
+
+	t.Run("RestoresPodTemplateOfReplicaSetForRevision", func(t *testing.T) {
+
+		deployment, previous, current := newRollbackTestObjects()
+		client := newTypedTestClient(deployment, previous, current)
+
+		// act
+		err := client.RollbackDeployment(context.Background(), "mynamespace", "myapp", 1)
+
+		assert.Nil(t, err)
+		deployment, err = client.GetDeployment(context.Background(), "mynamespace", "myapp")
+		assert.Nil(t, err)
+		assert.Equal(t, "myapp:1.0.0", deployment.Spec.Template.Spec.Containers[0].Image)
+		_, hasHashLabel := deployment.Spec.Template.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+		assert.False(t, hasHashLabel)
+	})
+
+	t.Run("ReturnsErrNotFoundIfRevisionDoesNotExist", func(t *testing.T) {
+
+		deployment, previous, current := newRollbackTestObjects()
+		client := newTypedTestClient(deployment, previous, current)
+
+		// act
+		err := client.RollbackDeployment(context.Background(), "mynamespace", "myapp", 5)
+
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+}
+
+func TestDeploymentRevision(t *testing.T) {
+
+	t.Run("ReturnsRevisionFromAnnotation", func(t *testing.T) {
+
+		deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"deployment.kubernetes.io/revision": "3"}}}
+
+		// act
+		revision := DeploymentRevision(deployment)
+
+		assert.Equal(t, int64(3), revision)
+	})
+
+	t.Run("ReturnsZeroIfAnnotationIsMissing", func(t *testing.T) {
+
+		// act
+		revision := DeploymentRevision(&appsv1.Deployment{})
+
+		assert.Equal(t, int64(0), revision)
+	})
+}
+
+func TestUpdateConfigMapData(t *testing.T) {
+
+	t.Run("ReplacesData", func(t *testing.T) {
+
+		client := newTypedTestClient(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp-configs", Namespace: "mynamespace"},
+			Data:       map[string]string{"config.yaml": "new", "added.yaml": "new"},
+		})
+
+		// act
+		err := client.UpdateConfigMapData(context.Background(), "mynamespace", "myapp-configs", map[string]string{"config.yaml": "old"})
+
+		assert.Nil(t, err)
+		configMap, err := client.GetConfigMap(context.Background(), "mynamespace", "myapp-configs")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"config.yaml": "old"}, configMap.Data)
+	})
+}
+
+func TestUpdateSecretData(t *testing.T) {
+
+	t.Run("ReplacesData", func(t *testing.T) {
+
+		client := newTypedTestClient(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp-secrets", Namespace: "mynamespace"},
+			Data:       map[string][]byte{"password": []byte("new")},
+		})
+
+		// act
+		err := client.UpdateSecretData(context.Background(), "mynamespace", "myapp-secrets", map[string][]byte{"password": []byte("old")})
+
+		assert.Nil(t, err)
+		secret, err := client.GetSecret(context.Background(), "mynamespace", "myapp-secrets")
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("old"), secret.Data["password"])
+	})
+
+	t.Run("ReturnsErrNotFoundIfSecretDoesNotExist", func(t *testing.T) {
+
+		client := newTypedTestClient()
+
+		// act
+		err := client.UpdateSecretData(context.Background(), "mynamespace", "myapp-secrets", map[string][]byte{})
+
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+}
+
+func TestPatchService(t *testing.T) {
+
+	t.Run("AppliesJSONPatch", func(t *testing.T) {
+
+		client := newTypedTestClient(&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: "mynamespace"},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeNodePort,
+				Ports: []corev1.ServicePort{{Name: "http", Port: 80, NodePort: 30080}},
+			},
+		})
+
+		// act
+		err := client.PatchService(context.Background(), "mynamespace", "myapp", types.JSONPatchType, []byte(`[{"op": "remove", "path": "/spec/ports/0/nodePort"}, {"op": "replace", "path": "/spec/type", "value": "ClusterIP"}]`))
+
+		assert.Nil(t, err)
+		service, err := client.GetService(context.Background(), "mynamespace", "myapp")
+		assert.Nil(t, err)
+		assert.Equal(t, corev1.ServiceTypeClusterIP, service.Spec.Type)
+		assert.Equal(t, int32(0), service.Spec.Ports[0].NodePort)
+	})
+}
+
+func TestWaitForDeploymentRollout(t *testing.T) {
+
+	t.Run("ReturnsNilIfDeploymentIsRolledOut", func(t *testing.T) {
+
+		replicas := int32(2)
+		client := newTypedTestClient(&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: "mynamespace", Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+		})
+
+		// act
+		err := client.WaitForDeploymentRollout(context.Background(), "mynamespace", "myapp", time.Second)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("ReturnsErrRolloutFailedIfProgressDeadlineIsExceeded", func(t *testing.T) {
+
+		replicas := int32(2)
+		client := newTypedTestClient(&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: "mynamespace", Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status: appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 1, AvailableReplicas: 2, Conditions: []appsv1.DeploymentCondition{
+				{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded"},
+			}},
+		})
+
+		// act
+		err := client.WaitForDeploymentRollout(context.Background(), "mynamespace", "myapp", time.Second)
+
+		assert.True(t, errors.Is(err, ErrRolloutFailed))
+	})
+
+	t.Run("ReturnsErrTimeoutIfDeploymentDoesNotFinishInTime", func(t *testing.T) {
+
+		replicas := int32(2)
+		client := newTypedTestClient(&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: "mynamespace", Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 1, AvailableReplicas: 2},
+		})
+
+		// act
+		err := client.WaitForDeploymentRollout(context.Background(), "mynamespace", "myapp", 50*time.Millisecond)
+
+		assert.True(t, errors.Is(err, ErrTimeout))
+	})
+}
+
+func TestWaitForStatefulSetRollout(t *testing.T) {
+
+	t.Run("ReturnsNilIfAllPodsAreAtUpdateRevision", func(t *testing.T) {
+
+		replicas := int32(3)
+		client := newTypedTestClient(&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: "mynamespace", Generation: 1},
+			Spec:       appsv1.StatefulSetSpec{Replicas: &replicas, UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType}},
+			Status:     appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 3, CurrentReplicas: 3, UpdatedReplicas: 3, CurrentRevision: "myapp-1", UpdateRevision: "myapp-1"},
+		})
+
+		// act
+		err := client.WaitForStatefulSetRollout(context.Background(), "mynamespace", "myapp", time.Second)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("ReturnsErrTimeoutIfPodsAreNotAtUpdateRevision", func(t *testing.T) {
+
+		replicas := int32(3)
+		client := newTypedTestClient(&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: "mynamespace", Generation: 1},
+			Spec:       appsv1.StatefulSetSpec{Replicas: &replicas, UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType}},
+			Status:     appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 3, CurrentReplicas: 2, UpdatedReplicas: 1, CurrentRevision: "myapp-1", UpdateRevision: "myapp-2"},
+		})
+
+		// act
+		err := client.WaitForStatefulSetRollout(context.Background(), "mynamespace", "myapp", 50*time.Millisecond)
+
+		assert.True(t, errors.Is(err, ErrTimeout))
+	})
+}
+
+func TestWaitForDaemonSetRollout(t *testing.T) {
+
+	t.Run("ReturnsNilIfAllScheduledPodsAreUpdatedAndAvailable", func(t *testing.T) {
+
+		client := newTypedTestClient(&appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: "mynamespace", Generation: 2},
+			Spec:       appsv1.DaemonSetSpec{UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType}},
+			Status:     appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 5, UpdatedNumberScheduled: 5, NumberAvailable: 5},
+		})
+
+		// act
+		err := client.WaitForDaemonSetRollout(context.Background(), "mynamespace", "myapp", time.Second)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("ReturnsErrTimeoutIfNotAllScheduledPodsAreUpdated", func(t *testing.T) {
+
+		client := newTypedTestClient(&appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: "mynamespace", Generation: 2},
+			Spec:       appsv1.DaemonSetSpec{UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType}},
+			Status:     appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 5, UpdatedNumberScheduled: 3, NumberAvailable: 5},
+		})
+
+		// act
+		err := client.WaitForDaemonSetRollout(context.Background(), "mynamespace", "myapp", 50*time.Millisecond)
+
+		assert.True(t, errors.Is(err, ErrTimeout))
+	})
+}
+
+func TestWaitForJobCompletion(t *testing.T) {
+
+	t.Run("ReturnsNilIfJobIsComplete", func(t *testing.T) {
+
+		client := newTypedTestClient(newTestJob(batchv1.JobComplete, ""))
+
+		// act
+		err := client.WaitForJobCompletion(context.Background(), "mynamespace", "myapp-pre-deploy", time.Second)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("ReturnsErrJobFailedIfJobHasFailed", func(t *testing.T) {
+
+		job := newTestJob(batchv1.JobFailed, "Job has reached the specified backoff limit")
+		job.Status.Conditions[0].Reason = "BackoffLimitExceeded"
+		client := newTypedTestClient(job)
+
+		// act
+		err := client.WaitForJobCompletion(context.Background(), "mynamespace", "myapp-pre-deploy", time.Second)
+
+		assert.True(t, errors.Is(err, ErrJobFailed))
+		assert.Contains(t, err.Error(), "failed with reason BackoffLimitExceeded: Job has reached the specified backoff limit")
+	})
+
+	t.Run("ReturnsErrTimeoutIfJobDoesNotFinishInTime", func(t *testing.T) {
+
+		client := newTypedTestClient(newTestJob("", ""))
+
+		// act
+		err := client.WaitForJobCompletion(context.Background(), "mynamespace", "myapp-pre-deploy", 50*time.Millisecond)
+
+		assert.True(t, errors.Is(err, ErrTimeout))
+	})
+}
+
+func TestCreateJobFromCronJob(t *testing.T) {
+
+	t.Run("CreatesJobFromJobTemplateOwnedByCronJob", func(t *testing.T) {
+
+		client := newTypedTestClient(&batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "myjob", Namespace: "mynamespace", UID: "cronjob-uid"},
+			Spec: batchv1.CronJobSpec{
+				JobTemplate: batchv1.JobTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "myjob"}},
+					Spec: batchv1.JobSpec{
+						Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "myjob", Image: "myjob:1.0.0"}}}},
+					},
+				},
+			},
+		})
+
+		// act
+		err := client.CreateJobFromCronJob(context.Background(), "mynamespace", "myjob", "myjob-run-123", map[string]string{"estafette.io/release-id": "123"})
+
+		assert.Nil(t, err)
+		job, err := client.kubeClientset.BatchV1().Jobs("mynamespace").Get(context.Background(), "myjob-run-123", metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"app": "myjob", "estafette.io/release-id": "123"}, job.Labels)
+		assert.Equal(t, "manual", job.Annotations["cronjob.kubernetes.io/instantiate"])
+		assert.Equal(t, "123", job.Spec.Template.Labels["estafette.io/release-id"])
+		assert.Equal(t, "myjob:1.0.0", job.Spec.Template.Spec.Containers[0].Image)
+		assert.True(t, metav1.IsControlledBy(job, &metav1.ObjectMeta{UID: "cronjob-uid"}))
+	})
+
+	t.Run("ReturnsErrNotFoundIfCronJobDoesNotExist", func(t *testing.T) {
+
+		client := newTypedTestClient()
+
+		// act
+		err := client.CreateJobFromCronJob(context.Background(), "mynamespace", "myjob", "myjob-run-123", nil)
+
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+}
+
+func TestSuspendCronJob(t *testing.T) {
+
+	t.Run("PatchesOnlySuspendFlag", func(t *testing.T) {
+
+		client := newTypedTestClient(&batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "myjob", Namespace: "mynamespace"},
+			Spec:       batchv1.CronJobSpec{Schedule: "*/5 * * * *"},
+		})
+
+		// act
+		err := client.SuspendCronJob(context.Background(), "mynamespace", "myjob", true)
+
+		assert.Nil(t, err)
+		cronJob, err := client.GetCronJob(context.Background(), "mynamespace", "myjob")
+		assert.Nil(t, err)
+		assert.True(t, *cronJob.Spec.Suspend)
+		assert.Equal(t, "*/5 * * * *", cronJob.Spec.Schedule)
+	})
+
+	t.Run("ReturnsErrNotFoundIfCronJobDoesNotExist", func(t *testing.T) {
+
+		client := newTypedTestClient()
+
+		// act
+		err := client.SuspendCronJob(context.Background(), "mynamespace", "myjob", false)
+
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+}
+
+func TestStreamJobLogs(t *testing.T) {
+
+	t.Run("StreamsLogsOfEveryStartedPodOfTheJob", func(t *testing.T) {
+
+		client := newTypedTestClient(
+			newTestJob(batchv1.JobComplete, ""),
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "myapp-pre-deploy-abc", Namespace: "mynamespace", Labels: map[string]string{"job-name": "myapp-pre-deploy"}},
+				Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "myapp-pre-deploy-def", Namespace: "mynamespace", Labels: map[string]string{"job-name": "myapp-pre-deploy"}},
+				Status:     corev1.PodStatus{Phase: corev1.PodSucceeded},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "myapp-abc", Namespace: "mynamespace", Labels: map[string]string{"app": "myapp"}},
+				Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+			},
+		)
+		out := &bytes.Buffer{}
+		client.out = out
+
+		// act
+		err := client.StreamJobLogs(context.Background(), "mynamespace", "myapp-pre-deploy", "myapp")
+
+		assert.Nil(t, err)
+		assert.Contains(t, out.String(), "==> pod/myapp-pre-deploy-abc container myapp <==")
+		assert.Contains(t, out.String(), "==> pod/myapp-pre-deploy-def container myapp <==")
+		assert.NotContains(t, out.String(), "pod/myapp-abc")
+	})
+
+	t.Run("ReturnsErrTimeoutIfContextIsCancelledBeforePodStarts", func(t *testing.T) {
+
+		client := newTypedTestClient(
+			newTestJob("", ""),
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "myapp-pre-deploy-abc", Namespace: "mynamespace", Labels: map[string]string{"job-name": "myapp-pre-deploy"}},
+				Status:     corev1.PodStatus{Phase: corev1.PodPending},
+			},
+		)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		// act
+		err := client.StreamJobLogs(ctx, "mynamespace", "myapp-pre-deploy", "myapp")
+
+		assert.True(t, errors.Is(err, ErrTimeout))
+	})
+}
+
+func TestPrintPodLogs(t *testing.T) {
+
+	t.Run("PrintsLogsForRequestedContainerOnly", func(t *testing.T) {
+
+		client := newTypedTestClient(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp-abc", Namespace: "mynamespace", Labels: map[string]string{"app": "myapp", "track": "canary"}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "myapp"}, {Name: "openresty"}}},
+		})
+		out := &bytes.Buffer{}
+		client.out = out
+
+		// act
+		err := client.PrintPodLogs(context.Background(), "mynamespace", "app=myapp,track=canary", "myapp", 50)
+
+		assert.Nil(t, err)
+		assert.Contains(t, out.String(), "==> pod/myapp-abc container myapp <==")
+		assert.NotContains(t, out.String(), "container openresty")
+	})
+}
+
+func newRollbackTestObjects() (deployment *appsv1.Deployment, previous, current *appsv1.ReplicaSet) {
+	controller := true
+	deployment = &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: "mynamespace", UID: "deployment-uid", Annotations: map[string]string{"deployment.kubernetes.io/revision": "2"}},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "myapp"}},
+			Template: newRollbackTestPodTemplate("myapp:2.0.0", ""),
+		},
+	}
+	owner := []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "myapp", UID: "deployment-uid", Controller: &controller}}
+	previous = &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-abc", Namespace: "mynamespace", Labels: map[string]string{"app": "myapp"}, Annotations: map[string]string{"deployment.kubernetes.io/revision": "1"}, OwnerReferences: owner},
+		Spec:       appsv1.ReplicaSetSpec{Template: newRollbackTestPodTemplate("myapp:1.0.0", "abc")},
+	}
+	current = &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-def", Namespace: "mynamespace", Labels: map[string]string{"app": "myapp"}, Annotations: map[string]string{"deployment.kubernetes.io/revision": "2"}, OwnerReferences: owner},
+		Spec:       appsv1.ReplicaSetSpec{Template: newRollbackTestPodTemplate("myapp:2.0.0", "def")},
+	}
+
+	return
+}
+
+func newRollbackTestPodTemplate(image, podTemplateHash string) corev1.PodTemplateSpec {
+	labels := map[string]string{"app": "myapp"}
+	if podTemplateHash != "" {
+		labels[appsv1.DefaultDeploymentUniqueLabelKey] = podTemplateHash
+	}
+
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: labels},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "myapp", Image: image}}},
+	}
+}
+
+func newTestJob(conditionType batchv1.JobConditionType, message string) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-pre-deploy", Namespace: "mynamespace"},
+		Status:     batchv1.JobStatus{Active: 1},
+	}
+	if conditionType != "" {
+		job.Status.Active = 0
+		job.Status.Conditions = []batchv1.JobCondition{{Type: conditionType, Status: corev1.ConditionTrue, Message: message}}
+	}
+
+	return job
+}
+
+func testClientSideAppliedConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "myapp-configs",
+			Namespace:       "mynamespace",
+			ResourceVersion: "1",
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				testManagedFieldsEntry("kubectl-client-side-apply", metav1.ManagedFieldsOperationUpdate, `{"f:data":{".":{},"f:a":{},"f:b":{}}}`),
+			},
+		},
+		Data: map[string]string{"a": "value", "b": "value"},
+	}
+}
+
+func testManagedFieldsEntry(manager string, operation metav1.ManagedFieldsOperationType, fields string) metav1.ManagedFieldsEntry {
+	return metav1.ManagedFieldsEntry{
+		Manager:    manager,
+		Operation:  operation,
+		APIVersion: "v1",
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(fields)},
+	}
+}
+
+func newTypedTestClient(objects ...runtime.Object) *client {
+	c := newClientWithClientsets(fake.NewSimpleClientset(objects...), nil, newTestRESTMapper())
+	c.pollInterval = 10 * time.Millisecond
+
+	return c
+}
+
+func newDynamicTestClient(objects ...runtime.Object) (*client, *dynamicfake.FakeDynamicClient) {
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(scheme.Scheme, objects...)
+
+	c := newClientWithClientsets(fake.NewSimpleClientset(), applyingDynamicClient{dynamicClient}, newTestRESTMapper())
+	c.pollInterval = 10 * time.Millisecond
+
+	return c, dynamicClient
+}
+
+// applyingDynamicClient emulates server-side apply, which the fake object tracker doesn't support, by creating or replacing the object
+type applyingDynamicClient struct {
+	*dynamicfake.FakeDynamicClient
+}
+
+func (c applyingDynamicClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return applyingNamespaceableResource{c.FakeDynamicClient.Resource(resource)}
+}
+
+type applyingNamespaceableResource struct {
+	dynamic.NamespaceableResourceInterface
+}
+
+func (r applyingNamespaceableResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return applyingResource{r.NamespaceableResourceInterface.Namespace(namespace)}
+}
+
+func (r applyingNamespaceableResource) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	return applyingResource{r.NamespaceableResourceInterface}.Patch(ctx, name, pt, data, options, subresources...)
+}
+
+type applyingResource struct {
+	dynamic.ResourceInterface
+}
+
+func (r applyingResource) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if pt != types.ApplyPatchType {
+		return r.ResourceInterface.Patch(ctx, name, pt, data, options, subresources...)
+	}
+
+	obj := &unstructured.Unstructured{}
+	err := json.Unmarshal(data, &obj.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return r.Create(ctx, obj, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Update(ctx, obj, metav1.UpdateOptions{})
+}
+
+func newTestRESTMapper() meta.RESTMapper {
+	// the default group versions let version-less lookups, like the ones Prune does, resolve the served version like discovery would
+	restMapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{corev1.SchemeGroupVersion, appsv1.SchemeGroupVersion})
+	restMapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), meta.RESTScopeRoot)
+	restMapper.Add(corev1.SchemeGroupVersion.WithKind("Service"), meta.RESTScopeNamespace)
+	restMapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	restMapper.Add(corev1.SchemeGroupVersion.WithKind("Secret"), meta.RESTScopeNamespace)
+	restMapper.Add(appsv1.SchemeGroupVersion.WithKind("Deployment"), meta.RESTScopeNamespace)
+
+	return restMapper
+}
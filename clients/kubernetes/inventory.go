@@ -0,0 +1,151 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ObjectReference identifies an object in a namespace by group, kind and name; it leaves out the apiVersion so an inventory stays valid
+// when a release moves a kind to a newer apiVersion
+type ObjectReference struct {
+	Group string
+	Kind  string
+	Name  string
+}
+
+// String formats the reference like kubectl does, for example Deployment.apps/myapp or ConfigMap/myapp-configs
+func (r ObjectReference) String() string {
+	if r.Group == "" {
+		return fmt.Sprintf("%v/%v", r.Kind, r.Name)
+	}
+
+	return fmt.Sprintf("%v.%v/%v", r.Kind, r.Group, r.Name)
+}
+
+// ParseObjectReference parses a reference in the format of ObjectReference.String
+func ParseObjectReference(value string) (reference ObjectReference, err error) {
+	kindAndGroup, name, found := cut(value, "/")
+	if !found || kindAndGroup == "" || name == "" {
+		return reference, fmt.Errorf("Object reference %q isn't formatted as Kind.group/name", value)
+	}
+	kind, group, _ := cut(kindAndGroup, ".")
+
+	return ObjectReference{Group: group, Kind: kind, Name: name}, nil
+}
+
+// FormatInventory returns the references one per line and sorted, so the inventory of an unchanged release is identical
+func FormatInventory(references []ObjectReference) string {
+	lines := make([]string, 0, len(references))
+	for _, reference := range references {
+		lines = append(lines, reference.String())
+	}
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n")
+}
+
+// ParseInventory parses the references written by FormatInventory, ignoring empty lines
+func ParseInventory(inventory string) (references []ObjectReference, err error) {
+	for _, line := range strings.Split(inventory, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		reference, err := ParseObjectReference(line)
+		if err != nil {
+			return nil, err
+		}
+		references = append(references, reference)
+	}
+
+	return references, nil
+}
+
+// ManifestObjects returns references to the objects in the manifest whose labels match the label selector
+func (c *client) ManifestObjects(manifest []byte, labelSelector string) (references []ObjectReference, err error) {
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid label selector %v: %w", labelSelector, err)
+	}
+
+	objects, err := decodeManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, obj := range objects {
+		if !selector.Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+		references = append(references, ObjectReference{Group: obj.GroupVersionKind().Group, Kind: obj.GetKind(), Name: obj.GetName()})
+	}
+
+	return references, nil
+}
+
+// Prune deletes the referenced objects that still exist and whose labels match the label selector; objects of kinds the cluster no longer serves
+// and objects that were relabelled, for example because another app took them over, are left alone
+func (c *client) Prune(ctx context.Context, namespace string, references []ObjectReference, labelSelector string, dryRun bool) (err error) {
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		return fmt.Errorf("Invalid label selector %v: %w", labelSelector, err)
+	}
+
+	for _, reference := range references {
+		groupKind := schema.GroupKind{Group: reference.Group, Kind: reference.Kind}
+		mapping, err := c.restMapper.RESTMapping(groupKind)
+		if meta.IsNoMatchError(err) {
+			log.Debug().Msgf("Skipping pruning of %v, since the cluster doesn't serve it", reference.String())
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		resource := c.dynamicClient.Resource(mapping.Resource).Namespace(namespace)
+		if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+			resource = c.dynamicClient.Resource(mapping.Resource)
+		}
+
+		obj, err := resource.Get(ctx, reference.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("Can't get %v in namespace %v: %w", reference.String(), namespace, c.substituteErrorsWithPredefinedErrors(err))
+		}
+		if !selector.Matches(labels.Set(obj.GetLabels())) {
+			log.Info().Msgf("Skipping pruning of %v, since it no longer has labels %v", reference.String(), labelSelector)
+			continue
+		}
+
+		err = resource.Delete(ctx, reference.Name, c.deleteOptions(dryRun))
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("Can't delete %v in namespace %v: %w", reference.String(), namespace, c.substituteErrorsWithPredefinedErrors(err))
+		}
+		c.logDeleted(mapping.Resource.GroupResource(), reference.Name, dryRun)
+	}
+
+	return nil
+}
+
+// cut is strings.Cut, which isn't available in go 1.17
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+
+	return s, "", false
+}
@@ -0,0 +1,120 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+// clientSideApplyManager is the field manager kubectl apply uses without --server-side, which is how releases were applied before
+const clientSideApplyManager = "kubectl-client-side-apply"
+
+// upgradeManagedFields hands the fields an earlier kubectl client-side apply manages over to the server-side apply field manager, so fields
+// removed from the templates since get removed from the live object by the next apply instead of staying behind owned by kubectl;
+// it does what csaupgrade.UpgradeManagedFields does in newer client-go versions
+func (c *client) upgradeManagedFields(ctx context.Context, resource dynamic.ResourceInterface, name string) (err error) {
+	live, err := resource.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	managedFields, upgraded, err := mergeClientSideApplyFields(live.GetManagedFields())
+	if err != nil || !upgraded {
+		return err
+	}
+
+	// test the resource version, so fields managed by a concurrent update aren't overwritten
+	patch, err := json.Marshal([]map[string]interface{}{
+		{"op": "test", "path": "/metadata/resourceVersion", "value": live.GetResourceVersion()},
+		{"op": "replace", "path": "/metadata/managedFields", "value": managedFields},
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("Taking over the fields of %v %v managed by kubectl client-side apply...", live.GetKind(), name)
+	_, err = resource.Patch(ctx, name, types.JSONPatchType, patch, metav1.PatchOptions{FieldManager: fieldManager})
+
+	return err
+}
+
+// mergeClientSideApplyFields merges the entry of kubectl client-side apply into the entry of the server-side apply field manager, or turns it into
+// that entry if there's none yet; it returns false if there's no entry of kubectl client-side apply
+func mergeClientSideApplyFields(managedFields []metav1.ManagedFieldsEntry) (upgraded []metav1.ManagedFieldsEntry, ok bool, err error) {
+	clientSideIndex, serverSideIndex := -1, -1
+	for i, entry := range managedFields {
+		if clientSideIndex < 0 && entry.Manager == clientSideApplyManager && entry.Operation == metav1.ManagedFieldsOperationUpdate {
+			clientSideIndex = i
+		}
+		if serverSideIndex < 0 && entry.Manager == fieldManager && entry.Operation == metav1.ManagedFieldsOperationApply {
+			serverSideIndex = i
+		}
+	}
+	if clientSideIndex < 0 {
+		return managedFields, false, nil
+	}
+
+	entry := *managedFields[clientSideIndex].DeepCopy()
+	entry.Manager = fieldManager
+	entry.Operation = metav1.ManagedFieldsOperationApply
+
+	if serverSideIndex >= 0 {
+		entry = *managedFields[serverSideIndex].DeepCopy()
+
+		clientSideFields, err := decodeManagedFields(managedFields[clientSideIndex])
+		if err != nil {
+			return nil, false, err
+		}
+		serverSideFields, err := decodeManagedFields(managedFields[serverSideIndex])
+		if err != nil {
+			return nil, false, err
+		}
+		raw, err := serverSideFields.Union(clientSideFields).ToJSON()
+		if err != nil {
+			return nil, false, err
+		}
+		entry.FieldsV1 = &metav1.FieldsV1{Raw: raw}
+	}
+
+	// the merged entry takes the place of the first of both, so the order of the other managers stays the same
+	insertIndex := clientSideIndex
+	if serverSideIndex >= 0 && serverSideIndex < clientSideIndex {
+		insertIndex = serverSideIndex
+	}
+	for i := range managedFields {
+		switch i {
+		case insertIndex:
+			upgraded = append(upgraded, entry)
+		case clientSideIndex, serverSideIndex:
+		default:
+			upgraded = append(upgraded, managedFields[i])
+		}
+	}
+
+	return upgraded, true, nil
+}
+
+func decodeManagedFields(entry metav1.ManagedFieldsEntry) (*fieldpath.Set, error) {
+	fields := &fieldpath.Set{}
+	if entry.FieldsV1 == nil {
+		return fields, nil
+	}
+
+	err := fields.FromJSON(bytes.NewReader(entry.FieldsV1.Raw))
+	if err != nil {
+		return nil, fmt.Errorf("Can't decode fields managed by %v: %w", entry.Manager, err)
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,550 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: client.go
+
+// Package kubernetes is a generated GoMock package.
+package kubernetes
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	v1 "k8s.io/api/apps/v1"
+	v10 "k8s.io/api/batch/v1"
+	v11 "k8s.io/api/core/v1"
+	v12 "k8s.io/api/networking/v1"
+	v13 "k8s.io/api/policy/v1"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+)
+
+// MockClient is a mock of Client interface.
+type MockClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockClientMockRecorder
+}
+
+// MockClientMockRecorder is the mock recorder for MockClient.
+type MockClientMockRecorder struct {
+	mock *MockClient
+}
+
+// NewMockClient creates a new mock instance.
+func NewMockClient(ctrl *gomock.Controller) *MockClient {
+	mock := &MockClient{ctrl: ctrl}
+	mock.recorder = &MockClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClient) EXPECT() *MockClientMockRecorder {
+	return m.recorder
+}
+
+// Apply mocks base method.
+func (m *MockClient) Apply(ctx context.Context, namespace string, manifest []byte, dryRun bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Apply", ctx, namespace, manifest, dryRun)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Apply indicates an expected call of Apply.
+func (mr *MockClientMockRecorder) Apply(ctx, namespace, manifest, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Apply", reflect.TypeOf((*MockClient)(nil).Apply), ctx, namespace, manifest, dryRun)
+}
+
+// CreateJobFromCronJob mocks base method.
+func (m *MockClient) CreateJobFromCronJob(ctx context.Context, namespace, cronJobName, jobName string, labels map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateJobFromCronJob", ctx, namespace, cronJobName, jobName, labels)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateJobFromCronJob indicates an expected call of CreateJobFromCronJob.
+func (mr *MockClientMockRecorder) CreateJobFromCronJob(ctx, namespace, cronJobName, jobName, labels interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJobFromCronJob", reflect.TypeOf((*MockClient)(nil).CreateJobFromCronJob), ctx, namespace, cronJobName, jobName, labels)
+}
+
+// Delete mocks base method.
+func (m *MockClient) Delete(ctx context.Context, resource schema.GroupResource, namespace, name string, dryRun bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, resource, namespace, name, dryRun)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockClientMockRecorder) Delete(ctx, resource, namespace, name, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockClient)(nil).Delete), ctx, resource, namespace, name, dryRun)
+}
+
+// DeleteByLabelSelector mocks base method.
+func (m *MockClient) DeleteByLabelSelector(ctx context.Context, resources []schema.GroupResource, namespace, labelSelector string, dryRun bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByLabelSelector", ctx, resources, namespace, labelSelector, dryRun)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByLabelSelector indicates an expected call of DeleteByLabelSelector.
+func (mr *MockClientMockRecorder) DeleteByLabelSelector(ctx, resources, namespace, labelSelector, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByLabelSelector", reflect.TypeOf((*MockClient)(nil).DeleteByLabelSelector), ctx, resources, namespace, labelSelector, dryRun)
+}
+
+// Diff mocks base method.
+func (m *MockClient) Diff(ctx context.Context, namespace string, manifest []byte) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Diff", ctx, namespace, manifest)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Diff indicates an expected call of Diff.
+func (mr *MockClientMockRecorder) Diff(ctx, namespace, manifest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Diff", reflect.TypeOf((*MockClient)(nil).Diff), ctx, namespace, manifest)
+}
+
+// GetConfigMap mocks base method.
+func (m *MockClient) GetConfigMap(ctx context.Context, namespace, name string) (*v11.ConfigMap, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigMap", ctx, namespace, name)
+	ret0, _ := ret[0].(*v11.ConfigMap)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConfigMap indicates an expected call of GetConfigMap.
+func (mr *MockClientMockRecorder) GetConfigMap(ctx, namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigMap", reflect.TypeOf((*MockClient)(nil).GetConfigMap), ctx, namespace, name)
+}
+
+// GetCronJob mocks base method.
+func (m *MockClient) GetCronJob(ctx context.Context, namespace, name string) (*v10.CronJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCronJob", ctx, namespace, name)
+	ret0, _ := ret[0].(*v10.CronJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCronJob indicates an expected call of GetCronJob.
+func (mr *MockClientMockRecorder) GetCronJob(ctx, namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCronJob", reflect.TypeOf((*MockClient)(nil).GetCronJob), ctx, namespace, name)
+}
+
+// GetDeployment mocks base method.
+func (m *MockClient) GetDeployment(ctx context.Context, namespace, name string) (*v1.Deployment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeployment", ctx, namespace, name)
+	ret0, _ := ret[0].(*v1.Deployment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeployment indicates an expected call of GetDeployment.
+func (mr *MockClientMockRecorder) GetDeployment(ctx, namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeployment", reflect.TypeOf((*MockClient)(nil).GetDeployment), ctx, namespace, name)
+}
+
+// GetIngress mocks base method.
+func (m *MockClient) GetIngress(ctx context.Context, namespace, name string) (*v12.Ingress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIngress", ctx, namespace, name)
+	ret0, _ := ret[0].(*v12.Ingress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIngress indicates an expected call of GetIngress.
+func (mr *MockClientMockRecorder) GetIngress(ctx, namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIngress", reflect.TypeOf((*MockClient)(nil).GetIngress), ctx, namespace, name)
+}
+
+// GetPodDisruptionBudget mocks base method.
+func (m *MockClient) GetPodDisruptionBudget(ctx context.Context, namespace, name string) (*v13.PodDisruptionBudget, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPodDisruptionBudget", ctx, namespace, name)
+	ret0, _ := ret[0].(*v13.PodDisruptionBudget)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPodDisruptionBudget indicates an expected call of GetPodDisruptionBudget.
+func (mr *MockClientMockRecorder) GetPodDisruptionBudget(ctx, namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPodDisruptionBudget", reflect.TypeOf((*MockClient)(nil).GetPodDisruptionBudget), ctx, namespace, name)
+}
+
+// GetSecret mocks base method.
+func (m *MockClient) GetSecret(ctx context.Context, namespace, name string) (*v11.Secret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecret", ctx, namespace, name)
+	ret0, _ := ret[0].(*v11.Secret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecret indicates an expected call of GetSecret.
+func (mr *MockClientMockRecorder) GetSecret(ctx, namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecret", reflect.TypeOf((*MockClient)(nil).GetSecret), ctx, namespace, name)
+}
+
+// GetService mocks base method.
+func (m *MockClient) GetService(ctx context.Context, namespace, name string) (*v11.Service, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetService", ctx, namespace, name)
+	ret0, _ := ret[0].(*v11.Service)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetService indicates an expected call of GetService.
+func (mr *MockClientMockRecorder) GetService(ctx, namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetService", reflect.TypeOf((*MockClient)(nil).GetService), ctx, namespace, name)
+}
+
+// Init mocks base method.
+func (m *MockClient) Init(ctx context.Context, kubeContextName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Init", ctx, kubeContextName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Init indicates an expected call of Init.
+func (mr *MockClientMockRecorder) Init(ctx, kubeContextName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockClient)(nil).Init), ctx, kubeContextName)
+}
+
+// ListConfigMaps mocks base method.
+func (m *MockClient) ListConfigMaps(ctx context.Context, namespace, labelSelector string) ([]v11.ConfigMap, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListConfigMaps", ctx, namespace, labelSelector)
+	ret0, _ := ret[0].([]v11.ConfigMap)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListConfigMaps indicates an expected call of ListConfigMaps.
+func (mr *MockClientMockRecorder) ListConfigMaps(ctx, namespace, labelSelector interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListConfigMaps", reflect.TypeOf((*MockClient)(nil).ListConfigMaps), ctx, namespace, labelSelector)
+}
+
+// ListDeployments mocks base method.
+func (m *MockClient) ListDeployments(ctx context.Context, namespace, labelSelector string) ([]v1.Deployment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeployments", ctx, namespace, labelSelector)
+	ret0, _ := ret[0].([]v1.Deployment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeployments indicates an expected call of ListDeployments.
+func (mr *MockClientMockRecorder) ListDeployments(ctx, namespace, labelSelector interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeployments", reflect.TypeOf((*MockClient)(nil).ListDeployments), ctx, namespace, labelSelector)
+}
+
+// ListSecrets mocks base method.
+func (m *MockClient) ListSecrets(ctx context.Context, namespace, labelSelector string) ([]v11.Secret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSecrets", ctx, namespace, labelSelector)
+	ret0, _ := ret[0].([]v11.Secret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSecrets indicates an expected call of ListSecrets.
+func (mr *MockClientMockRecorder) ListSecrets(ctx, namespace, labelSelector interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecrets", reflect.TypeOf((*MockClient)(nil).ListSecrets), ctx, namespace, labelSelector)
+}
+
+// ManifestObjects mocks base method.
+func (m *MockClient) ManifestObjects(manifest []byte, labelSelector string) ([]ObjectReference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ManifestObjects", manifest, labelSelector)
+	ret0, _ := ret[0].([]ObjectReference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ManifestObjects indicates an expected call of ManifestObjects.
+func (mr *MockClientMockRecorder) ManifestObjects(manifest, labelSelector interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ManifestObjects", reflect.TypeOf((*MockClient)(nil).ManifestObjects), manifest, labelSelector)
+}
+
+// PatchDeployment mocks base method.
+func (m *MockClient) PatchDeployment(ctx context.Context, namespace, name string, patchType types.PatchType, patch []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchDeployment", ctx, namespace, name, patchType, patch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchDeployment indicates an expected call of PatchDeployment.
+func (mr *MockClientMockRecorder) PatchDeployment(ctx, namespace, name, patchType, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchDeployment", reflect.TypeOf((*MockClient)(nil).PatchDeployment), ctx, namespace, name, patchType, patch)
+}
+
+// PatchService mocks base method.
+func (m *MockClient) PatchService(ctx context.Context, namespace, name string, patchType types.PatchType, patch []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchService", ctx, namespace, name, patchType, patch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchService indicates an expected call of PatchService.
+func (mr *MockClientMockRecorder) PatchService(ctx, namespace, name, patchType, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchService", reflect.TypeOf((*MockClient)(nil).PatchService), ctx, namespace, name, patchType, patch)
+}
+
+// PrintPodLogs mocks base method.
+func (m *MockClient) PrintPodLogs(ctx context.Context, namespace, labelSelector, container string, tailLines int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrintPodLogs", ctx, namespace, labelSelector, container, tailLines)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PrintPodLogs indicates an expected call of PrintPodLogs.
+func (mr *MockClientMockRecorder) PrintPodLogs(ctx, namespace, labelSelector, container, tailLines interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrintPodLogs", reflect.TypeOf((*MockClient)(nil).PrintPodLogs), ctx, namespace, labelSelector, container, tailLines)
+}
+
+// PrintResources mocks base method.
+func (m *MockClient) PrintResources(ctx context.Context, resources []schema.GroupResource, namespace, labelSelector string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrintResources", ctx, resources, namespace, labelSelector)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PrintResources indicates an expected call of PrintResources.
+func (mr *MockClientMockRecorder) PrintResources(ctx, resources, namespace, labelSelector interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrintResources", reflect.TypeOf((*MockClient)(nil).PrintResources), ctx, resources, namespace, labelSelector)
+}
+
+// Prune mocks base method.
+func (m *MockClient) Prune(ctx context.Context, namespace string, references []ObjectReference, labelSelector string, dryRun bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Prune", ctx, namespace, references, labelSelector, dryRun)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Prune indicates an expected call of Prune.
+func (mr *MockClientMockRecorder) Prune(ctx, namespace, references, labelSelector, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prune", reflect.TypeOf((*MockClient)(nil).Prune), ctx, namespace, references, labelSelector, dryRun)
+}
+
+// RemoveAnnotations mocks base method.
+func (m *MockClient) RemoveAnnotations(ctx context.Context, resource schema.GroupResource, namespace, name string, annotations ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, resource, namespace, name}
+	for _, a := range annotations {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RemoveAnnotations", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveAnnotations indicates an expected call of RemoveAnnotations.
+func (mr *MockClientMockRecorder) RemoveAnnotations(ctx, resource, namespace, name interface{}, annotations ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, resource, namespace, name}, annotations...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAnnotations", reflect.TypeOf((*MockClient)(nil).RemoveAnnotations), varargs...)
+}
+
+// RestartDeployment mocks base method.
+func (m *MockClient) RestartDeployment(ctx context.Context, namespace, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestartDeployment", ctx, namespace, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestartDeployment indicates an expected call of RestartDeployment.
+func (mr *MockClientMockRecorder) RestartDeployment(ctx, namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestartDeployment", reflect.TypeOf((*MockClient)(nil).RestartDeployment), ctx, namespace, name)
+}
+
+// RollbackDeployment mocks base method.
+func (m *MockClient) RollbackDeployment(ctx context.Context, namespace, name string, revision int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollbackDeployment", ctx, namespace, name, revision)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RollbackDeployment indicates an expected call of RollbackDeployment.
+func (mr *MockClientMockRecorder) RollbackDeployment(ctx, namespace, name, revision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollbackDeployment", reflect.TypeOf((*MockClient)(nil).RollbackDeployment), ctx, namespace, name, revision)
+}
+
+// ScaleDeployment mocks base method.
+func (m *MockClient) ScaleDeployment(ctx context.Context, namespace, name string, replicas int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScaleDeployment", ctx, namespace, name, replicas)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ScaleDeployment indicates an expected call of ScaleDeployment.
+func (mr *MockClientMockRecorder) ScaleDeployment(ctx, namespace, name, replicas interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScaleDeployment", reflect.TypeOf((*MockClient)(nil).ScaleDeployment), ctx, namespace, name, replicas)
+}
+
+// StreamJobLogs mocks base method.
+func (m *MockClient) StreamJobLogs(ctx context.Context, namespace, name, container string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamJobLogs", ctx, namespace, name, container)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamJobLogs indicates an expected call of StreamJobLogs.
+func (mr *MockClientMockRecorder) StreamJobLogs(ctx, namespace, name, container interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamJobLogs", reflect.TypeOf((*MockClient)(nil).StreamJobLogs), ctx, namespace, name, container)
+}
+
+// SuspendCronJob mocks base method.
+func (m *MockClient) SuspendCronJob(ctx context.Context, namespace, name string, suspend bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuspendCronJob", ctx, namespace, name, suspend)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SuspendCronJob indicates an expected call of SuspendCronJob.
+func (mr *MockClientMockRecorder) SuspendCronJob(ctx, namespace, name, suspend interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuspendCronJob", reflect.TypeOf((*MockClient)(nil).SuspendCronJob), ctx, namespace, name, suspend)
+}
+
+// UpdateConfigMapData mocks base method.
+func (m *MockClient) UpdateConfigMapData(ctx context.Context, namespace, name string, data map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateConfigMapData", ctx, namespace, name, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateConfigMapData indicates an expected call of UpdateConfigMapData.
+func (mr *MockClientMockRecorder) UpdateConfigMapData(ctx, namespace, name, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateConfigMapData", reflect.TypeOf((*MockClient)(nil).UpdateConfigMapData), ctx, namespace, name, data)
+}
+
+// UpdateSecretData mocks base method.
+func (m *MockClient) UpdateSecretData(ctx context.Context, namespace, name string, data map[string][]byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSecretData", ctx, namespace, name, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSecretData indicates an expected call of UpdateSecretData.
+func (mr *MockClientMockRecorder) UpdateSecretData(ctx, namespace, name, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSecretData", reflect.TypeOf((*MockClient)(nil).UpdateSecretData), ctx, namespace, name, data)
+}
+
+// WaitForDaemonSetRollout mocks base method.
+func (m *MockClient) WaitForDaemonSetRollout(ctx context.Context, namespace, name string, timeout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForDaemonSetRollout", ctx, namespace, name, timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitForDaemonSetRollout indicates an expected call of WaitForDaemonSetRollout.
+func (mr *MockClientMockRecorder) WaitForDaemonSetRollout(ctx, namespace, name, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForDaemonSetRollout", reflect.TypeOf((*MockClient)(nil).WaitForDaemonSetRollout), ctx, namespace, name, timeout)
+}
+
+// WaitForDeletion mocks base method.
+func (m *MockClient) WaitForDeletion(ctx context.Context, resource schema.GroupResource, namespace, name string, timeout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForDeletion", ctx, resource, namespace, name, timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitForDeletion indicates an expected call of WaitForDeletion.
+func (mr *MockClientMockRecorder) WaitForDeletion(ctx, resource, namespace, name, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForDeletion", reflect.TypeOf((*MockClient)(nil).WaitForDeletion), ctx, resource, namespace, name, timeout)
+}
+
+// WaitForDeploymentRollout mocks base method.
+func (m *MockClient) WaitForDeploymentRollout(ctx context.Context, namespace, name string, timeout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForDeploymentRollout", ctx, namespace, name, timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitForDeploymentRollout indicates an expected call of WaitForDeploymentRollout.
+func (mr *MockClientMockRecorder) WaitForDeploymentRollout(ctx, namespace, name, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForDeploymentRollout", reflect.TypeOf((*MockClient)(nil).WaitForDeploymentRollout), ctx, namespace, name, timeout)
+}
+
+// WaitForJobCompletion mocks base method.
+func (m *MockClient) WaitForJobCompletion(ctx context.Context, namespace, name string, timeout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForJobCompletion", ctx, namespace, name, timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitForJobCompletion indicates an expected call of WaitForJobCompletion.
+func (mr *MockClientMockRecorder) WaitForJobCompletion(ctx, namespace, name, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForJobCompletion", reflect.TypeOf((*MockClient)(nil).WaitForJobCompletion), ctx, namespace, name, timeout)
+}
+
+// WaitForStatefulSetRollout mocks base method.
+func (m *MockClient) WaitForStatefulSetRollout(ctx context.Context, namespace, name string, timeout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForStatefulSetRollout", ctx, namespace, name, timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitForStatefulSetRollout indicates an expected call of WaitForStatefulSetRollout.
+func (mr *MockClientMockRecorder) WaitForStatefulSetRollout(ctx, namespace, name, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForStatefulSetRollout", reflect.TypeOf((*MockClient)(nil).WaitForStatefulSetRollout), ctx, namespace, name, timeout)
+}
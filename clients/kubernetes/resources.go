@@ -0,0 +1,25 @@
+package kubernetes
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// resource types the extension creates, inspects or cleans up; the version is resolved against the cluster when used
+var (
+	Services                 = schema.GroupResource{Group: "", Resource: "services"}
+	Endpoints                = schema.GroupResource{Group: "", Resource: "endpoints"}
+	ConfigMaps               = schema.GroupResource{Group: "", Resource: "configmaps"}
+	Secrets                  = schema.GroupResource{Group: "", Resource: "secrets"}
+	ServiceAccounts          = schema.GroupResource{Group: "", Resource: "serviceaccounts"}
+	Pods                     = schema.GroupResource{Group: "", Resource: "pods"}
+	Deployments              = schema.GroupResource{Group: "apps", Resource: "deployments"}
+	StatefulSets             = schema.GroupResource{Group: "apps", Resource: "statefulsets"}
+	DaemonSets               = schema.GroupResource{Group: "apps", Resource: "daemonsets"}
+	Jobs                     = schema.GroupResource{Group: "batch", Resource: "jobs"}
+	CronJobs                 = schema.GroupResource{Group: "batch", Resource: "cronjobs"}
+	Ingresses                = schema.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"}
+	HorizontalPodAutoscalers = schema.GroupResource{Group: "autoscaling", Resource: "horizontalpodautoscalers"}
+	PodDisruptionBudgets     = schema.GroupResource{Group: "policy", Resource: "poddisruptionbudgets"}
+	BackendConfigs           = schema.GroupResource{Group: "cloud.google.com", Resource: "backendconfigs"}
+	ScaledObjects            = schema.GroupResource{Group: "keda.sh", Resource: "scaledobjects"}
+)
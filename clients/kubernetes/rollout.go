@@ -0,0 +1,120 @@
+package kubernetes
+
+import (
+	"fmt"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// deploymentRolloutStatus mirrors the status checks kubectl rollout status does for a deployment
+func deploymentRolloutStatus(deployment *appsv1.Deployment) (done bool, message string, err error) {
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return false, "Waiting for deployment spec update to be observed...", nil
+	}
+
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			return false, "", ErrRolloutFailed.wrap(fmt.Errorf("deployment %q exceeded its progress deadline", deployment.Name))
+		}
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	if deployment.Status.UpdatedReplicas < replicas {
+		return false, fmt.Sprintf("Waiting for deployment %q rollout to finish: %d out of %d new replicas have been updated...", deployment.Name, deployment.Status.UpdatedReplicas, replicas), nil
+	}
+	if deployment.Status.Replicas > deployment.Status.UpdatedReplicas {
+		return false, fmt.Sprintf("Waiting for deployment %q rollout to finish: %d old replicas are pending termination...", deployment.Name, deployment.Status.Replicas-deployment.Status.UpdatedReplicas), nil
+	}
+	if deployment.Status.AvailableReplicas < deployment.Status.UpdatedReplicas {
+		return false, fmt.Sprintf("Waiting for deployment %q rollout to finish: %d of %d updated replicas are available...", deployment.Name, deployment.Status.AvailableReplicas, deployment.Status.UpdatedReplicas), nil
+	}
+
+	return true, fmt.Sprintf("deployment %q successfully rolled out", deployment.Name), nil
+}
+
+// statefulSetRolloutStatus mirrors the status checks kubectl rollout status does for a statefulset
+func statefulSetRolloutStatus(statefulSet *appsv1.StatefulSet) (done bool, message string) {
+	if statefulSet.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
+		return true, fmt.Sprintf("statefulset %q uses update strategy %v, not waiting for its pods to be updated", statefulSet.Name, statefulSet.Spec.UpdateStrategy.Type)
+	}
+
+	if statefulSet.Status.ObservedGeneration == 0 || statefulSet.Generation > statefulSet.Status.ObservedGeneration {
+		return false, "Waiting for statefulset spec update to be observed..."
+	}
+
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+
+	if statefulSet.Status.ReadyReplicas < replicas {
+		return false, fmt.Sprintf("Waiting for %d pods to be ready...", replicas-statefulSet.Status.ReadyReplicas)
+	}
+
+	if statefulSet.Spec.UpdateStrategy.RollingUpdate != nil && statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition != nil && *statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition > 0 {
+		partition := *statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition
+		if statefulSet.Status.UpdatedReplicas < replicas-partition {
+			return false, fmt.Sprintf("Waiting for partitioned roll out to finish: %d out of %d new pods have been updated...", statefulSet.Status.UpdatedReplicas, replicas-partition)
+		}
+		return true, fmt.Sprintf("partitioned roll out complete: %d new pods have been updated...", statefulSet.Status.UpdatedReplicas)
+	}
+
+	if statefulSet.Status.UpdateRevision != statefulSet.Status.CurrentRevision {
+		return false, fmt.Sprintf("waiting for statefulset rolling update to complete %d pods at revision %s...", statefulSet.Status.UpdatedReplicas, statefulSet.Status.UpdateRevision)
+	}
+
+	return true, fmt.Sprintf("statefulset rolling update complete %d pods at revision %s...", statefulSet.Status.CurrentReplicas, statefulSet.Status.CurrentRevision)
+}
+
+// daemonSetRolloutStatus mirrors the status checks kubectl rollout status does for a daemonset
+func daemonSetRolloutStatus(daemonSet *appsv1.DaemonSet) (done bool, message string) {
+	if daemonSet.Spec.UpdateStrategy.Type != appsv1.RollingUpdateDaemonSetStrategyType {
+		return true, fmt.Sprintf("daemonset %q uses update strategy %v, not waiting for its pods to be updated", daemonSet.Name, daemonSet.Spec.UpdateStrategy.Type)
+	}
+
+	if daemonSet.Generation > daemonSet.Status.ObservedGeneration {
+		return false, "Waiting for daemonset spec update to be observed..."
+	}
+
+	if daemonSet.Status.UpdatedNumberScheduled < daemonSet.Status.DesiredNumberScheduled {
+		return false, fmt.Sprintf("Waiting for daemonset %q rollout to finish: %d out of %d new pods have been updated...", daemonSet.Name, daemonSet.Status.UpdatedNumberScheduled, daemonSet.Status.DesiredNumberScheduled)
+	}
+	if daemonSet.Status.NumberAvailable < daemonSet.Status.DesiredNumberScheduled {
+		return false, fmt.Sprintf("Waiting for daemonset %q rollout to finish: %d of %d updated pods are available...", daemonSet.Name, daemonSet.Status.NumberAvailable, daemonSet.Status.DesiredNumberScheduled)
+	}
+
+	return true, fmt.Sprintf("daemonset %q successfully rolled out", daemonSet.Name)
+}
+
+// jobCompletionStatus reports whether the job has completed, or returns ErrJobFailed if it has failed
+func jobCompletionStatus(job *batchv1.Job) (done bool, message string, err error) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, fmt.Sprintf("job %q completed", job.Name), nil
+		case batchv1.JobFailed:
+			return false, "", ErrJobFailed.wrap(fmt.Errorf("job %q failed with reason %v: %v", job.Name, condition.Reason, condition.Message))
+		}
+	}
+
+	return false, fmt.Sprintf("Waiting for job %q to complete: %d active, %d succeeded, %d failed pods...", job.Name, job.Status.Active, job.Status.Succeeded, job.Status.Failed), nil
+}
+
+// DeploymentRevision returns the revision the deployment controller assigned to the current pod template of the deployment, or 0 if it hasn't assigned one yet
+func DeploymentRevision(deployment *appsv1.Deployment) int64 {
+	revision, err := strconv.ParseInt(deployment.Annotations[deploymentRevisionAnnotation], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return revision
+}
@@ -14,6 +14,8 @@ import (
 //go:generate mockgen -package=parameters -destination ./mock.go -source=client.go
 type Client interface {
 	Init(ctx context.Context, paramsYAML string, credential *api.GKECredentials, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID string) (parameters api.Params, err error)
+	InitOffline(ctx context.Context, paramsYAML string, credential *api.GKECredentials, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID string) (parameters api.Params, err error)
+	Validate(ctx context.Context, paramsYAML string, credential *api.GKECredentials, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID string) (findings []api.ValidationError, err error)
 }
 
 // NewClient returns a new gcp.Client
@@ -26,6 +28,62 @@ type client struct {
 
 func (c *client) Init(ctx context.Context, paramsYAML string, credential *api.GKECredentials, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID string) (parameters api.Params, err error) {
 
+	parameters, err = c.InitOffline(ctx, paramsYAML, credential, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID)
+	if err != nil {
+		return
+	}
+
+	// replacing sidecar image tags with digest
+	parameters.ReplaceSidecarTagsWithDigest()
+
+	return
+}
+
+// InitOffline sets defaults and validates the parameters like Init, but leaves sidecar image tags as is to avoid accessing the container registry
+func (c *client) InitOffline(ctx context.Context, paramsYAML string, credential *api.GKECredentials, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID string) (parameters api.Params, err error) {
+
+	parameters, unknownParams, err := c.unmarshalWithDefaults(paramsYAML, credential, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID)
+	if err != nil {
+		return
+	}
+
+	log.Info().Msg("Validating required parameters...")
+	valid, errors, warnings := parameters.ValidateRequiredProperties()
+	for _, finding := range unknownParams {
+		if finding.Severity == api.SeverityError {
+			valid = false
+			errors = append([]error{finding}, errors...)
+			continue
+		}
+		warnings = append(warnings, finding.Error())
+	}
+	if !valid {
+		return parameters, fmt.Errorf("Not all valid fields are set: %v", errors)
+	}
+
+	for _, warning := range warnings {
+		log.Printf("Warning: %s", warning)
+	}
+
+	return
+}
+
+// Validate sets defaults for the parameters like Init and returns all errors and warnings instead of failing on the first invalid set
+func (c *client) Validate(ctx context.Context, paramsYAML string, credential *api.GKECredentials, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID string) (findings []api.ValidationError, err error) {
+
+	parameters, unknownParams, err := c.unmarshalWithDefaults(paramsYAML, credential, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Msg("Validating parameters...")
+	return append(unknownParams, parameters.Validate()...), nil
+}
+
+// unmarshalWithDefaults returns the parameters with defaults set and a finding for each unknown parameter in paramsYAML,
+// which is an error or a warning depending on the unknownParams parameter
+func (c *client) unmarshalWithDefaults(paramsYAML string, credential *api.GKECredentials, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID string) (parameters api.Params, unknownParams []api.ValidationError, err error) {
+
 	// put all estafette labels in map
 	log.Info().Msg("Getting all estafette labels from envvars...")
 	estafetteLabels := map[string]string{}
@@ -57,24 +115,21 @@ func (c *client) Init(ctx context.Context, paramsYAML string, credential *api.GK
 	log.Info().Msg("Unmarshalling parameters / custom properties...")
 	err = yaml.Unmarshal([]byte(paramsYAML), &parameters)
 	if err != nil {
-		return parameters, fmt.Errorf("Failed unmarshalling parameters: %w", err)
+		return parameters, nil, fmt.Errorf("Failed unmarshalling parameters: %w", err)
 	}
 
 	log.Info().Msg("Setting defaults for parameters that are not set in the manifest...")
 	parameters.SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, api.ActionType(releaseAction), releaseID, estafetteLabels)
 
-	log.Info().Msg("Validating required parameters...")
-	valid, errors, warnings := parameters.ValidateRequiredProperties()
-	if !valid {
-		return parameters, fmt.Errorf("Not all valid fields are set: %v", errors)
+	log.Info().Msg("Checking for unknown parameters...")
+	severity := api.SeverityWarning
+	if parameters.UnknownParams == api.UnknownParamsError {
+		severity = api.SeverityError
 	}
-
-	for _, warning := range warnings {
-		log.Printf("Warning: %s", warning)
+	unknownParams, err = api.FindUnknownParams([]byte(paramsYAML), severity)
+	if err != nil {
+		return parameters, nil, err
 	}
 
-	// replacing sidecar image tags with digest
-	parameters.ReplaceSidecarTagsWithDigest()
-
 	return
 }
@@ -49,3 +49,33 @@ func (mr *MockClientMockRecorder) Init(ctx, paramsYAML, credential, gitSource, g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockClient)(nil).Init), ctx, paramsYAML, credential, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID)
 }
+
+// InitOffline mocks base method.
+func (m *MockClient) InitOffline(ctx context.Context, paramsYAML string, credential *api.GKECredentials, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID string) (api.Params, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InitOffline", ctx, paramsYAML, credential, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID)
+	ret0, _ := ret[0].(api.Params)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InitOffline indicates an expected call of InitOffline.
+func (mr *MockClientMockRecorder) InitOffline(ctx, paramsYAML, credential, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitOffline", reflect.TypeOf((*MockClient)(nil).InitOffline), ctx, paramsYAML, credential, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID)
+}
+
+// Validate mocks base method.
+func (m *MockClient) Validate(ctx context.Context, paramsYAML string, credential *api.GKECredentials, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID string) ([]api.ValidationError, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Validate", ctx, paramsYAML, credential, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID)
+	ret0, _ := ret[0].([]api.ValidationError)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Validate indicates an expected call of Validate.
+func (mr *MockClientMockRecorder) Validate(ctx, paramsYAML, credential, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockClient)(nil).Validate), ctx, paramsYAML, credential, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID)
+}
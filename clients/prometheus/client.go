@@ -0,0 +1,125 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrQueryFailed is returned when prometheus can't be reached, returns an error or a response that isn't a single value
+	ErrQueryFailed = wrapError{msg: "Prometheus query failed"}
+
+	// ErrNoData is returned when a query succeeds, but doesn't return any samples
+	ErrNoData = wrapError{msg: "Prometheus query returned no data"}
+)
+
+//go:generate mockgen -package=prometheus -destination ./mock.go -source=client.go
+type Client interface {
+	Query(ctx context.Context, serverURL, query string) (value float64, err error)
+}
+
+// NewClient returns a new prometheus.Client
+func NewClient(ctx context.Context) (Client, error) {
+	return &client{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+type client struct {
+	httpClient *http.Client
+}
+
+type queryResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type vectorSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value"`
+}
+
+func (c *client) Query(ctx context.Context, serverURL, query string) (value float64, err error) {
+
+	queryURL := fmt.Sprintf("%v/api/v1/query?query=%v", strings.TrimSuffix(serverURL, "/"), url.QueryEscape(query))
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, queryURL, nil)
+	if err != nil {
+		return 0, ErrQueryFailed.wrap(err)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return 0, ErrQueryFailed.wrap(err)
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return 0, ErrQueryFailed.wrap(err)
+	}
+
+	var queryResponse queryResponse
+	err = json.Unmarshal(body, &queryResponse)
+	if err != nil {
+		return 0, ErrQueryFailed.wrap(fmt.Errorf("Failed unmarshalling response with status code %v: %w", response.StatusCode, err))
+	}
+	if queryResponse.Status != "success" {
+		return 0, ErrQueryFailed.wrap(fmt.Errorf("%v: %v", queryResponse.ErrorType, queryResponse.Error))
+	}
+
+	switch queryResponse.Data.ResultType {
+	case "vector":
+		var samples []vectorSample
+		err = json.Unmarshal(queryResponse.Data.Result, &samples)
+		if err != nil {
+			return 0, ErrQueryFailed.wrap(err)
+		}
+		if len(samples) == 0 {
+			return 0, ErrNoData.wrap(fmt.Errorf("Query %v", query))
+		}
+		if len(samples) > 1 {
+			return 0, ErrQueryFailed.wrap(fmt.Errorf("Query %v returned %v series instead of 1; aggregate it, for example with sum()", query, len(samples)))
+		}
+		return parseSampleValue(samples[0].Value)
+
+	case "scalar":
+		var sample []interface{}
+		err = json.Unmarshal(queryResponse.Data.Result, &sample)
+		if err != nil {
+			return 0, ErrQueryFailed.wrap(err)
+		}
+		return parseSampleValue(sample)
+	}
+
+	return 0, ErrQueryFailed.wrap(fmt.Errorf("Query %v returned result type %v instead of vector or scalar", query, queryResponse.Data.ResultType))
+}
+
+// parseSampleValue reads the value from a [<unix time>, "<value>"] pair
+func parseSampleValue(sample []interface{}) (value float64, err error) {
+	if len(sample) != 2 {
+		return 0, ErrQueryFailed.wrap(fmt.Errorf("Sample %v doesn't have a timestamp and value", sample))
+	}
+	valueString, ok := sample[1].(string)
+	if !ok {
+		return 0, ErrQueryFailed.wrap(fmt.Errorf("Sample value %v isn't a string", sample[1]))
+	}
+	value, err = strconv.ParseFloat(valueString, 64)
+	if err != nil {
+		return 0, ErrQueryFailed.wrap(err)
+	}
+
+	return value, nil
+}
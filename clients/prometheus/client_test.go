@@ -0,0 +1,118 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery(t *testing.T) {
+
+	t.Run("ReturnsValueOfSingleVectorSample", func(t *testing.T) {
+
+		server := newTestServer(t, http.StatusOK, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"track":"canary"},"value":[1629451200.123,"0.25"]}]}}`)
+		client, err := NewClient(context.Background())
+		assert.Nil(t, err)
+
+		// act
+		value, err := client.Query(context.Background(), server.URL, `sum(rate(nginx_http_requests_total{track="canary"}[5m]))`)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0.25, value)
+	})
+
+	t.Run("ReturnsValueOfScalar", func(t *testing.T) {
+
+		server := newTestServer(t, http.StatusOK, `{"status":"success","data":{"resultType":"scalar","result":[1629451200.123,"42"]}}`)
+		client, err := NewClient(context.Background())
+		assert.Nil(t, err)
+
+		// act
+		value, err := client.Query(context.Background(), server.URL, "scalar(vector(42))")
+
+		assert.Nil(t, err)
+		assert.Equal(t, float64(42), value)
+	})
+
+	t.Run("PassesQueryAsEscapedParameter", func(t *testing.T) {
+
+		query := `histogram_quantile(0.95, sum(rate(http_request_duration_seconds_bucket{app="myapp",track="canary"}[5m])) by (le))`
+		receivedQuery := ""
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			receivedQuery = r.URL.Query().Get("query")
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"scalar","result":[1629451200.123,"1"]}}`))
+		}))
+		defer server.Close()
+		client, err := NewClient(context.Background())
+		assert.Nil(t, err)
+
+		// act
+		_, err = client.Query(context.Background(), server.URL+"/", query)
+
+		assert.Nil(t, err)
+		assert.Equal(t, query, receivedQuery)
+	})
+
+	t.Run("ReturnsErrNoDataIfVectorIsEmpty", func(t *testing.T) {
+
+		server := newTestServer(t, http.StatusOK, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+		client, err := NewClient(context.Background())
+		assert.Nil(t, err)
+
+		// act
+		_, err = client.Query(context.Background(), server.URL, "up")
+
+		assert.True(t, errors.Is(err, ErrNoData))
+	})
+
+	t.Run("ReturnsErrQueryFailedIfVectorHasMultipleSeries", func(t *testing.T) {
+
+		server := newTestServer(t, http.StatusOK, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"pod":"a"},"value":[1629451200.123,"1"]},{"metric":{"pod":"b"},"value":[1629451200.123,"1"]}]}}`)
+		client, err := NewClient(context.Background())
+		assert.Nil(t, err)
+
+		// act
+		_, err = client.Query(context.Background(), server.URL, "up")
+
+		assert.True(t, errors.Is(err, ErrQueryFailed))
+	})
+
+	t.Run("ReturnsErrQueryFailedIfPrometheusReturnsError", func(t *testing.T) {
+
+		server := newTestServer(t, http.StatusBadRequest, `{"status":"error","errorType":"bad_data","error":"parse error at char 4"}`)
+		client, err := NewClient(context.Background())
+		assert.Nil(t, err)
+
+		// act
+		_, err = client.Query(context.Background(), server.URL, "sum(")
+
+		assert.True(t, errors.Is(err, ErrQueryFailed))
+		assert.Contains(t, err.Error(), "parse error at char 4")
+	})
+
+	t.Run("ReturnsErrQueryFailedIfResponseIsNotJSON", func(t *testing.T) {
+
+		server := newTestServer(t, http.StatusBadGateway, `<html>Bad Gateway</html>`)
+		client, err := NewClient(context.Background())
+		assert.Nil(t, err)
+
+		// act
+		_, err = client.Query(context.Background(), server.URL, "up")
+
+		assert.True(t, errors.Is(err, ErrQueryFailed))
+	})
+}
+
+func newTestServer(t *testing.T, statusCode int, body string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
@@ -2,18 +2,27 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"runtime"
 
 	"github.com/alecthomas/kingpin"
 	"github.com/estafette/estafette-extension-gke/api"
 	"github.com/estafette/estafette-extension-gke/clients/credentials"
 	"github.com/estafette/estafette-extension-gke/clients/gcp"
+	"github.com/estafette/estafette-extension-gke/clients/kubernetes"
 	"github.com/estafette/estafette-extension-gke/clients/parameters"
+	"github.com/estafette/estafette-extension-gke/clients/prometheus"
 	"github.com/estafette/estafette-extension-gke/services/builder"
 	"github.com/estafette/estafette-extension-gke/services/extension"
 	"github.com/estafette/estafette-extension-gke/services/generator"
+	"github.com/estafette/estafette-extension-gke/services/validator"
 	foundation "github.com/estafette/estafette-foundation"
 	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v2"
 )
 
 var (
@@ -27,10 +36,27 @@ var (
 )
 
 var (
+	// commands
+	deployCommand   = kingpin.Command("deploy", "Releases the application to a gke cluster; the default when running as estafette extension.").Default()
+	renderCommand   = kingpin.Command("render", "Renders the manifests for a release without accessing gcp or the cluster, to preview them locally.")
+	validateCommand = kingpin.Command("validate", "Validates the parameters for a release and reports all findings as json or sarif, for linters and editors.")
+	schemaCommand   = kingpin.Command("schema", "Prints the JSON Schema for the stage parameters, for autocompletion in editors.")
+
 	// flags
-	paramsJSON      = kingpin.Flag("params", "Extension parameters, created from custom properties.").Envar("ESTAFETTE_EXTENSION_CUSTOM_PROPERTIES").Required().String()
-	paramsYAML      = kingpin.Flag("params-yaml", "Extension parameters, created from custom properties.").Envar("ESTAFETTE_EXTENSION_CUSTOM_PROPERTIES_YAML").Required().String()
-	credentialsPath = kingpin.Flag("credentials-path", "Path to GKE credentials configured at service level, passed in to this trusted extension.").Default("/credentials/kubernetes_engine.json").String()
+	paramsJSON      = deployCommand.Flag("params", "Extension parameters, created from custom properties.").Envar("ESTAFETTE_EXTENSION_CUSTOM_PROPERTIES").Required().String()
+	paramsYAML      = deployCommand.Flag("params-yaml", "Extension parameters, created from custom properties.").Envar("ESTAFETTE_EXTENSION_CUSTOM_PROPERTIES_YAML").Required().String()
+	credentialsPath = deployCommand.Flag("credentials-path", "Path to GKE credentials configured at service level, passed in to this trusted extension.").Default("/credentials/kubernetes_engine.json").String()
+
+	// render flags
+	renderParamsFile   = renderCommand.Flag("params-file", "Path to a yaml file with the extension parameters, as set on the release stage.").Required().ExistingFile()
+	renderDefaultsFile = renderCommand.Flag("defaults-file", "Path to a yaml file with the parameter defaults, as set in the credential.").ExistingFile()
+	renderOutputDir    = renderCommand.Flag("output-dir", "Directory to write the rendered kubernetes.yaml to; printed to stdout if not set.").String()
+
+	// validate flags
+	validateParamsFile   = validateCommand.Flag("params-file", "Path to a yaml file with the extension parameters, as set on the release stage.").Required().ExistingFile()
+	validateDefaultsFile = validateCommand.Flag("defaults-file", "Path to a yaml file with the parameter defaults, as set in the credential.").ExistingFile()
+	validateFormat       = validateCommand.Flag("format", "Format of the findings.").Default("json").Enum("json", "sarif")
+	validateOutput       = validateCommand.Flag("output", "File to write the findings to; printed to stdout if not set.").String()
 
 	// optional flags
 	gitSource     = kingpin.Flag("git-source", "Repository source.").Envar("ESTAFETTE_GIT_SOURCE").String()
@@ -44,15 +70,38 @@ var (
 	releaseAction = kingpin.Flag("release-action", "Name of the release action, to control the type of release.").Envar("ESTAFETTE_RELEASE_ACTION").String()
 	releaseID     = kingpin.Flag("release-id", "ID of the release, to use as a label.").Envar("ESTAFETTE_RELEASE_ID").String()
 	triggeredBy   = kingpin.Flag("triggered-by", "The user id of the person triggering the release.").Envar("ESTAFETTE_TRIGGER_MANUAL_USER_ID").String()
-
-	assistTroubleshootingOnError = false
-	paramsForTroubleshooting     = api.Params{}
+	templatesDir  = kingpin.Flag("templates-dir", "Directory with templates to use instead of the embedded ones with the same file name.").Envar("ESTAFETTE_EXTENSION_TEMPLATES_DIR").String()
 )
 
+//go:generate sh -c "go run . schema > params.schema.json"
 func main() {
 
 	// parse command line parameters
-	kingpin.Parse()
+	command := kingpin.Parse()
+
+	if command == schemaCommand.FullCommand() {
+		schema, err := api.GenerateParamsSchema()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed generating schema")
+		}
+		_, _ = os.Stdout.Write(schema)
+		return
+	}
+
+	if command == renderCommand.FullCommand() || command == validateCommand.FullCommand() {
+		// keep stdout for the output by logging to stderr without startup message
+		foundation.InitLoggingByFormatSilent(foundation.NewApplicationInfo(appgroup, app, version, branch, revision, buildDate), os.Getenv("ESTAFETTE_LOG_FORMAT"))
+		foundation.SetLoggingLevelFromEnv()
+		log.Logger = log.Output(os.Stderr)
+
+		ctx := foundation.InitCancellationContext(context.Background())
+		if command == renderCommand.FullCommand() {
+			render(ctx)
+		} else {
+			validate(ctx)
+		}
+		return
+	}
 
 	// init log format from envvar ESTAFETTE_LOG_FORMAT
 	foundation.InitLoggingFromEnv(foundation.NewApplicationInfo(appgroup, app, version, branch, revision, buildDate))
@@ -80,7 +129,17 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed creating gcp.Client")
 	}
 
-	builderService, err := builder.NewService(ctx)
+	kubernetesClient, err := kubernetes.NewClient(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed creating kubernetes.Client")
+	}
+
+	prometheusClient, err := prometheus.NewClient(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed creating prometheus.Client")
+	}
+
+	builderService, err := builder.NewService(ctx, templateSource())
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed creating builder.Service")
 	}
@@ -90,13 +149,185 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed creating generator.Service")
 	}
 
-	extensionService, err := extension.NewService(ctx, credentialsClient, parametersClient, gcpClient, builderService, generatorService)
+	validatorService, err := validator.NewService(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed creating validator.Service")
+	}
+
+	extensionService, err := extension.NewService(ctx, credentialsClient, parametersClient, gcpClient, kubernetesClient, prometheusClient, builderService, generatorService, validatorService)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed creating extension.Service")
 	}
 
 	err = extensionService.Run(ctx, credential, *releaseName, *paramsYAML, *gitSource, *gitOwner, *gitName, *appLabel, *buildVersion, *releaseAction, *releaseID, *gitBranch, *gitRevision, *triggeredBy)
+
+	// show the state of the application in the cluster, with logs if the release failed
+	extensionService.AssistTroubleshooting(ctx, err)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed running extension.Service")
+		os.Exit(exitCodeForError(err))
+	}
+}
+
+// render prints the manifests for a release, or writes them to the output directory, without any gcp or cluster access
+func render(ctx context.Context) {
+
+	paramsYAML, credential := readLocalParams(*renderParamsFile, *renderDefaultsFile)
+
+	parametersClient, err := parameters.NewClient(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed creating parameters.Client")
+	}
+
+	builderService, err := builder.NewService(ctx, templateSource())
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed creating builder.Service")
+	}
+
+	generatorService, err := generator.NewService(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed creating generator.Service")
+	}
+
+	validatorService, err := validator.NewService(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed creating validator.Service")
+	}
+
+	extensionService, err := extension.NewService(ctx, nil, parametersClient, nil, nil, nil, builderService, generatorService, validatorService)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed creating extension.Service")
+	}
+
+	manifest, err := extensionService.Render(ctx, credential, *releaseName, paramsYAML, *gitSource, *gitOwner, *gitName, *appLabel, *buildVersion, *releaseAction, *releaseID, *gitBranch, *gitRevision, *triggeredBy)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed rendering manifests")
+		os.Exit(exitCodeForError(err))
+	}
+
+	if *renderOutputDir == "" {
+		_, _ = os.Stdout.Write(manifest)
+		return
+	}
+
+	err = os.MkdirAll(*renderOutputDir, 0755)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed creating output directory %v", *renderOutputDir)
+	}
+	err = ioutil.WriteFile(filepath.Join(*renderOutputDir, "kubernetes.yaml"), manifest, 0644)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed writing manifest to output directory %v", *renderOutputDir)
+	}
+}
+
+// validate reports all errors and warnings for the parameters and exits with the validation exit code if there's any error
+func validate(ctx context.Context) {
+
+	paramsYAML, credential := readLocalParams(*validateParamsFile, *validateDefaultsFile)
+
+	parametersClient, err := parameters.NewClient(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed creating parameters.Client")
+	}
+
+	findings, err := parametersClient.Validate(ctx, paramsYAML, credential, *gitSource, *gitOwner, *gitName, *appLabel, *buildVersion, *releaseName, *releaseAction, *releaseID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed validating parameters")
+		os.Exit(exitCodeForError(extension.ErrValidation))
+	}
+
+	valid := true
+	for _, finding := range findings {
+		if finding.Severity == api.SeverityError {
+			valid = false
+		}
+	}
+
+	var output interface{}
+	switch *validateFormat {
+	case "sarif":
+		output = api.NewSarifLog(findings, *validateParamsFile, []byte(paramsYAML), version)
+	default:
+		output = struct {
+			Valid    bool                  `json:"valid"`
+			Findings []api.ValidationError `json:"findings"`
+		}{valid, findings}
+	}
+
+	outputJSON, err := json.MarshalIndent(output, "", "  ")
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed running extension.Service")
+		log.Fatal().Err(err).Msg("Failed marshalling findings")
+	}
+	outputJSON = append(outputJSON, '\n')
+
+	if *validateOutput == "" {
+		_, _ = os.Stdout.Write(outputJSON)
+	} else {
+		err = ioutil.WriteFile(*validateOutput, outputJSON, 0644)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Failed writing findings to %v", *validateOutput)
+		}
+	}
+
+	if !valid {
+		os.Exit(exitCodeForError(extension.ErrValidation))
+	}
+}
+
+// templateSource returns the embedded templates, overlaid with the ones in the templates directory if set
+func templateSource() builder.TemplateSource {
+	if *templatesDir == "" {
+		return builder.NewEmbeddedTemplateSource()
 	}
+
+	log.Info().Msgf("Overlaying embedded templates with templates in directory %v...", *templatesDir)
+	return builder.NewOverlayTemplateSource(*templatesDir, builder.NewEmbeddedTemplateSource())
+}
+
+// readLocalParams reads the stage parameters and the credential defaults from files, for the commands that run outside of estafette
+func readLocalParams(paramsFile, defaultsFile string) (paramsYAML string, credential *api.GKECredentials) {
+
+	paramsData, err := ioutil.ReadFile(paramsFile)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed reading params file %v", paramsFile)
+	}
+
+	credential = &api.GKECredentials{Name: "local"}
+	if defaultsFile != "" {
+		defaultsYAML, err := ioutil.ReadFile(defaultsFile)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Failed reading defaults file %v", defaultsFile)
+		}
+		var defaults api.Params
+		err = yaml.Unmarshal(defaultsYAML, &defaults)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Failed unmarshalling defaults file %v", defaultsFile)
+		}
+		credential.AdditionalProperties.Defaults = &defaults
+	}
+
+	return string(paramsData), credential
+}
+
+// exitCodeForError returns a distinct exit code per release phase, so a failure can be traced to a phase without reading the logs
+func exitCodeForError(err error) int {
+	switch {
+	case errors.Is(err, extension.ErrValidation):
+		return 2
+	case errors.Is(err, extension.ErrRender):
+		return 3
+	case errors.Is(err, extension.ErrApply):
+		return 4
+	case errors.Is(err, extension.ErrRollout):
+		return 5
+	case errors.Is(err, extension.ErrCleanup):
+		return 6
+	case errors.Is(err, extension.ErrCanaryAnalysis):
+		return 7
+	case errors.Is(err, extension.ErrHook):
+		return 8
+	}
+
+	return 1
 }
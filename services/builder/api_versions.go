@@ -0,0 +1,114 @@
+package builder
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/estafette/estafette-extension-gke/api"
+	"github.com/rs/zerolog/log"
+)
+
+type removedAPIVersion struct {
+	apiVersion  string
+	kind        string
+	removedIn   int
+	replacement string
+}
+
+// removedAPIVersions are the apiVersions that were removed from kubernetes 1.x, with the minor version they were removed in
+var removedAPIVersions = []removedAPIVersion{
+	{"extensions/v1beta1", "Deployment", 16, "apps/v1"},
+	{"extensions/v1beta1", "DaemonSet", 16, "apps/v1"},
+	{"apps/v1beta1", "Deployment", 16, "apps/v1"},
+	{"apps/v1beta2", "Deployment", 16, "apps/v1"},
+	{"apps/v1beta1", "StatefulSet", 16, "apps/v1"},
+	{"apps/v1beta2", "StatefulSet", 16, "apps/v1"},
+	{"apps/v1beta2", "DaemonSet", 16, "apps/v1"},
+	{"extensions/v1beta1", "Ingress", 22, "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", "Ingress", 22, "networking.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "Role", 22, "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "RoleBinding", 22, "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRole", 22, "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRoleBinding", 22, "rbac.authorization.k8s.io/v1"},
+	{"scheduling.k8s.io/v1beta1", "PriorityClass", 22, "scheduling.k8s.io/v1"},
+	{"batch/v1beta1", "CronJob", 25, "batch/v1"},
+	{"policy/v1beta1", "PodDisruptionBudget", 25, "policy/v1"},
+	{"policy/v1beta1", "PodSecurityPolicy", 25, ""},
+	{"autoscaling/v2beta1", "HorizontalPodAutoscaler", 25, "autoscaling/v2"},
+	{"autoscaling/v2beta2", "HorizontalPodAutoscaler", 26, "autoscaling/v2"},
+}
+
+// GetAPIVersions returns the newest apiVersions the kubernetes version serves for the kinds whose apiVersion changed;
+// without a valid kubernetes version the newest apiVersions are used
+func (s *service) GetAPIVersions(kubernetesVersion string) api.APIVersionsData {
+
+	apiVersions := api.APIVersionsData{
+		PodDisruptionBudget:     "policy/v1",
+		CronJob:                 "batch/v1",
+		HorizontalPodAutoscaler: "autoscaling/v2",
+	}
+
+	minor, err := api.ParseKubernetesMinorVersion(kubernetesVersion)
+	if err != nil {
+		return apiVersions
+	}
+	if minor < 21 {
+		apiVersions.PodDisruptionBudget = "policy/v1beta1"
+		apiVersions.CronJob = "batch/v1beta1"
+	}
+	if minor < 23 {
+		apiVersions.HorizontalPodAutoscaler = "autoscaling/v2beta2"
+	}
+
+	return apiVersions
+}
+
+var (
+	apiVersionLineRegex = regexp.MustCompile(`(?m)^apiVersion:\s*["']?([^\s"']+)`)
+	kindLineRegex       = regexp.MustCompile(`(?m)^kind:\s*["']?([^\s"']+)`)
+)
+
+// findRemovedAPIVersions returns a message for each document in a local manifest that uses an apiVersion the kubernetes version no longer serves;
+// without a valid kubernetes version any removed apiVersion is reported
+func findRemovedAPIVersions(manifestPath string, data []byte, kubernetesVersion string) []string {
+
+	minor, err := api.ParseKubernetesMinorVersion(kubernetesVersion)
+	if err != nil {
+		minor = -1
+	}
+
+	messages := []string{}
+	for _, document := range strings.Split(string(data), "\n---") {
+		apiVersionMatch := apiVersionLineRegex.FindStringSubmatch(document)
+		kindMatch := kindLineRegex.FindStringSubmatch(document)
+		if apiVersionMatch == nil || kindMatch == nil {
+			continue
+		}
+
+		for _, r := range removedAPIVersions {
+			if r.apiVersion != apiVersionMatch[1] || r.kind != kindMatch[1] || (minor >= 0 && minor < r.removedIn) {
+				continue
+			}
+
+			message := ""
+			if minor >= 0 {
+				message = fmt.Sprintf("Manifest %v uses %v %v, which isn't served by kubernetes %v", manifestPath, r.apiVersion, r.kind, kubernetesVersion)
+			} else {
+				message = fmt.Sprintf("Manifest %v uses %v %v, which is removed in kubernetes 1.%v", manifestPath, r.apiVersion, r.kind, r.removedIn)
+			}
+			if r.replacement != "" {
+				message += fmt.Sprintf("; use %v instead", r.replacement)
+			}
+			messages = append(messages, message)
+		}
+	}
+
+	return messages
+}
+
+func warnForRemovedAPIVersions(manifestPath string, data []byte, kubernetesVersion string) {
+	for _, message := range findRemovedAPIVersions(manifestPath, data, kubernetesVersion) {
+		log.Warn().Msg(message)
+	}
+}
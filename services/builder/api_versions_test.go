@@ -0,0 +1,100 @@
+package builder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/estafette/estafette-extension-gke/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAPIVersions(t *testing.T) {
+
+	t.Run("ReturnsV1beta1VersionsBeforeKubernetes121", func(t *testing.T) {
+
+		service, _ := NewService(context.Background(), NewEmbeddedTemplateSource())
+
+		// act
+		apiVersions := service.GetAPIVersions("1.20.15-gke.1000")
+
+		assert.Equal(t, api.APIVersionsData{PodDisruptionBudget: "policy/v1beta1", CronJob: "batch/v1beta1", HorizontalPodAutoscaler: "autoscaling/v2beta2"}, apiVersions)
+	})
+
+	t.Run("ReturnsAutoscalingV2beta2BeforeKubernetes123", func(t *testing.T) {
+
+		service, _ := NewService(context.Background(), NewEmbeddedTemplateSource())
+
+		// act
+		apiVersions := service.GetAPIVersions("1.22.17-gke.100")
+
+		assert.Equal(t, api.APIVersionsData{PodDisruptionBudget: "policy/v1", CronJob: "batch/v1", HorizontalPodAutoscaler: "autoscaling/v2beta2"}, apiVersions)
+	})
+
+	t.Run("ReturnsV1AndV2VersionsFromKubernetes123", func(t *testing.T) {
+
+		service, _ := NewService(context.Background(), NewEmbeddedTemplateSource())
+
+		// act
+		apiVersions := service.GetAPIVersions("v1.23")
+
+		assert.Equal(t, api.APIVersionsData{PodDisruptionBudget: "policy/v1", CronJob: "batch/v1", HorizontalPodAutoscaler: "autoscaling/v2"}, apiVersions)
+	})
+
+	t.Run("ReturnsNewestVersionsWithoutKubernetesVersion", func(t *testing.T) {
+
+		service, _ := NewService(context.Background(), NewEmbeddedTemplateSource())
+
+		// act
+		apiVersions := service.GetAPIVersions("")
+
+		assert.Equal(t, api.APIVersionsData{PodDisruptionBudget: "policy/v1", CronJob: "batch/v1", HorizontalPodAutoscaler: "autoscaling/v2"}, apiVersions)
+	})
+}
+
+func TestFindRemovedAPIVersions(t *testing.T) {
+
+	manifest := []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: {{.Name}}
+---
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: {{.Name}}
+---
+apiVersion: "networking.k8s.io/v1beta1"
+kind: Ingress
+metadata:
+  name: {{.Name}}`)
+
+	t.Run("ReturnsApiVersionsRemovedInKubernetesVersion", func(t *testing.T) {
+
+		// act
+		messages := findRemovedAPIVersions("./gke/pdb.yaml", manifest, "1.25.8-gke.1000")
+
+		assert.Equal(t, []string{
+			"Manifest ./gke/pdb.yaml uses policy/v1beta1 PodDisruptionBudget, which isn't served by kubernetes 1.25.8-gke.1000; use policy/v1 instead",
+			"Manifest ./gke/pdb.yaml uses networking.k8s.io/v1beta1 Ingress, which isn't served by kubernetes 1.25.8-gke.1000; use networking.k8s.io/v1 instead",
+		}, messages)
+	})
+
+	t.Run("ReturnsNothingForApiVersionsStillServedByKubernetesVersion", func(t *testing.T) {
+
+		// act
+		messages := findRemovedAPIVersions("./gke/pdb.yaml", manifest, "1.21")
+
+		assert.Empty(t, messages)
+	})
+
+	t.Run("ReturnsAllRemovedApiVersionsWithoutKubernetesVersion", func(t *testing.T) {
+
+		// act
+		messages := findRemovedAPIVersions("./gke/pdb.yaml", manifest, "")
+
+		assert.Equal(t, []string{
+			"Manifest ./gke/pdb.yaml uses policy/v1beta1 PodDisruptionBudget, which is removed in kubernetes 1.25; use policy/v1 instead",
+			"Manifest ./gke/pdb.yaml uses networking.k8s.io/v1beta1 Ingress, which is removed in kubernetes 1.22; use networking.k8s.io/v1 instead",
+		}, messages)
+	})
+}
@@ -51,6 +51,20 @@ func (mr *MockServiceMockRecorder) BuildTemplates(params, includePodDisruptionBu
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildTemplates", reflect.TypeOf((*MockService)(nil).BuildTemplates), params, includePodDisruptionBudget)
 }
 
+// GetAPIVersions mocks base method.
+func (m *MockService) GetAPIVersions(kubernetesVersion string) api.APIVersionsData {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIVersions", kubernetesVersion)
+	ret0, _ := ret[0].(api.APIVersionsData)
+	return ret0
+}
+
+// GetAPIVersions indicates an expected call of GetAPIVersions.
+func (mr *MockServiceMockRecorder) GetAPIVersions(kubernetesVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIVersions", reflect.TypeOf((*MockService)(nil).GetAPIVersions), kubernetesVersion)
+}
+
 // GetAtomicUpdateServiceTemplate mocks base method.
 func (m *MockService) GetAtomicUpdateServiceTemplate() (*template.Template, error) {
 	m.ctrl.T.Helper()
@@ -66,6 +80,51 @@ func (mr *MockServiceMockRecorder) GetAtomicUpdateServiceTemplate() *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAtomicUpdateServiceTemplate", reflect.TypeOf((*MockService)(nil).GetAtomicUpdateServiceTemplate))
 }
 
+// GetBlueGreenPreviewTemplate mocks base method.
+func (m *MockService) GetBlueGreenPreviewTemplate() (*template.Template, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlueGreenPreviewTemplate")
+	ret0, _ := ret[0].(*template.Template)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlueGreenPreviewTemplate indicates an expected call of GetBlueGreenPreviewTemplate.
+func (mr *MockServiceMockRecorder) GetBlueGreenPreviewTemplate() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlueGreenPreviewTemplate", reflect.TypeOf((*MockService)(nil).GetBlueGreenPreviewTemplate))
+}
+
+// GetHookTemplate mocks base method.
+func (m *MockService) GetHookTemplate(params api.Params) (*template.Template, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHookTemplate", params)
+	ret0, _ := ret[0].(*template.Template)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHookTemplate indicates an expected call of GetHookTemplate.
+func (mr *MockServiceMockRecorder) GetHookTemplate(params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHookTemplate", reflect.TypeOf((*MockService)(nil).GetHookTemplate), params)
+}
+
+// GetProgressiveCanaryTemplate mocks base method.
+func (m *MockService) GetProgressiveCanaryTemplate() (*template.Template, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProgressiveCanaryTemplate")
+	ret0, _ := ret[0].(*template.Template)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProgressiveCanaryTemplate indicates an expected call of GetProgressiveCanaryTemplate.
+func (mr *MockServiceMockRecorder) GetProgressiveCanaryTemplate() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProgressiveCanaryTemplate", reflect.TypeOf((*MockService)(nil).GetProgressiveCanaryTemplate))
+}
+
 // GetTemplates mocks base method.
 func (m *MockService) GetTemplates(params api.Params, includePodDisruptionBudget bool) []string {
 	m.ctrl.T.Helper()
@@ -81,11 +140,12 @@ func (mr *MockServiceMockRecorder) GetTemplates(params, includePodDisruptionBudg
 }
 
 // RenderConfig mocks base method.
-func (m *MockService) RenderConfig(params api.Params) map[string]string {
+func (m *MockService) RenderConfig(params api.Params) (map[string]string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "RenderConfig", params)
 	ret0, _ := ret[0].(map[string]string)
-	return ret0
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // RenderConfig indicates an expected call of RenderConfig.
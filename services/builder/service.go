@@ -19,16 +19,23 @@ type Service interface {
 	BuildTemplates(params api.Params, includePodDisruptionBudget bool) (*template.Template, error)
 	GetTemplates(params api.Params, includePodDisruptionBudget bool) []string
 	GetAtomicUpdateServiceTemplate() (*template.Template, error)
-	RenderConfig(params api.Params) (renderedConfigFiles map[string]string)
+	GetProgressiveCanaryTemplate() (*template.Template, error)
+	GetBlueGreenPreviewTemplate() (*template.Template, error)
+	GetHookTemplate(params api.Params) (*template.Template, error)
+	RenderConfig(params api.Params) (renderedConfigFiles map[string]string, err error)
 	RenderTemplate(tmpl *template.Template, templateData api.TemplateData, logTemplate bool) (bytes.Buffer, error)
+	GetAPIVersions(kubernetesVersion string) api.APIVersionsData
 }
 
 // NewService returns a new extension.Service
-func NewService(ctx context.Context) (Service, error) {
-	return &service{}, nil
+func NewService(ctx context.Context, templateSource TemplateSource) (Service, error) {
+	return &service{
+		templateSource: templateSource,
+	}, nil
 }
 
 type service struct {
+	templateSource TemplateSource
 }
 
 func (s *service) BuildTemplates(params api.Params, includePodDisruptionBudget bool) (*template.Template, error) {
@@ -42,31 +49,19 @@ func (s *service) BuildTemplates(params api.Params, includePodDisruptionBudget b
 
 	log.Info().Msgf("Merging templates %v...", strings.Join(templatesToMerge, ", "))
 
-	templateStrings := []string{}
-	for _, t := range templatesToMerge {
-		data, err := ioutil.ReadFile(t)
-		if err != nil {
-			log.Fatal().Err(err).Msgf("Failed reading file %v. Do you have a git-clone stage before running this extension? For releases git-clone is not automatically handled to save time in case it's not needed. ", t)
-		}
-		templateStrings = append(templateStrings, string(data))
-	}
-	templateString := strings.Join(templateStrings, "\n---\n")
-
-	// parse templates
-	log.Info().Msg("Parsing merged templates...")
-	return template.New("kubernetes.yaml").Funcs(sprig.TxtFuncMap()).Parse(templateString)
+	return s.mergeTemplates("kubernetes.yaml", templatesToMerge, params.Manifests.Files, params.KubernetesVersion)
 }
 
 func (s *service) GetTemplates(params api.Params, includePodDisruptionBudget bool) []string {
 
-	if params.Action == api.ActionRollbackCanary || params.Action == api.ActionUnknown || params.Action == api.ActionRestartCanary || params.Action == api.ActionRestartStable || params.Action == api.ActionRestartSimple {
+	if params.Action == api.ActionRollbackCanary || params.Action == api.ActionUnknown || params.Action == api.ActionRestartCanary || params.Action == api.ActionRestartStable || params.Action == api.ActionRestartSimple || params.Action == api.ActionPromote || params.Action == api.ActionSwitchBack || params.Action == api.ActionRunCronJob || params.Action == api.ActionSuspendCronJob || params.Action == api.ActionResumeCronJob {
 		return []string{}
 	}
 
 	templatesToMerge := []string{}
 
 	switch params.Kind {
-	case api.KindConfig:
+	case api.KindConfig, api.KindSecret:
 		templatesToMerge = append(templatesToMerge, []string{
 			"namespace.yaml",
 		}...)
@@ -103,7 +98,7 @@ func (s *service) GetTemplates(params api.Params, includePodDisruptionBudget boo
 			"deployment.yaml",
 		}...)
 
-		if params.StrategyType != api.StrategyTypeAtomicUpdate {
+		if params.StrategyType != api.StrategyTypeAtomicUpdate && params.StrategyType != api.StrategyTypeBlueGreen {
 			templatesToMerge = append(templatesToMerge, "service.yaml")
 		}
 
@@ -117,11 +112,18 @@ func (s *service) GetTemplates(params api.Params, includePodDisruptionBudget boo
 			"serviceaccount.yaml",
 			"deployment.yaml",
 		}...)
+
+	case api.KindDaemonSet:
+		templatesToMerge = append(templatesToMerge, []string{
+			"namespace.yaml",
+			"serviceaccount.yaml",
+			"daemonset.yaml",
+		}...)
 	}
 
 	hasImagePullSecret := params.ImagePullSecretUser != "" && params.ImagePullSecretPassword != ""
 
-	if hasImagePullSecret && params.Kind != api.KindConfig && params.Kind != api.KindConfigToFile {
+	if hasImagePullSecret && params.Kind != api.KindConfig && params.Kind != api.KindSecret && params.Kind != api.KindConfigToFile {
 		templatesToMerge = append(templatesToMerge, []string{
 			"image-pull-secret.yaml",
 		}...)
@@ -131,7 +133,11 @@ func (s *service) GetTemplates(params api.Params, includePodDisruptionBudget boo
 		templatesToMerge = append(templatesToMerge, "poddisruptionbudget.yaml")
 	}
 	if (params.Kind == api.KindDeployment || params.Kind == api.KindHeadlessDeployment) && params.Autoscale.Enabled != nil && *params.Autoscale.Enabled && params.StrategyType != "Recreate" && (params.Action == api.ActionDeploySimple || params.Action == api.ActionDeployStable || params.Action == api.ActionDiffSimple || params.Action == api.ActionDiffStable) {
-		templatesToMerge = append(templatesToMerge, "horizontalpodautoscaler.yaml")
+		if params.UsesKedaAutoscaler() {
+			templatesToMerge = append(templatesToMerge, "scaledobject.yaml")
+		} else {
+			templatesToMerge = append(templatesToMerge, "horizontalpodautoscaler.yaml")
+		}
 	}
 	if (params.Kind == api.KindDeployment || params.Kind == api.KindHeadlessDeployment) && params.VerticalPodAutoscaler.Enabled != nil && *params.VerticalPodAutoscaler.Enabled && (params.Action == api.ActionDeploySimple || params.Action == api.ActionDeployStable || params.Action == api.ActionDiffSimple || params.Action == api.ActionDiffStable) {
 		templatesToMerge = append(templatesToMerge, "verticalpodautoscaler.yaml")
@@ -164,11 +170,6 @@ func (s *service) GetTemplates(params api.Params, includePodDisruptionBudget boo
 		templatesToMerge = append(templatesToMerge, "configmap.yaml")
 	}
 
-	// prefix all filenames with templates dir
-	for i, t := range templatesToMerge {
-		templatesToMerge[i] = fmt.Sprintf("/templates/%v", t)
-	}
-
 	// add or override with local manifests
 	for _, lm := range params.Manifests.Files {
 		filename := filepath.Base(lm)
@@ -193,10 +194,80 @@ func (s *service) GetTemplates(params api.Params, includePodDisruptionBudget boo
 func (s *service) GetAtomicUpdateServiceTemplate() (*template.Template, error) {
 
 	// parse service template
-	return template.New("service.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("/templates/service.yaml")
+	return s.mergeTemplates("service.yaml", []string{"service.yaml"}, nil, "")
+}
+
+func (s *service) GetProgressiveCanaryTemplate() (*template.Template, error) {
+
+	// merge service and ingress template, rendered with canary template data
+	return s.mergeTemplates("canary.yaml", []string{"service.yaml", "ingress.yaml"}, nil, "")
+}
+
+func (s *service) GetBlueGreenPreviewTemplate() (*template.Template, error) {
+
+	// merge service and ingress template, rendered with preview template data
+	return s.mergeTemplates("preview.yaml", []string{"service.yaml", "ingress.yaml"}, nil, "")
+}
+
+func (s *service) GetHookTemplate(params api.Params) (*template.Template, error) {
+
+	// a pre-deploy hook can run before the first release, so include everything the job mounts or runs as
+	templatesToMerge := []string{
+		"namespace.yaml",
+		"serviceaccount.yaml",
+	}
+	if params.ImagePullSecretUser != "" && params.ImagePullSecretPassword != "" {
+		templatesToMerge = append(templatesToMerge, "image-pull-secret.yaml")
+	}
+	if params.HasSecrets() {
+		templatesToMerge = append(templatesToMerge, "application-secrets.yaml")
+	}
+	if params.UseGoogleCloudCredentials || params.LegacyGoogleCloudServiceAccountKeyFile != "" {
+		templatesToMerge = append(templatesToMerge, "service-account-secret.yaml")
+	}
+	if len(params.Configs.Files) > 0 || len(params.Configs.InlineFiles) > 0 {
+		templatesToMerge = append(templatesToMerge, "configmap.yaml")
+	}
+	templatesToMerge = append(templatesToMerge, "job.yaml")
+
+	return s.mergeTemplates("hook.yaml", templatesToMerge, nil, "")
+}
+
+// mergeTemplates reads the local manifests from the working directory and all other templates from the template source;
+// local manifests using an apiVersion that's removed in the kubernetes version are logged, since they would fail to apply
+func (s *service) mergeTemplates(name string, templatesToMerge []string, localManifests []string, kubernetesVersion string) (*template.Template, error) {
+
+	isLocalManifest := map[string]bool{}
+	for _, lm := range localManifests {
+		isLocalManifest[lm] = true
+	}
+
+	templateStrings := []string{}
+	for _, t := range templatesToMerge {
+		var data []byte
+		var err error
+		if isLocalManifest[t] {
+			data, err = ioutil.ReadFile(t)
+			if err != nil {
+				return nil, fmt.Errorf("Failed reading file %v. Do you have a git-clone stage before running this extension? For releases git-clone is not automatically handled to save time in case it's not needed: %w", t, err)
+			}
+			warnForRemovedAPIVersions(t, data, kubernetesVersion)
+		} else {
+			data, err = s.templateSource.ReadTemplate(t)
+			if err != nil {
+				return nil, fmt.Errorf("Failed reading template %v: %w", t, err)
+			}
+		}
+		templateStrings = append(templateStrings, string(data))
+	}
+	templateString := strings.Join(templateStrings, "\n---\n")
+
+	// parse templates
+	log.Info().Msg("Parsing merged templates...")
+	return template.New(name).Funcs(sprig.TxtFuncMap()).Parse(templateString)
 }
 
-func (s *service) RenderConfig(params api.Params) (renderedConfigFiles map[string]string) {
+func (s *service) RenderConfig(params api.Params) (renderedConfigFiles map[string]string, err error) {
 
 	renderedConfigFiles = map[string]string{}
 
@@ -208,17 +279,17 @@ func (s *service) RenderConfig(params api.Params) (renderedConfigFiles map[strin
 
 			data, err := ioutil.ReadFile(cf)
 			if err != nil {
-				log.Fatal().Err(err).Msgf("Failed reading file %v. Do you have a git-clone stage before running this extension? For releases git-clone is not automatically handled to save time in case it's not needed. ", cf)
+				return nil, fmt.Errorf("Failed reading file %v. Do you have a git-clone stage before running this extension? For releases git-clone is not automatically handled to save time in case it's not needed: %w", cf, err)
 			}
 			tmpl, err := template.New(cf).Parse(string(data))
 			if err != nil {
-				log.Fatal().Err(err).Msgf("Failed building template from file %v: ", cf)
+				return nil, fmt.Errorf("Failed building template from file %v: %w", cf, err)
 			}
 
 			var renderedTemplate bytes.Buffer
 			err = tmpl.Execute(&renderedTemplate, params.Configs.Data)
 			if err != nil {
-				log.Fatal().Err(err).Msgf("Failed rendering template from file %v: ", cf)
+				return nil, fmt.Errorf("Failed rendering template from file %v: %w", cf, err)
 			}
 
 			renderedConfigFiles[filepath.Base(cf)] = renderedTemplate.String()
@@ -228,12 +299,12 @@ func (s *service) RenderConfig(params api.Params) (renderedConfigFiles map[strin
 		for filename, content := range params.Configs.InlineFiles {
 			tmpl, err := template.New(filename).Parse(content)
 			if err != nil {
-				log.Fatal().Err(err).Msgf("Failed building template from inline file %v: ", filename)
+				return nil, fmt.Errorf("Failed building template from inline file %v: %w", filename, err)
 			}
 			var renderedTemplate bytes.Buffer
 			err = tmpl.Execute(&renderedTemplate, params.Configs.Data)
 			if err != nil {
-				log.Fatal().Err(err).Msgf("Failed rendering template from file %v: ", filename)
+				return nil, fmt.Errorf("Failed rendering template from file %v: %w", filename, err)
 			}
 
 			renderedConfigFiles[filename] = renderedTemplate.String()
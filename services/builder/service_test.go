@@ -3,6 +3,8 @@ package builder
 import (
 	bytes "bytes"
 	"context"
+	"io/ioutil"
+	"path/filepath"
 	"strings"
 	"testing"
 	template "text/template"
@@ -10,6 +12,7 @@ import (
 	"github.com/Masterminds/sprig/v3"
 	"github.com/estafette/estafette-extension-gke/api"
 	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
 )
 
 func TestGetTemplates(t *testing.T) {
@@ -17,7 +20,7 @@ func TestGetTemplates(t *testing.T) {
 	t.Run("IncludesIngressIfVisibilityIsPrivateAndKindIsDeployment", func(t *testing.T) {
 
 		ctx := context.Background()
-		service, err := NewService(ctx)
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
 		assert.Nil(t, err)
 
 		params := api.Params{
@@ -29,13 +32,13 @@ func TestGetTemplates(t *testing.T) {
 		// act
 		templates := service.GetTemplates(params, true)
 
-		assert.True(t, stringArrayContains(templates, "/templates/ingress.yaml"))
+		assert.True(t, stringArrayContains(templates, "ingress.yaml"))
 	})
 
 	t.Run("IncludesIngressIfVisibilityIsIapAndKindIsDeployment", func(t *testing.T) {
 
 		ctx := context.Background()
-		service, err := NewService(ctx)
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
 		assert.Nil(t, err)
 
 		params := api.Params{
@@ -47,13 +50,13 @@ func TestGetTemplates(t *testing.T) {
 		// act
 		templates := service.GetTemplates(params, true)
 
-		assert.True(t, stringArrayContains(templates, "/templates/ingress.yaml"))
+		assert.True(t, stringArrayContains(templates, "ingress.yaml"))
 	})
 
 	t.Run("IncludesIngressIfVisibilityIsPublicWhitelistAndKindIsDeployment", func(t *testing.T) {
 
 		ctx := context.Background()
-		service, err := NewService(ctx)
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
 		assert.Nil(t, err)
 
 		params := api.Params{
@@ -65,13 +68,13 @@ func TestGetTemplates(t *testing.T) {
 		// act
 		templates := service.GetTemplates(params, true)
 
-		assert.True(t, stringArrayContains(templates, "/templates/ingress.yaml"))
+		assert.True(t, stringArrayContains(templates, "ingress.yaml"))
 	})
 
 	t.Run("DoesNotIncludeIngressIfVisibilityIsPublic", func(t *testing.T) {
 
 		ctx := context.Background()
-		service, err := NewService(ctx)
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
 		assert.Nil(t, err)
 
 		params := api.Params{
@@ -82,13 +85,13 @@ func TestGetTemplates(t *testing.T) {
 		// act
 		templates := service.GetTemplates(params, true)
 
-		assert.False(t, stringArrayContains(templates, "/templates/ingress.yaml"))
+		assert.False(t, stringArrayContains(templates, "ingress.yaml"))
 	})
 
 	t.Run("IncludesInternalIngressIfOneOrMoreInternalHostsAreSetAndKindIsDeployment", func(t *testing.T) {
 
 		ctx := context.Background()
-		service, err := NewService(ctx)
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
 		assert.Nil(t, err)
 
 		params := api.Params{
@@ -100,13 +103,13 @@ func TestGetTemplates(t *testing.T) {
 		// act
 		templates := service.GetTemplates(params, true)
 
-		assert.True(t, stringArrayContains(templates, "/templates/ingress-internal.yaml"))
+		assert.True(t, stringArrayContains(templates, "ingress-internal.yaml"))
 	})
 
 	t.Run("DoesNotIncludeInternalIngressIfNoInternalHostsAreSet", func(t *testing.T) {
 
 		ctx := context.Background()
-		service, err := NewService(ctx)
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
 		assert.Nil(t, err)
 
 		params := api.Params{
@@ -117,13 +120,13 @@ func TestGetTemplates(t *testing.T) {
 		// act
 		templates := service.GetTemplates(params, true)
 
-		assert.False(t, stringArrayContains(templates, "/templates/ingress-internal.yaml"))
+		assert.False(t, stringArrayContains(templates, "ingress-internal.yaml"))
 	})
 
 	t.Run("IncludesApplicationSecretsIfLengthOfSecretsIsMoreThanZero", func(t *testing.T) {
 
 		ctx := context.Background()
-		service, err := NewService(ctx)
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
 		assert.Nil(t, err)
 
 		params := api.Params{
@@ -139,13 +142,13 @@ func TestGetTemplates(t *testing.T) {
 		// act
 		templates := service.GetTemplates(params, true)
 
-		assert.True(t, stringArrayContains(templates, "/templates/application-secrets.yaml"))
+		assert.True(t, stringArrayContains(templates, "application-secrets.yaml"))
 	})
 
 	t.Run("DoesNotIncludeApplicationSecretsIfLengthOfSecretsZero", func(t *testing.T) {
 
 		ctx := context.Background()
-		service, err := NewService(ctx)
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
 		assert.Nil(t, err)
 
 		params := api.Params{
@@ -155,13 +158,13 @@ func TestGetTemplates(t *testing.T) {
 		// act
 		templates := service.GetTemplates(params, true)
 
-		assert.False(t, stringArrayContains(templates, "/templates/application-secrets.yaml"))
+		assert.False(t, stringArrayContains(templates, "application-secrets.yaml"))
 	})
 
 	t.Run("AddLocalManifestsIfSetInLocalManifestsParam", func(t *testing.T) {
 
 		ctx := context.Background()
-		service, err := NewService(ctx)
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
 		assert.Nil(t, err)
 
 		params := api.Params{
@@ -182,7 +185,7 @@ func TestGetTemplates(t *testing.T) {
 	t.Run("OverrideWithLocalManifestsIfSetInLocalManifestsParamWithSameFilename", func(t *testing.T) {
 
 		ctx := context.Background()
-		service, err := NewService(ctx)
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
 		assert.Nil(t, err)
 
 		params := api.Params{
@@ -198,13 +201,13 @@ func TestGetTemplates(t *testing.T) {
 		templates := service.GetTemplates(params, true)
 
 		assert.True(t, stringArrayContains(templates, "./gke/service.yaml"))
-		assert.False(t, stringArrayContains(templates, "/templates/service.yaml"))
+		assert.False(t, stringArrayContains(templates, "service.yaml"))
 	})
 
 	t.Run("ReturnsEmptyListIfActionIsRollbackCanaray", func(t *testing.T) {
 
 		ctx := context.Background()
-		service, err := NewService(ctx)
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
 		assert.Nil(t, err)
 
 		params := api.Params{
@@ -217,10 +220,68 @@ func TestGetTemplates(t *testing.T) {
 		assert.Equal(t, 0, len(templates))
 	})
 
+	t.Run("ReturnsEmptyListIfActionIsPromoteOrSwitchBack", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
+		assert.Nil(t, err)
+
+		for _, action := range []api.ActionType{api.ActionPromote, api.ActionSwitchBack} {
+			params := api.Params{
+				Action:       action,
+				Kind:         api.KindDeployment,
+				StrategyType: api.StrategyTypeBlueGreen,
+			}
+
+			// act
+			templates := service.GetTemplates(params, true)
+
+			assert.Equal(t, 0, len(templates))
+		}
+	})
+
+	t.Run("ReturnsEmptyListIfActionIsRunSuspendOrResumeCronJob", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
+		assert.Nil(t, err)
+
+		for _, action := range []api.ActionType{api.ActionRunCronJob, api.ActionSuspendCronJob, api.ActionResumeCronJob} {
+			params := api.Params{
+				Action: action,
+				Kind:   api.KindCronJob,
+			}
+
+			// act
+			templates := service.GetTemplates(params, true)
+
+			assert.Equal(t, 0, len(templates))
+		}
+	})
+
+	t.Run("DoesNotIncludeServiceIfStrategyTypeIsBlueGreen", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Action:       api.ActionDeploySimple,
+			Kind:         api.KindDeployment,
+			StrategyType: api.StrategyTypeBlueGreen,
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.False(t, stringArrayContains(templates, "service.yaml"))
+		assert.True(t, stringArrayContains(templates, "deployment.yaml"))
+	})
+
 	t.Run("ReturnsOnlyHorizontalPodAutoscalerAndPodDisruptionBudgetIfActionIsDeployCanary", func(t *testing.T) {
 
 		ctx := context.Background()
-		service, err := NewService(ctx)
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
 		assert.Nil(t, err)
 
 		params := api.Params{
@@ -230,14 +291,102 @@ func TestGetTemplates(t *testing.T) {
 		// act
 		templates := service.GetTemplates(params, true)
 
-		assert.False(t, stringArrayContains(templates, "/templates/horizontalpodautoscaler.yaml"))
-		assert.False(t, stringArrayContains(templates, "/templates/poddisruptionbudget.yaml"))
+		assert.False(t, stringArrayContains(templates, "horizontalpodautoscaler.yaml"))
+		assert.False(t, stringArrayContains(templates, "poddisruptionbudget.yaml"))
+	})
+
+	t.Run("ReturnsScaledObjectInsteadOfHorizontalPodAutoscalerIfAutoscaleTypeIsKeda", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
+		assert.Nil(t, err)
+
+		trueValue := true
+		params := api.Params{
+			Action: api.ActionDeploySimple,
+			Kind:   api.KindDeployment,
+			Autoscale: api.AutoscaleParams{
+				Enabled: &trueValue,
+				Type:    api.AutoscalerTypeKeda,
+			},
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.True(t, stringArrayContains(templates, "scaledobject.yaml"))
+		assert.False(t, stringArrayContains(templates, "horizontalpodautoscaler.yaml"))
+	})
+
+	t.Run("ReturnsHorizontalPodAutoscalerInsteadOfScaledObjectIfAutoscaleTypeIsHPA", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
+		assert.Nil(t, err)
+
+		trueValue := true
+		params := api.Params{
+			Action: api.ActionDeploySimple,
+			Kind:   api.KindDeployment,
+			Autoscale: api.AutoscaleParams{
+				Enabled: &trueValue,
+				Type:    api.AutoscalerTypeHPA,
+			},
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.True(t, stringArrayContains(templates, "horizontalpodautoscaler.yaml"))
+		assert.False(t, stringArrayContains(templates, "scaledobject.yaml"))
+	})
+
+	t.Run("ReturnsOnlyNamespaceAndApplicationSecretsIfKindIsSecret", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Action: api.ActionDeploySimple,
+			Kind:   api.KindSecret,
+			Secrets: api.SecretsParams{
+				Keys: map[string]interface{}{
+					"password": "c2VjcmV0",
+				},
+			},
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.Equal(t, []string{"namespace.yaml", "application-secrets.yaml"}, templates)
+	})
+
+	t.Run("ReturnsDaemonSetWithoutDeploymentAndServiceIfKindIsDaemonSet", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Action: api.ActionDeploySimple,
+			Kind:   api.KindDaemonSet,
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.True(t, stringArrayContains(templates, "daemonset.yaml"))
+		assert.True(t, stringArrayContains(templates, "serviceaccount.yaml"))
+		assert.False(t, stringArrayContains(templates, "deployment.yaml"))
+		assert.False(t, stringArrayContains(templates, "service.yaml"))
 	})
 
 	t.Run("DoesNotIncludeCertificateSecretIfCertificateSecretIsSet", func(t *testing.T) {
 
 		ctx := context.Background()
-		service, err := NewService(ctx)
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
 		assert.Nil(t, err)
 
 		params := api.Params{
@@ -249,13 +398,13 @@ func TestGetTemplates(t *testing.T) {
 		// act
 		templates := service.GetTemplates(params, true)
 
-		assert.False(t, stringArrayContains(templates, "/templates/certificate-secret.yaml"))
+		assert.False(t, stringArrayContains(templates, "certificate-secret.yaml"))
 	})
 
 	t.Run("IncludesCertificateSecretIfCertificateSecretIsNotSet", func(t *testing.T) {
 
 		ctx := context.Background()
-		service, err := NewService(ctx)
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
 		assert.Nil(t, err)
 
 		params := api.Params{
@@ -266,13 +415,13 @@ func TestGetTemplates(t *testing.T) {
 		// act
 		templates := service.GetTemplates(params, true)
 
-		assert.True(t, stringArrayContains(templates, "/templates/certificate-secret.yaml"))
+		assert.True(t, stringArrayContains(templates, "certificate-secret.yaml"))
 	})
 
 	t.Run("IncludesApigeeIngressIfVisibilityIsApigeeAndKindIsDeployment", func(t *testing.T) {
 
 		ctx := context.Background()
-		service, err := NewService(ctx)
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
 		assert.Nil(t, err)
 
 		params := api.Params{
@@ -284,14 +433,14 @@ func TestGetTemplates(t *testing.T) {
 		// act
 		templates := service.GetTemplates(params, true)
 
-		assert.True(t, stringArrayContains(templates, "/templates/ingress-apigee.yaml"))
-		assert.True(t, stringArrayContains(templates, "/templates/ingress.yaml"))
+		assert.True(t, stringArrayContains(templates, "ingress-apigee.yaml"))
+		assert.True(t, stringArrayContains(templates, "ingress.yaml"))
 	})
 
 	t.Run("IncludesIngressEspIfVisibilityIsESP", func(t *testing.T) {
 
 		ctx := context.Background()
-		service, err := NewService(ctx)
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
 		assert.Nil(t, err)
 
 		params := api.Params{
@@ -304,13 +453,13 @@ func TestGetTemplates(t *testing.T) {
 		// act
 		templates := service.GetTemplates(params, true)
 
-		assert.True(t, stringArrayContains(templates, "/templates/ingress-esp.yaml"))
+		assert.True(t, stringArrayContains(templates, "ingress-esp.yaml"))
 	})
 
 	t.Run("IncludesIngressEspIfVisibilityIsESPv2", func(t *testing.T) {
 
 		ctx := context.Background()
-		service, err := NewService(ctx)
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
 		assert.Nil(t, err)
 
 		params := api.Params{
@@ -323,7 +472,54 @@ func TestGetTemplates(t *testing.T) {
 		// act
 		templates := service.GetTemplates(params, true)
 
-		assert.True(t, stringArrayContains(templates, "/templates/ingress-esp.yaml"))
+		assert.True(t, stringArrayContains(templates, "ingress-esp.yaml"))
+	})
+}
+
+func TestBuildTemplates(t *testing.T) {
+
+	t.Run("MergesEmbeddedTemplates", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Action: api.ActionDeploySimple,
+			Kind:   api.KindDeployment,
+		}
+
+		// act
+		tmpl, err := service.BuildTemplates(params, true)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "kubernetes.yaml", tmpl.Name())
+		assert.Contains(t, tmpl.Root.String(), "kind: Deployment")
+	})
+
+	t.Run("ReadsLocalManifestsFromWorkingDirectory", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx, NewEmbeddedTemplateSource())
+		assert.Nil(t, err)
+
+		localManifest := filepath.Join(t.TempDir(), "service.yaml")
+		err = ioutil.WriteFile(localManifest, []byte("kind: LocalService"), 0644)
+		assert.Nil(t, err)
+		params := api.Params{
+			Action: api.ActionDeploySimple,
+			Kind:   api.KindDeployment,
+			Manifests: api.ManifestsParams{
+				Files: []string{localManifest},
+			},
+		}
+
+		// act
+		tmpl, err := service.BuildTemplates(params, true)
+
+		assert.Nil(t, err)
+		assert.Contains(t, tmpl.Root.String(), "kind: LocalService")
+		assert.NotContains(t, tmpl.Root.String(), "kind: Service\n")
 	})
 }
 
@@ -380,6 +576,9 @@ func TestInjectSteps(t *testing.T) {
 			MinReplicas:         3,
 			MaxReplicas:         19,
 			TargetCPUPercentage: 65,
+			APIVersions: api.APIVersionsData{
+				HorizontalPodAutoscaler: "autoscaling/v2",
+			},
 		}
 		tmpl, err := template.New("horizontalpodautoscaler.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/horizontalpodautoscaler.yaml")
 		assert.Nil(t, err)
@@ -389,9 +588,82 @@ func TestInjectSteps(t *testing.T) {
 		err = tmpl.Execute(&renderedTemplate, data)
 
 		assert.Nil(t, err)
-		assert.Equal(t, "apiVersion: autoscaling/v1\nkind: HorizontalPodAutoscaler\nmetadata:\n  name: myapp-canary\n  namespace: mynamespace\n  labels:\n    \"app\": \"myapp\"\n    \"team\": \"myteam\"\nspec:\n  scaleTargetRef:\n    apiVersion: apps/v1\n    kind: Deployment\n    name: myapp-canary\n  minReplicas: 3\n  maxReplicas: 19\n  targetCPUUtilizationPercentage: 65", renderedTemplate.String())
+		assert.Equal(t, "apiVersion: autoscaling/v2\nkind: HorizontalPodAutoscaler\nmetadata:\n  name: myapp-canary\n  namespace: mynamespace\n  labels:\n    \"app\": \"myapp\"\n    \"team\": \"myteam\"\nspec:\n  scaleTargetRef:\n    apiVersion: apps/v1\n    kind: Deployment\n    name: myapp-canary\n  minReplicas: 3\n  maxReplicas: 19\n  metrics:\n  - type: Resource\n    resource:\n      name: cpu\n      target:\n        type: Utilization\n        averageUtilization: 65", renderedTemplate.String())
 		assert.True(t, strings.Contains(renderedTemplate.String(), "mynamespace"))
 	})
+
+	t.Run("RenderScaledObject", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:          "myapp",
+			NameWithTrack: "myapp-canary",
+			Namespace:     "mynamespace",
+			Labels: map[string]string{
+				"app": "myapp",
+			},
+			MinReplicas:         3,
+			MaxReplicas:         19,
+			KedaPollingInterval: 15,
+			KedaScaleToZero:     true,
+			KedaTriggers: []api.KedaTriggerData{
+				{Type: "gcp-pubsub", Metadata: map[string]string{"subscriptionName": "mysubscription", "value": "5"}, AuthenticationRef: "gcp-workload-identity"},
+			},
+		}
+		tmpl, err := template.New("scaledobject.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/scaledobject.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "apiVersion: keda.sh/v1alpha1\nkind: ScaledObject\nmetadata:\n  name: myapp-canary\n  namespace: mynamespace\n  labels:\n    \"app\": \"myapp\"\nspec:\n  scaleTargetRef:\n    apiVersion: apps/v1\n    kind: Deployment\n    name: myapp-canary\n  minReplicaCount: 3\n  maxReplicaCount: 19\n  idleReplicaCount: 0\n  pollingInterval: 15\n  triggers:\n  - type: gcp-pubsub\n    metadata:\n      \"subscriptionName\": \"mysubscription\"\n      \"value\": \"5\"\n    authenticationRef:\n      name: gcp-workload-identity", renderedTemplate.String())
+	})
+
+	t.Run("RenderHookJob", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:          "myapp",
+			NameWithTrack: "myapp-canary",
+			JobName:       "myapp-canary-pre-deploy",
+			Namespace:     "mynamespace",
+			RestartPolicy: "Never",
+			Completions:   1,
+			Parallelism:   1,
+			Container: api.ContainerData{
+				Repository: "extensions",
+				Name:       "myapp",
+				Tag:        "1.0.0",
+				Command:    []string{"/migrate"},
+				Args:       []string{"up", "--verbose"},
+				SecretEnvironmentVariables: map[string]interface{}{
+					"DB_PASSWORD": "secret",
+				},
+			},
+			SecretsName:     "myapp-canary-secrets",
+			MountConfigmap:  true,
+			ConfigmapName:   "myapp-canary-configs",
+			ConfigMountPath: "/configs",
+			ToYAML: func(v interface{}) string {
+				out, _ := yaml.Marshal(v)
+				return string(out)
+			},
+		}
+		tmpl, err := template.New("job.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/job.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.Contains(t, renderedTemplate.String(), "kind: Job\nmetadata:\n  name: myapp-canary-pre-deploy\n")
+		assert.Contains(t, renderedTemplate.String(), "      restartPolicy: Never\n      serviceAccount: myapp\n")
+		assert.Contains(t, renderedTemplate.String(), "        command:\n        - /migrate\n")
+		assert.Contains(t, renderedTemplate.String(), "        args:\n        - up\n        - --verbose\n")
+		assert.Contains(t, renderedTemplate.String(), "              name: myapp-canary-secrets\n")
+		assert.Contains(t, renderedTemplate.String(), "        configMap:\n          name: myapp-canary-configs")
+	})
 }
 
 func stringArrayContains(array []string, search string) bool {
@@ -0,0 +1,64 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/estafette/estafette-extension-gke/templates"
+	"github.com/rs/zerolog/log"
+)
+
+// TemplateSource provides the content of the manifest templates by file name, like deployment.yaml
+type TemplateSource interface {
+	ReadTemplate(name string) ([]byte, error)
+}
+
+// NewEmbeddedTemplateSource returns the templates embedded in the binary, which are versioned together with the code that renders them
+func NewEmbeddedTemplateSource() TemplateSource {
+	return NewFSTemplateSource(templates.FS)
+}
+
+// NewFSTemplateSource returns the templates in the root of fsys
+func NewFSTemplateSource(fsys fs.FS) TemplateSource {
+	return &fsTemplateSource{
+		fsys: fsys,
+	}
+}
+
+type fsTemplateSource struct {
+	fsys fs.FS
+}
+
+func (s *fsTemplateSource) ReadTemplate(name string) ([]byte, error) {
+	return fs.ReadFile(s.fsys, name)
+}
+
+// NewOverlayTemplateSource returns the templates in dir where it has them and those from base otherwise, to customize or try out templates without rebuilding
+func NewOverlayTemplateSource(dir string, base TemplateSource) TemplateSource {
+	return &overlayTemplateSource{
+		dir:     dir,
+		overlay: NewFSTemplateSource(os.DirFS(dir)),
+		base:    base,
+	}
+}
+
+type overlayTemplateSource struct {
+	dir     string
+	overlay TemplateSource
+	base    TemplateSource
+}
+
+func (s *overlayTemplateSource) ReadTemplate(name string) ([]byte, error) {
+	data, err := s.overlay.ReadTemplate(name)
+	if err == nil {
+		log.Info().Msgf("Using template %v from directory %v", name, s.dir)
+		return data, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("Failed reading template %v from directory %v: %w", name, s.dir, err)
+	}
+
+	return s.base.ReadTemplate(name)
+}
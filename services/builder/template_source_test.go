@@ -0,0 +1,63 @@
+package builder
+
+import (
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbeddedTemplateSource(t *testing.T) {
+
+	t.Run("ReadsTemplateByFileName", func(t *testing.T) {
+
+		templateSource := NewEmbeddedTemplateSource()
+
+		// act
+		data, err := templateSource.ReadTemplate("deployment.yaml")
+
+		assert.Nil(t, err)
+		assert.Contains(t, string(data), "kind: Deployment")
+	})
+
+	t.Run("ReturnsErrNotExistIfTemplateIsUnknown", func(t *testing.T) {
+
+		templateSource := NewEmbeddedTemplateSource()
+
+		// act
+		_, err := templateSource.ReadTemplate("unknown.yaml")
+
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+}
+
+func TestOverlayTemplateSource(t *testing.T) {
+
+	t.Run("ReadsTemplateFromDirectoryIfItExists", func(t *testing.T) {
+
+		dir := t.TempDir()
+		err := ioutil.WriteFile(filepath.Join(dir, "deployment.yaml"), []byte("kind: CustomDeployment"), 0644)
+		assert.Nil(t, err)
+		templateSource := NewOverlayTemplateSource(dir, NewEmbeddedTemplateSource())
+
+		// act
+		data, err := templateSource.ReadTemplate("deployment.yaml")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "kind: CustomDeployment", string(data))
+	})
+
+	t.Run("ReadsTemplateFromBaseIfNotInDirectory", func(t *testing.T) {
+
+		templateSource := NewOverlayTemplateSource(t.TempDir(), NewEmbeddedTemplateSource())
+
+		// act
+		data, err := templateSource.ReadTemplate("service.yaml")
+
+		assert.Nil(t, err)
+		assert.Contains(t, string(data), "kind: Service")
+	})
+}
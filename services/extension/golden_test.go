@@ -0,0 +1,333 @@
+package extension
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/estafette/estafette-extension-gke/api"
+	"github.com/estafette/estafette-extension-gke/clients/parameters"
+	"github.com/estafette/estafette-extension-gke/services/builder"
+	"github.com/estafette/estafette-extension-gke/services/generator"
+	"github.com/estafette/estafette-extension-gke/services/validator"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata/golden with the rendered manifests")
+
+type goldenTestCase struct {
+	name       string
+	action     api.ActionType
+	paramsYAML string
+}
+
+const goldenTestBaseParamsYAML = `app: myapp
+kubernetesVersion: 1.21.14-gke.2100
+namespace: mynamespace
+container:
+  repository: extensions
+  name: myapp
+  tag: 1.0.0
+  cpu:
+    request: 100m
+  memory:
+    request: 128Mi
+hosts:
+- myapp.example.com
+internalhosts:
+- myapp.internal.example.com
+whitelist:
+- 10.0.0.0/8
+schedule: '*/5 * * * *'
+configs:
+  inline:
+    config.yaml: 'key: value'
+workloadIdentity: true
+request:
+  authsecret: myauthsecret
+espEndpointsProjectID: myproject
+espOpenapiYamlPath: testdata/openapi.yaml
+espConfigID: 2021-01-01r0
+iapOauthClientID: Y2xpZW50LWlk
+iapOauthClientSecret: Y2xpZW50LXNlY3JldA==
+apigeesuffix: apigee
+`
+
+// goldenTestCases returns each kind with each visibility and deploy action; visibility only applies to kinds that expose a service
+// and canary releases only to deployments, so the other combinations would render the same manifests or fail validation
+func goldenTestCases() []goldenTestCase {
+
+	kinds := []api.Kind{api.KindDeployment, api.KindHeadlessDeployment, api.KindStatefulset, api.KindDaemonSet, api.KindJob, api.KindCronJob, api.KindConfig, api.KindConfigToFile}
+	visibilities := []api.Visibility{api.VisibilityPrivate, api.VisibilityPublic, api.VisibilityPublicWhitelist, api.VisibilityESP, api.VisibilityESPv2, api.VisibilityIAP, api.VisibilityApigee}
+	actions := []api.ActionType{api.ActionDeploySimple, api.ActionDeployCanary, api.ActionDeployStable}
+
+	testCases := []goldenTestCase{}
+	for _, kind := range kinds {
+		kindVisibilities := visibilities
+		if kind != api.KindDeployment && kind != api.KindHeadlessDeployment && kind != api.KindStatefulset {
+			kindVisibilities = []api.Visibility{api.VisibilityPrivate}
+		}
+		kindActions := actions
+		if kind != api.KindDeployment {
+			kindActions = []api.ActionType{api.ActionDeploySimple}
+		}
+
+		for _, visibility := range kindVisibilities {
+			for _, action := range kindActions {
+				testCases = append(testCases, goldenTestCase{
+					name:       fmt.Sprintf("%v-%v-%v", kind, visibility, action),
+					action:     action,
+					paramsYAML: fmt.Sprintf("%vkind: %v\nvisibility: %v\n", goldenTestBaseParamsYAML, kind, visibility),
+				})
+			}
+		}
+	}
+
+	return append(testCases,
+		goldenTestCase{
+			name:   "deployment-private-deploy-simple-sidecars",
+			action: api.ActionDeploySimple,
+			paramsYAML: goldenTestBaseParamsYAML + `kind: deployment
+visibility: private
+sidecars:
+- type: openresty
+- type: cloudsqlproxy
+  dbinstanceconnectionname: myproject:europe-west1:mydatabase
+  sqlproxyport: 5432
+`,
+		},
+		goldenTestCase{
+			name:   "deployment-private-deploy-simple-atomic-update",
+			action: api.ActionDeploySimple,
+			paramsYAML: goldenTestBaseParamsYAML + `kind: deployment
+visibility: private
+strategytype: AtomicUpdate
+`,
+		},
+		goldenTestCase{
+			name:   "deployment-private-deploy-simple-autoscale-metrics",
+			action: api.ActionDeploySimple,
+			paramsYAML: goldenTestBaseParamsYAML + `kind: deployment
+visibility: private
+autoscale:
+  metrics:
+  - type: memory
+    averageUtilization: 75
+  - type: pods
+    name: http_requests_per_second
+    averageValue: 100
+  - type: object
+    name: requests-per-second
+    object:
+      apiVersion: networking.k8s.io/v1
+      kind: Ingress
+      name: myapp
+    value: 2k
+  - type: external
+    name: pubsub.googleapis.com|subscription|num_undelivered_messages
+    selector:
+      resource.labels.subscription_id: mysubscription
+    averageValue: 50
+  behavior:
+    scaleUp:
+      stabilizationWindowSeconds: 0
+      policies:
+      - type: Percent
+        value: 100
+        periodSeconds: 15
+    scaleDown:
+      stabilizationWindowSeconds: 300
+      selectPolicy: Min
+      policies:
+      - type: Pods
+        value: 2
+        periodSeconds: 60
+`,
+		},
+		goldenTestCase{
+			name:   "deployment-private-deploy-simple-keda",
+			action: api.ActionDeploySimple,
+			paramsYAML: goldenTestBaseParamsYAML + `kind: deployment
+visibility: private
+autoscale:
+  type: keda
+  min: 2
+  keda:
+    pollingInterval: 15
+    cooldownPeriod: 120
+    scaleToZero: true
+    triggers:
+    - type: gcp-pubsub
+      metadata:
+        subscriptionName: mysubscription
+        value: "5"
+      authenticationRef: gcp-workload-identity
+    - type: prometheus
+      metadata:
+        serverAddress: http://prometheus.monitoring:9090
+        query: sum(rate(http_requests_total{app="myapp"}[2m]))
+        threshold: "100"
+    - type: cron
+      metadata:
+        timezone: Europe/Amsterdam
+        start: 0 8 * * 1-5
+        end: 0 18 * * 1-5
+        desiredReplicas: "4"
+  behavior:
+    scaleDown:
+      stabilizationWindowSeconds: 300
+`,
+		},
+		goldenTestCase{
+			name:   "daemonset-private-deploy-simple-node-selector",
+			action: api.ActionDeploySimple,
+			paramsYAML: goldenTestBaseParamsYAML + `kind: daemonset
+visibility: private
+nodeSelector:
+  cloud.google.com/gke-nodepool: monitoring
+tolerations:
+- operator: Exists
+rollingupdate:
+  maxunavailable: 10%
+`,
+		},
+		goldenTestCase{
+			name:   "secret-private-deploy-simple",
+			action: api.ActionDeploySimple,
+			paramsYAML: goldenTestBaseParamsYAML + `kind: secret
+secrets:
+  keys:
+    username: YWRtaW4=
+    password: c2VjcmV0
+shared:
+  name: shared-credentials
+  restartDeployments:
+  - myapp
+`,
+		},
+		goldenTestCase{
+			name:   "config-private-deploy-simple-shared",
+			action: api.ActionDeploySimple,
+			paramsYAML: goldenTestBaseParamsYAML + `kind: config
+shared:
+  name: shared-configs
+`,
+		},
+		goldenTestCase{
+			name:   "deployment-private-deploy-simple-immutable-configs",
+			action: api.ActionDeploySimple,
+			paramsYAML: goldenTestBaseParamsYAML + `kind: deployment
+visibility: private
+secrets:
+  keys:
+    password: c2VjcmV0
+immutableConfigs:
+  enabled: true
+`,
+		},
+		goldenTestCase{
+			name:   "deployment-esp-deploy-simple-esp-sidecar",
+			action: api.ActionDeploySimple,
+			paramsYAML: goldenTestBaseParamsYAML + `kind: deployment
+visibility: esp
+sidecars:
+- type: esp
+`,
+		},
+	)
+}
+
+func TestRenderGolden(t *testing.T) {
+
+	ctx := context.Background()
+	parametersClient, err := parameters.NewClient(ctx)
+	assert.Nil(t, err)
+	builderService, err := builder.NewService(ctx, builder.NewEmbeddedTemplateSource())
+	assert.Nil(t, err)
+	generatorService, err := generator.NewService(ctx)
+	assert.Nil(t, err)
+	validatorService, err := validator.NewService(ctx)
+	assert.Nil(t, err)
+	service, err := NewService(ctx, nil, parametersClient, nil, nil, nil, builderService, generatorService, validatorService)
+	assert.Nil(t, err)
+
+	for _, tc := range goldenTestCases() {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+
+			// act
+			manifest, err := service.Render(ctx, &api.GKECredentials{Name: "gke-production"}, "production", tc.paramsYAML, "github.com", "estafette", "myapp", "myapp", "1.0.0", string(tc.action), "1", "main", "abc123", "me@estafette.io")
+
+			if !assert.NoError(t, err) {
+				return
+			}
+			assertManifestHasValidKubernetesObjects(t, manifest)
+			assertGolden(t, filepath.Join("testdata", "golden", tc.name+".yaml"), manifest)
+		})
+	}
+}
+
+func assertGolden(t *testing.T, goldenPath string, actual []byte) {
+	if *update {
+		err := ioutil.WriteFile(goldenPath, actual, 0644)
+		assert.Nil(t, err)
+		return
+	}
+
+	expected, err := ioutil.ReadFile(goldenPath)
+	if !assert.Nil(t, err, "golden file %v is missing; run go test ./services/extension -run TestRenderGolden -update to create it", goldenPath) {
+		return
+	}
+	assert.Equal(t, string(expected), string(actual), "rendered manifest differs from golden file %v; run go test ./services/extension -run TestRenderGolden -update if the change is intended", goldenPath)
+}
+
+// assertManifestHasValidKubernetesObjects decodes each document strictly for the kinds known to client-go, so unknown or misplaced fields fail;
+// custom resources like BackendConfig and VerticalPodAutoscaler are only checked for apiVersion, kind and name
+func assertManifestHasValidKubernetesObjects(t *testing.T, manifest []byte) {
+
+	deserializer := serializer.NewCodecFactory(scheme.Scheme, serializer.EnableStrict).UniversalDeserializer()
+	reader := k8syaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(manifest)))
+	for {
+		document, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if !assert.Nil(t, err) {
+			return
+		}
+		if strings.TrimSpace(string(document)) == "" {
+			continue
+		}
+
+		jsonDocument, err := yaml.YAMLToJSON(document)
+		if !assert.Nil(t, err, "document isn't valid yaml:\n%v", string(document)) {
+			continue
+		}
+
+		var object unstructured.Unstructured
+		err = object.UnmarshalJSON(jsonDocument)
+		if !assert.Nil(t, err, "document isn't a kubernetes object:\n%v", string(document)) {
+			continue
+		}
+		assert.NotEmpty(t, object.GetName(), "%v has no name", object.GetKind())
+
+		_, _, err = deserializer.Decode(jsonDocument, nil, nil)
+		if runtime.IsNotRegisteredError(err) {
+			continue
+		}
+		assert.Nil(t, err, "%v %v isn't valid", object.GetKind(), object.GetName())
+	}
+}
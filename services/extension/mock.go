@@ -35,6 +35,33 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
+// AssistTroubleshooting mocks base method.
+func (m *MockService) AssistTroubleshooting(ctx context.Context, err error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "AssistTroubleshooting", ctx, err)
+}
+
+// AssistTroubleshooting indicates an expected call of AssistTroubleshooting.
+func (mr *MockServiceMockRecorder) AssistTroubleshooting(ctx, err interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssistTroubleshooting", reflect.TypeOf((*MockService)(nil).AssistTroubleshooting), ctx, err)
+}
+
+// Render mocks base method.
+func (m *MockService) Render(ctx context.Context, credential *api.GKECredentials, releaseName, paramsYAML, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseAction, releaseID, gitBranch, gitRevision, triggeredBy string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Render", ctx, credential, releaseName, paramsYAML, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseAction, releaseID, gitBranch, gitRevision, triggeredBy)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Render indicates an expected call of Render.
+func (mr *MockServiceMockRecorder) Render(ctx, credential, releaseName, paramsYAML, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseAction, releaseID, gitBranch, gitRevision, triggeredBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Render", reflect.TypeOf((*MockService)(nil).Render), ctx, credential, releaseName, paramsYAML, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseAction, releaseID, gitBranch, gitRevision, triggeredBy)
+}
+
 // Run mocks base method.
 func (m *MockService) Run(ctx context.Context, credential *api.GKECredentials, releaseName, paramsYAML, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseAction, releaseID, gitBranch, gitRevision, triggeredBy string) error {
 	m.ctrl.T.Helper()
@@ -2,35 +2,97 @@ package extension
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
-	"strconv"
+	"math"
+	"path/filepath"
+	"sort"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/estafette/estafette-extension-gke/api"
 	"github.com/estafette/estafette-extension-gke/clients/credentials"
 	"github.com/estafette/estafette-extension-gke/clients/gcp"
+	"github.com/estafette/estafette-extension-gke/clients/kubernetes"
 	"github.com/estafette/estafette-extension-gke/clients/parameters"
+	"github.com/estafette/estafette-extension-gke/clients/prometheus"
 	"github.com/estafette/estafette-extension-gke/services/builder"
 	"github.com/estafette/estafette-extension-gke/services/generator"
-	foundation "github.com/estafette/estafette-foundation"
+	"github.com/estafette/estafette-extension-gke/services/validator"
 	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var (
+	// ErrValidation is returned when the parameters or the rendered manifests are invalid
+	ErrValidation = wrapError{msg: "Validation failed"}
+
+	// ErrRender is returned when building or rendering the templates fails
+	ErrRender = wrapError{msg: "Rendering manifests failed"}
+
+	// ErrApply is returned when connecting to the cluster or creating, patching or deleting resources as part of the release fails
+	ErrApply = wrapError{msg: "Applying manifests failed"}
+
+	// ErrRollout is returned when the deployment or statefulset doesn't roll out successfully
+	ErrRollout = wrapError{msg: "Rollout failed"}
+
+	// ErrCleanup is returned when removing resources that are no longer needed after the release fails
+	ErrCleanup = wrapError{msg: "Cleanup failed"}
+
+	// ErrCanaryAnalysis is returned when the canary performs worse than stable according to the configured metrics; the canary is rolled back when this happens
+	ErrCanaryAnalysis = wrapError{msg: "Canary analysis failed"}
+
+	// ErrHook is returned when a pre- or post-deploy hook job fails or doesn't complete in time
+	ErrHook = wrapError{msg: "Deploy hook failed"}
+)
+
+const (
+	atomicIDLabel = "estafette.io/atomic-id"
+
+	// previousAtomicIDAnnotation on the service of a BlueGreen deployment records the color that was active before the current one, to switch back to
+	previousAtomicIDAnnotation = "estafette.io/previous-atomic-id"
+
+	// retireAfterAnnotation on a deployment of a BlueGreen color records until when the inactive color is kept around
+	retireAfterAnnotation = "estafette.io/retire-after"
+
+	// immutableConfigsForLabel on the configmaps and secrets created with immutable configs records the workload they were created for
+	immutableConfigsForLabel = "estafette.io/immutable-configs-for"
+
+	// inventoryKey in the inventory configmap of a track lists the objects its last release rendered
+	inventoryKey = "objects"
+
+	preDeployHook  = "pre-deploy"
+	postDeployHook = "post-deploy"
 )
 
 //go:generate mockgen -package=extension -destination ./mock.go -source=service.go
 type Service interface {
 	Run(ctx context.Context, credential *api.GKECredentials, releaseName, paramsYAML, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseAction, releaseID, gitBranch, gitRevision, triggeredBy string) (err error)
+	AssistTroubleshooting(ctx context.Context, err error)
+	Render(ctx context.Context, credential *api.GKECredentials, releaseName, paramsYAML, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseAction, releaseID, gitBranch, gitRevision, triggeredBy string) (manifest []byte, err error)
 }
 
 // NewService returns a new extension.Service
-func NewService(ctx context.Context, credentialsClient credentials.Client, parametersClient parameters.Client, gcpClient gcp.Client, builderService builder.Service, generatorService generator.Service) (Service, error) {
+func NewService(ctx context.Context, credentialsClient credentials.Client, parametersClient parameters.Client, gcpClient gcp.Client, kubernetesClient kubernetes.Client, prometheusClient prometheus.Client, builderService builder.Service, generatorService generator.Service, validatorService validator.Service) (Service, error) {
 	return &service{
 		credentialsClient: credentialsClient,
 		parametersClient:  parametersClient,
 		gcpClient:         gcpClient,
+		kubernetesClient:  kubernetesClient,
+		prometheusClient:  prometheusClient,
 		builderService:    builderService,
 		generatorService:  generatorService,
+		validatorService:  validatorService,
+		manifestDirectory: "/",
+		drainDuration:     30 * time.Second,
+		logsGracePeriod:   30 * time.Second,
 	}, nil
 }
 
@@ -38,45 +100,72 @@ type service struct {
 	credentialsClient credentials.Client
 	parametersClient  parameters.Client
 	gcpClient         gcp.Client
+	kubernetesClient  kubernetes.Client
+	prometheusClient  prometheus.Client
 	builderService    builder.Service
 	generatorService  generator.Service
-
-	assistTroubleshootingOnError bool
-	paramsForTroubleshooting     api.Params
+	validatorService  validator.Service
+	manifestDirectory string
+	drainDuration     time.Duration
+	logsGracePeriod   time.Duration
+
+	assistTroubleshootingOnError   bool
+	paramsForTroubleshooting       api.Params
+	templateDataForTroubleshooting api.TemplateData
+	releaseIDForTroubleshooting    string
+	buildVersionForTroubleshooting string
 }
 
 func (s *service) Run(ctx context.Context, credential *api.GKECredentials, releaseName, paramsYAML, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseAction, releaseID, gitBranch, gitRevision, triggeredBy string) (err error) {
 
 	params, err := s.parametersClient.Init(ctx, paramsYAML, credential, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed initializing parameters")
+		return ErrValidation.wrap(fmt.Errorf("Failed initializing parameters: %w", err))
 	}
 
-	_, err = s.gcpClient.LoadGKEClusterKubeConfig(ctx, credential)
+	kubeContextName, err := s.gcpClient.LoadGKEClusterKubeConfig(ctx, credential)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed creating kube config for gke cluster")
+		return ErrApply.wrap(fmt.Errorf("Failed creating kube config for gke cluster: %w", err))
 	}
 
+	err = s.kubernetesClient.Init(ctx, kubeContextName)
+	if err != nil {
+		return ErrApply.wrap(fmt.Errorf("Failed creating kubernetes client for gke cluster: %w", err))
+	}
+
+	kubernetesVersion, err := s.getKubernetesVersion(ctx, params, credential)
+	if err != nil {
+		return ErrApply.wrap(fmt.Errorf("Failed retrieving kubernetes version of gke cluster: %w", err))
+	}
+	// the templates and local manifests target the version of the cluster, unless it's overridden by the param
+	params.KubernetesVersion = kubernetesVersion
+
 	// combine templates
 	tmpl, err := s.builderService.BuildTemplates(params, true)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed building templates")
+		return ErrRender.wrap(fmt.Errorf("Failed building templates: %w", err))
 	}
 
 	tmplNoPDB, err := s.builderService.BuildTemplates(params, false)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed building templates without poddisruptionbudget")
+		return ErrRender.wrap(fmt.Errorf("Failed building templates without poddisruptionbudget: %w", err))
 	}
 
 	// pre-render config files if they exist
-	params.Configs.RenderedFileContent = s.builderService.RenderConfig(params)
+	params.Configs.RenderedFileContent, err = s.builderService.RenderConfig(params)
+	if err != nil {
+		return ErrRender.wrap(fmt.Errorf("Failed rendering config files: %w", err))
+	}
 	if params.Kind == api.KindConfigToFile {
 		// write files to working directory
 		for filename, data := range params.Configs.RenderedFileContent {
-			ioutil.WriteFile(filename, []byte(data), 0600)
+			err = ioutil.WriteFile(filename, []byte(data), 0600)
+			if err != nil {
+				return ErrRender.wrap(fmt.Errorf("Failed writing config file %v: %w", filename, err))
+			}
 		}
 
-		return
+		return nil
 	}
 
 	// checking number of replicas for existing deployment to make switching deployment type safe
@@ -87,303 +176,1443 @@ func (s *service) Run(ctx context.Context, credential *api.GKECredentials, relea
 
 	// generate the data required for rendering the templates
 	templateData := s.generatorService.GenerateTemplateData(params, currentReplicas, gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy)
+	templateData.APIVersions = s.builderService.GetAPIVersions(kubernetesVersion)
 
 	if params.Action == api.ActionDelete || params.Action == api.ActionDiffDelete {
 		log.Info().Msgf("Deleting all resources with label app=%v in namespace %v...", templateData.AppLabelSelector, templateData.Namespace)
-		args := []string{"delete", "svc,ing,deploy,sts,cronjob,job,cm,secret,hpa,pdb,sa,backendconfig", "-l", fmt.Sprintf("app=%v", templateData.AppLabelSelector), "-n", templateData.Namespace, "--ignore-not-found=true"}
-		if params.DryRun || params.Action == api.ActionDiffDelete {
-			args = append(args, "--dry-run=client")
+		resources := []schema.GroupResource{kubernetes.Services, kubernetes.Ingresses, kubernetes.Deployments, kubernetes.StatefulSets, kubernetes.DaemonSets, kubernetes.CronJobs, kubernetes.Jobs, kubernetes.ConfigMaps, kubernetes.Secrets, kubernetes.HorizontalPodAutoscalers, kubernetes.ScaledObjects, kubernetes.PodDisruptionBudgets, kubernetes.ServiceAccounts, kubernetes.BackendConfigs}
+		err = s.kubernetesClient.DeleteByLabelSelector(ctx, resources, templateData.Namespace, fmt.Sprintf("app=%v", templateData.AppLabelSelector), params.DryRun || params.Action == api.ActionDiffDelete)
+		if err != nil {
+			return ErrApply.wrap(fmt.Errorf("Failed deleting resources with label app=%v: %w", templateData.AppLabelSelector, err))
 		}
-		foundation.RunCommandWithArgs(ctx, "kubectl", args)
 
-		return
+		return nil
+	}
+
+	if params.Action == api.ActionPromote || params.Action == api.ActionSwitchBack {
+		return s.switchBlueGreenColor(ctx, params, templateData)
+	}
+
+	if params.Action == api.ActionRunCronJob {
+		return s.runCronJob(ctx, params, templateData, releaseID)
+	}
+
+	if params.Action == api.ActionSuspendCronJob || params.Action == api.ActionResumeCronJob {
+		return s.suspendCronJob(ctx, params, templateData, params.Action == api.ActionSuspendCronJob)
+	}
+
+	// keep a cronjob suspended by the suspend-cronjob action suspended, unless the suspend param says otherwise
+	if params.Kind == api.KindCronJob && params.Suspend == nil {
+		templateData.Suspend = s.isCronJobSuspended(ctx, templateData)
 	}
 
 	// render the template
 	renderedTemplate, err := s.builderService.RenderTemplate(tmpl, templateData, true)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed rendering templates")
+		return ErrRender.wrap(fmt.Errorf("Failed rendering templates: %w", err))
 	}
 	renderedNoPDBTemplate, err := s.builderService.RenderTemplate(tmplNoPDB, templateData, false)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed rendering templates without poddisruptionbudget")
+		return ErrRender.wrap(fmt.Errorf("Failed rendering templates without poddisruptionbudget: %w", err))
 	}
 
 	if tmpl != nil {
 		log.Info().Msg("Storing rendered manifest on disk...")
-		err = ioutil.WriteFile("/kubernetes.yaml", renderedTemplate.Bytes(), 0600)
+		err = ioutil.WriteFile(filepath.Join(s.manifestDirectory, "kubernetes.yaml"), renderedTemplate.Bytes(), 0600)
 		if err != nil {
-			log.Fatal().Err(err).Msg("Failed writing manifest")
+			return ErrRender.wrap(fmt.Errorf("Failed writing manifest: %w", err))
 		}
 	}
 
 	if tmplNoPDB != nil {
 		log.Info().Msg("Storing rendered manifest without poddisruptionbudget on disk...")
-		err = ioutil.WriteFile("/kubernetes-no-pdb.yaml", renderedNoPDBTemplate.Bytes(), 0600)
+		err = ioutil.WriteFile(filepath.Join(s.manifestDirectory, "kubernetes-no-pdb.yaml"), renderedNoPDBTemplate.Bytes(), 0600)
 		if err != nil {
-			log.Fatal().Err(err).Msg("Failed writing manifest without poddisruptionbudget")
+			return ErrRender.wrap(fmt.Errorf("Failed writing manifest without poddisruptionbudget: %w", err))
 		}
 	}
 
+	sharedDataChanged := false
+	var renderedObjects, staleObjects []kubernetes.ObjectReference
 	if tmpl != nil {
+		err = s.validateManifest(renderedTemplate.Bytes(), kubernetesVersion)
+		if err != nil {
+			return err
+		}
+
 		// visibility public is deprecated, so fail if creating new public service
-		s.failIfCreatingNewPublicService(ctx, params, templateData, templateData.Name, templateData.Namespace)
+		err = s.failIfCreatingNewPublicService(ctx, params, templateData, templateData.Name, templateData.Namespace)
+		if err != nil {
+			return ErrValidation.wrap(err)
+		}
+		err = s.failIfCanaryWithoutStable(ctx, params, templateData.Name, templateData.Namespace)
+		if err != nil {
+			return ErrValidation.wrap(err)
+		}
+		sharedDataChanged, err = s.checkSharedDataUpdate(ctx, params, templateData)
+		if err != nil {
+			return ErrValidation.wrap(err)
+		}
+		if params.UseInventoryPruning() {
+			renderedObjects, staleObjects, err = s.getStaleObjects(ctx, templateData, renderedTemplate.Bytes())
+			if err != nil {
+				return ErrValidation.wrap(err)
+			}
+		}
 
 		// fix resources before server-side dry-run to avoid failure
 		s.cleanupJobIfRequired(ctx, params, templateData, templateData.Name, templateData.Namespace)
-		s.patchServiceIfRequired(ctx, params, templateData, templateData.Name, templateData.Namespace)
-		s.patchDeploymentIfRequired(ctx, params, templateData.Name, templateData.Namespace)
+		err = s.patchServiceIfRequired(ctx, params, templateData, templateData.Name, templateData.Namespace)
+		if err != nil {
+			return ErrApply.wrap(err)
+		}
+		err = s.patchDeploymentIfRequired(ctx, params, templateData.Name, templateData.Namespace)
+		if err != nil {
+			return ErrApply.wrap(err)
+		}
 
 		// always perform a dryrun to ensure we're not ending up in a semi broken state where half of the templates is successfully applied and others not
-		// await https://github.com/kubernetes/kubernetes/issues/83562 to switch back to server-side dry-run and not fail for new namespaces
 		log.Info().Msg("Performing a dryrun to test the validity of the manifests...")
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"apply", "-f", "/kubernetes-no-pdb.yaml", "-n", templateData.Namespace, "--dry-run=client"})
+		err = s.kubernetesClient.Apply(ctx, templateData.Namespace, renderedNoPDBTemplate.Bytes(), true)
+		if err != nil {
+			return ErrValidation.wrap(fmt.Errorf("Failed dryrun of manifests: %w", err))
+		}
 
 		log.Info().Msg("Performing a diff to show what's changed...")
-		_ = foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"diff", "-f", "/kubernetes-no-pdb.yaml", "-n", templateData.Namespace})
-	}
-
-	if !params.DryRun && params.Action != api.ActionDiffSimple && params.Action != api.ActionDiffCanary && params.Action != api.ActionDiffStable {
-
-		// ensure that from now on any error runs the troubleshooting assistant
-		s.assistTroubleshootingOnError = true
-		s.paramsForTroubleshooting = params
-
-		if tmpl != nil {
-			s.deployGoogleEndpointsServiceIfRequired(ctx, params)
-			s.removePoddisruptionBudgetIfRequired(ctx, params, templateData.NameWithTrack, templateData.Namespace)
-			s.removeIngressIfRequired(ctx, params, templateData, templateData.Name, templateData.Namespace)
-			s.removeExtensionCloudFlareExtensionStateAnnotation(ctx, params, templateData.Name, templateData.Namespace)
-
-			log.Info().Msg("Applying the manifests for real...")
-			foundation.RunCommandWithArgs(ctx, "kubectl", []string{"apply", "-f", "/kubernetes.yaml", "-n", templateData.Namespace})
-
-			if params.Kind == api.KindDeployment || params.Kind == api.KindHeadlessDeployment {
-				log.Info().Msg("Waiting for the deployment to finish...")
-				err = foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"rollout", "status", "deployment", templateData.NameWithTrack, "-n", templateData.Namespace})
-			}
-			if params.Kind == api.KindStatefulset {
-				log.Info().Msg("Waiting for the statefulset to finish...")
-				err = foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"rollout", "status", "statefulset", templateData.Name, "-n", templateData.Namespace})
-			}
-		}
-
-		if err != nil {
-			s.assistTroubleshooting(ctx, templateData, releaseID, buildVersion, err)
-		}
-
-		s.handleAtomicUpdate(ctx, params, templateData)
-
-		// clean up old stuff
-		switch params.Kind {
-		case api.KindDeployment:
-			switch params.Action {
-			case api.ActionDeployCanary:
-				s.scaleCanaryDeployment(ctx, templateData.Name, templateData.Namespace, 1)
-				s.deleteConfigsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
-				s.deleteSecretsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
-				break
-			case api.ActionDeployStable:
-				s.scaleCanaryDeployment(ctx, templateData.Name, templateData.Namespace, 0)
-				s.deleteResourcesForTypeSwitch(ctx, templateData.Name, templateData.Namespace)
-				s.deleteConfigsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
-				s.deleteSecretsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
-				s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
-				s.deleteIngressForVisibilityChange(ctx, templateData, templateData.Name, templateData.Namespace)
-				s.removeWorkloadIdentityAnnotationForParamsChange(ctx, params, templateData, templateData.Name, templateData.Namespace)
-				s.removeEstafetteCloudflareAnnotations(ctx, templateData, templateData.Name, templateData.Namespace)
-				s.removeBackendConfigAnnotation(ctx, templateData, templateData.Name, templateData.Namespace)
-				s.removeNegAnnotation(ctx, templateData, templateData.Name, templateData.Namespace)
-				s.deleteBackendConfigAndIAPOauthSecret(ctx, templateData, templateData.Name, templateData.Namespace)
-				s.deleteHorizontalPodAutoscaler(ctx, params, templateData.NameWithTrack, templateData.Namespace)
-				break
-			case api.ActionRollbackCanary:
-				s.scaleCanaryDeployment(ctx, templateData.Name, templateData.Namespace, 0)
-				break
-			case api.ActionRestartCanary:
-				s.restartDeployment(ctx, fmt.Sprintf("%v-canary", templateData.Name), templateData.Namespace)
-				break
-			case api.ActionRestartStable:
-				s.restartDeployment(ctx, fmt.Sprintf("%v-stable", templateData.Name), templateData.Namespace)
-				break
-			case api.ActionRestartSimple:
-				s.restartDeployment(ctx, templateData.Name, templateData.Namespace)
-				break
-			case api.ActionDeploySimple:
-				s.deleteResourcesForTypeSwitch(ctx, fmt.Sprintf("%v-canary", templateData.Name), templateData.Namespace)
-				s.deleteResourcesForTypeSwitch(ctx, fmt.Sprintf("%v-stable", templateData.Name), templateData.Namespace)
-				s.deleteConfigsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
-				s.deleteSecretsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
-				s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
-				s.deleteIngressForVisibilityChange(ctx, templateData, templateData.Name, templateData.Namespace)
-				s.removeWorkloadIdentityAnnotationForParamsChange(ctx, params, templateData, templateData.Name, templateData.Namespace)
-				s.removeEstafetteCloudflareAnnotations(ctx, templateData, templateData.Name, templateData.Namespace)
-				s.removeBackendConfigAnnotation(ctx, templateData, templateData.Name, templateData.Namespace)
-				s.removeNegAnnotation(ctx, templateData, templateData.Name, templateData.Namespace)
-				s.deleteBackendConfigAndIAPOauthSecret(ctx, templateData, templateData.Name, templateData.Namespace)
-				s.deleteHorizontalPodAutoscaler(ctx, params, templateData.Name, templateData.Namespace)
-				break
-			}
-			break
-
-		case api.KindHeadlessDeployment:
-			switch params.Action {
-			case api.ActionDeployCanary:
-				s.scaleCanaryDeployment(ctx, templateData.Name, templateData.Namespace, 1)
-				s.deleteConfigsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
-				s.deleteSecretsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
-				break
-			case api.ActionDeployStable:
-				s.scaleCanaryDeployment(ctx, templateData.Name, templateData.Namespace, 0)
-				s.deleteResourcesForTypeSwitch(ctx, templateData.Name, templateData.Namespace)
-				s.deleteConfigsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
-				s.deleteSecretsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
-				s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
-				s.removeWorkloadIdentityAnnotationForParamsChange(ctx, params, templateData, templateData.Name, templateData.Namespace)
-				s.deleteHorizontalPodAutoscaler(ctx, params, templateData.NameWithTrack, templateData.Namespace)
-				break
-			case api.ActionRollbackCanary:
-				s.scaleCanaryDeployment(ctx, templateData.Name, templateData.Namespace, 0)
-				break
-			case api.ActionRestartCanary:
-				s.restartDeployment(ctx, fmt.Sprintf("%v-canary", templateData.Name), templateData.Namespace)
-				break
-			case api.ActionRestartStable:
-				s.restartDeployment(ctx, fmt.Sprintf("%v-stable", templateData.Name), templateData.Namespace)
-				break
-			case api.ActionRestartSimple:
-				s.restartDeployment(ctx, templateData.Name, templateData.Namespace)
-				break
-			case api.ActionDeploySimple:
-				s.deleteResourcesForTypeSwitch(ctx, fmt.Sprintf("%v-canary", templateData.Name), templateData.Namespace)
-				s.deleteResourcesForTypeSwitch(ctx, fmt.Sprintf("%v-stable", templateData.Name), templateData.Namespace)
-				s.deleteConfigsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
-				s.deleteSecretsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
-				s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
-				s.removeWorkloadIdentityAnnotationForParamsChange(ctx, params, templateData, templateData.Name, templateData.Namespace)
-				s.deleteHorizontalPodAutoscaler(ctx, params, templateData.Name, templateData.Namespace)
-				break
-			}
-			break
-		case api.KindStatefulset:
-			s.deleteConfigsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
-			s.deleteSecretsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
-			s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
-			s.deleteIngressForVisibilityChange(ctx, templateData, templateData.Name, templateData.Namespace)
-			s.removeWorkloadIdentityAnnotationForParamsChange(ctx, params, templateData, templateData.Name, templateData.Namespace)
-			s.removeEstafetteCloudflareAnnotations(ctx, templateData, templateData.Name, templateData.Namespace)
-			s.removeBackendConfigAnnotation(ctx, templateData, templateData.Name, templateData.Namespace)
-			s.deleteBackendConfigAndIAPOauthSecret(ctx, templateData, templateData.Name, templateData.Namespace)
-			break
-		}
-
-		s.assistTroubleshooting(ctx, templateData, releaseID, buildVersion, err)
-	}
-
-	return nil
-}
-
-func (s *service) assistTroubleshooting(ctx context.Context, templateData api.TemplateData, releaseID, buildVersion string, err error) {
-	if s.assistTroubleshootingOnError {
-		log.Info().Msgf("Showing current ingresses, services, configmaps, secrets, deployments, jobs, cronjobs, poddisruptionbudgets, horizontalpodautoscalers, pods, endpoints for app=%v...", s.paramsForTroubleshooting.App)
-		_ = foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"get", "ing,svc,cm,secret,deploy,job,cronjob,sts,pdb,hpa,po,ep", "-l", fmt.Sprintf("app=%v", s.paramsForTroubleshooting.App), "-n", s.paramsForTroubleshooting.Namespace})
-
-		if err != nil {
-			log.Info().Msg("Rollout failed, trying to show logs...")
-			if releaseID != "" {
-				_ = foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"logs", "-l", fmt.Sprintf("app=%v,estafette.io/release-id=%v", templateData.AppLabelSelector, api.SanitizeLabel(releaseID)), "-n", templateData.Namespace, "--all-containers"})
-			} else if buildVersion != "" {
-				_ = foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"logs", "-l", fmt.Sprintf("app=%v,version=%v", templateData.AppLabelSelector, api.SanitizeLabel(buildVersion)), "-n", templateData.Namespace, "--all-containers"})
-			}
-		} else if s.paramsForTroubleshooting.Action == api.ActionDeployCanary {
-			log.Info().Msg("Showing logs for canary deployment...")
-			_ = foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"logs", "-l", fmt.Sprintf("app=%v,track=canary", s.paramsForTroubleshooting.App), "-n", s.paramsForTroubleshooting.Namespace, "-c", s.paramsForTroubleshooting.App, "--tail", "50"})
-		}
-
-		foundation.HandleError(err)
-	}
-}
-
-func (s *service) scaleCanaryDeployment(ctx context.Context, name, namespace string, replicas int) {
-	log.Info().Msgf("Scaling canary deployment to %v replicas...", replicas)
-	foundation.RunCommandWithArgs(ctx, "kubectl", []string{"scale", "deploy", fmt.Sprintf("%v-canary", name), "-n", namespace, fmt.Sprintf("--replicas=%v", replicas)})
+		diff, diffErr := s.kubernetesClient.Diff(ctx, templateData.Namespace, renderedNoPDBTemplate.Bytes())
+		if diffErr != nil {
+			log.Info().Msgf("Failed showing diff: %v", diffErr)
+		} else {
+			fmt.Print(diff)
+		}
+	}
+
+	if params.DryRun || params.Action == api.ActionDiffSimple || params.Action == api.ActionDiffCanary || params.Action == api.ActionDiffStable {
+		if len(staleObjects) > 0 {
+			log.Info().Msg("Performing a dryrun of pruning the objects the previous release rendered and this one doesn't...")
+			err = s.kubernetesClient.Prune(ctx, templateData.Namespace, staleObjects, inventoryLabelSelector(templateData), true)
+			if err != nil {
+				return ErrValidation.wrap(fmt.Errorf("Failed dryrun of pruning: %w", err))
+			}
+		}
+		return nil
+	}
+
+	// ensure that from now on any error runs the troubleshooting assistant
+	s.assistTroubleshootingOnError = true
+	s.paramsForTroubleshooting = params
+	s.templateDataForTroubleshooting = templateData
+	s.releaseIDForTroubleshooting = releaseID
+	s.buildVersionForTroubleshooting = buildVersion
+
+	if tmpl != nil {
+		err = s.deployGoogleEndpointsServiceIfRequired(ctx, params)
+		if err != nil {
+			return ErrApply.wrap(err)
+		}
+		err = s.removePoddisruptionBudgetIfRequired(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+		if err != nil {
+			return ErrApply.wrap(err)
+		}
+		err = s.removeIngressIfRequired(ctx, params, templateData, templateData.Name, templateData.Namespace)
+		if err != nil {
+			return ErrApply.wrap(err)
+		}
+		s.removeExtensionCloudFlareExtensionStateAnnotation(ctx, params, templateData.Name, templateData.Namespace)
+
+		var snapshot *releaseSnapshot
+		if params.UseRollbackOnFailure() {
+			snapshot, err = s.takeReleaseSnapshot(ctx, params, templateData)
+			if err != nil {
+				return ErrApply.wrap(err)
+			}
+		}
+
+		if params.UsePreDeployHook() {
+			err = s.runHook(ctx, params, templateData, preDeployHook, *params.Hooks.PreDeploy)
+			if err != nil {
+				return s.rollbackOnFailure(ctx, templateData, snapshot, err)
+			}
+		}
+
+		log.Info().Msg("Applying the manifests for real...")
+		err = s.kubernetesClient.Apply(ctx, templateData.Namespace, renderedTemplate.Bytes(), false)
+		if err != nil {
+			return s.rollbackOnFailure(ctx, templateData, snapshot, ErrApply.wrap(fmt.Errorf("Failed applying manifests: %w", err)))
+		}
+
+		if params.Kind == api.KindDeployment || params.Kind == api.KindHeadlessDeployment {
+			log.Info().Msg("Waiting for the deployment to finish...")
+			err = s.kubernetesClient.WaitForDeploymentRollout(ctx, templateData.Namespace, templateData.NameWithTrack, 0)
+			if err != nil {
+				return s.rollbackOnFailure(ctx, templateData, snapshot, ErrRollout.wrap(err))
+			}
+		}
+		if params.Kind == api.KindStatefulset {
+			log.Info().Msg("Waiting for the statefulset to finish...")
+			err = s.kubernetesClient.WaitForStatefulSetRollout(ctx, templateData.Namespace, templateData.Name, 0)
+			if err != nil {
+				return ErrRollout.wrap(err)
+			}
+		}
+		if params.Kind == api.KindDaemonSet {
+			log.Info().Msg("Waiting for the daemonset to finish...")
+			err = s.kubernetesClient.WaitForDaemonSetRollout(ctx, templateData.Namespace, templateData.Name, 0)
+			if err != nil {
+				return ErrRollout.wrap(err)
+			}
+		}
+		if params.Kind == api.KindJob && params.WaitForCompletion {
+			log.Info().Msgf("Waiting for the job to complete within %v...", params.CompletionTimeoutDuration())
+			err = s.waitForJobCompletion(ctx, templateData.Namespace, templateData.JobName, templateData.Name, params.CompletionTimeoutDuration())
+			if err != nil {
+				return ErrRollout.wrap(err)
+			}
+		}
+
+		if params.UsePostDeployHook() {
+			err = s.runHook(ctx, params, templateData, postDeployHook, *params.Hooks.PostDeploy)
+			if err != nil {
+				return s.rollbackOnFailure(ctx, templateData, snapshot, err)
+			}
+		}
+	}
+
+	err = s.handleAtomicUpdate(ctx, params, templateData)
+	if err != nil {
+		return err
+	}
+
+	err = s.handleBlueGreen(ctx, params, templateData)
+	if err != nil {
+		return err
+	}
+
+	err = s.restartDeploymentIfRequired(ctx, params, templateData)
+	if err != nil {
+		return err
+	}
+
+	err = s.restartConsumingDeploymentsIfRequired(ctx, params, templateData, sharedDataChanged)
+	if err != nil {
+		return err
+	}
+
+	// clean up old stuff
+	err = s.cleanup(ctx, params, templateData)
+	if err != nil {
+		return ErrCleanup.wrap(err)
+	}
+
+	if tmpl != nil {
+		err = s.pruneImmutableConfigs(ctx, params, templateData)
+		if err != nil {
+			return ErrCleanup.wrap(err)
+		}
+		if params.UseInventoryPruning() {
+			err = s.pruneStaleObjects(ctx, templateData, renderedObjects, staleObjects)
+			if err != nil {
+				return ErrCleanup.wrap(err)
+			}
+		}
+	}
+
+	if params.Action == api.ActionDeployCanary {
+		if params.UseProgressiveCanary() {
+			err = s.shiftTrafficToCanary(ctx, params, templateData, releaseID)
+		} else if params.UseCanaryAnalysis() {
+			err = s.analyzeCanary(ctx, params, templateData, releaseID)
+		}
+		if errors.Is(err, ErrCanaryAnalysis) {
+			log.Warn().Err(err).Msg("Canary analysis failed, rolling back canary...")
+			rollbackErr := s.rollbackCanary(ctx, params, templateData)
+			if rollbackErr != nil {
+				return fmt.Errorf("%w; rolling back canary failed as well: %v", err, rollbackErr)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		if params.UseProgressiveCanary() {
+			log.Info().Msg("All canary steps succeeded, promoting canary to stable...")
+			return s.promoteCanaryToStable(ctx, params, templateData, gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy)
+		}
+	}
+
+	return nil
 }
 
-func (s *service) restartDeployment(ctx context.Context, name, namespace string) {
-	log.Info().Msgf("Restarting deployment rollout...")
-	foundation.RunCommandWithArgs(ctx, "kubectl", []string{"rollout", "restart", "deployment", name, "-n", namespace})
-	_ = foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"rollout", "status", "deployment", name, "-n", namespace})
+// promoteCanaryToStable deploys the release to the stable track once all canary steps succeeded; it continues with the params of the canary
+// release, so the parameters, cluster access, validation, dryrun and hooks of that release aren't repeated
+func (s *service) promoteCanaryToStable(ctx context.Context, params api.Params, canaryData api.TemplateData, gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy string) (err error) {
+
+	params.Action = api.ActionDeployStable
+
+	tmpl, err := s.builderService.BuildTemplates(params, true)
+	if err != nil {
+		return ErrRender.wrap(fmt.Errorf("Failed building stable templates: %w", err))
+	}
+
+	templateData := s.generatorService.GenerateTemplateData(params, s.getExistingNumberOfReplicas(ctx, params), gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy)
+	templateData.APIVersions = canaryData.APIVersions
+
+	renderedTemplate, err := s.builderService.RenderTemplate(tmpl, templateData, true)
+	if err != nil {
+		return ErrRender.wrap(fmt.Errorf("Failed rendering stable templates: %w", err))
+	}
+
+	var renderedObjects, staleObjects []kubernetes.ObjectReference
+	if params.UseInventoryPruning() {
+		renderedObjects, staleObjects, err = s.getStaleObjects(ctx, templateData, renderedTemplate.Bytes())
+		if err != nil {
+			return ErrApply.wrap(err)
+		}
+	}
+
+	err = s.removePoddisruptionBudgetIfRequired(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+	if err != nil {
+		return ErrApply.wrap(err)
+	}
+
+	log.Info().Msg("Applying the stable manifests...")
+	err = s.kubernetesClient.Apply(ctx, templateData.Namespace, renderedTemplate.Bytes(), false)
+	if err != nil {
+		return ErrApply.wrap(fmt.Errorf("Failed applying stable manifests: %w", err))
+	}
+
+	log.Info().Msg("Waiting for the stable deployment to finish...")
+	err = s.kubernetesClient.WaitForDeploymentRollout(ctx, templateData.Namespace, templateData.NameWithTrack, 0)
+	if err != nil {
+		return ErrRollout.wrap(err)
+	}
+
+	err = s.cleanup(ctx, params, templateData)
+	if err != nil {
+		return ErrCleanup.wrap(err)
+	}
+
+	err = s.pruneImmutableConfigs(ctx, params, templateData)
+	if err != nil {
+		return ErrCleanup.wrap(err)
+	}
+	if params.UseInventoryPruning() {
+		err = s.pruneStaleObjects(ctx, templateData, renderedObjects, staleObjects)
+		if err != nil {
+			return ErrCleanup.wrap(err)
+		}
+	}
+
+	return nil
 }
 
-func (s *service) deleteResourcesForTypeSwitch(ctx context.Context, name, namespace string) {
-	// clean up resources in case a switch from simple to canary releases or vice versa has been made
-	log.Info().Msg("Deleting simple type deployment, configmap, secret, hpa and pdb...")
-	foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "deploy", name, "-n", namespace, "--ignore-not-found=true"})
-	foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "configmap", fmt.Sprintf("%v-configs", name), "-n", namespace, "--ignore-not-found=true"})
-	foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "secret", fmt.Sprintf("%v-secrets", name), "-n", namespace, "--ignore-not-found=true"})
-	foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "hpa", name, "-n", namespace, "--ignore-not-found=true"})
-	foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "pdb", name, "-n", namespace, "--ignore-not-found=true"})
+// rollbackCanary removes the canary ingress and scales the canary to zero replicas, like the rollback-canary action does
+func (s *service) rollbackCanary(ctx context.Context, params api.Params, templateData api.TemplateData) (err error) {
+
+	params.Action = api.ActionRollbackCanary
+
+	err = s.cleanup(ctx, params, templateData)
+	if err != nil {
+		return ErrCleanup.wrap(err)
+	}
+
+	return nil
 }
 
-func (s *service) deleteConfigsForParamsChange(ctx context.Context, params api.Params, name, namespace string) {
-	if len(params.Configs.Files) == 0 && len(params.Configs.InlineFiles) == 0 {
-		log.Info().Msg("Deleting application configs if it exists, because no configs are specified...")
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "configmap", fmt.Sprintf("%v-configs", name), "-n", namespace, "--ignore-not-found=true"})
+// runHook runs the hook as a job with the image, env, secrets, configs and service account of the application, streaming its logs until it completes
+func (s *service) runHook(ctx context.Context, params api.Params, templateData api.TemplateData, hook string, hookParams api.HookParams) (err error) {
+
+	hookTmpl, err := s.builderService.GetHookTemplate(params)
+	if err != nil {
+		return ErrRender.wrap(fmt.Errorf("Failed building %v hook template: %w", hook, err))
+	}
+
+	hookData := s.generatorService.GenerateHookTemplateData(templateData, hook, hookParams)
+	renderedTemplate, err := s.builderService.RenderTemplate(hookTmpl, hookData, false)
+	if err != nil {
+		return ErrRender.wrap(fmt.Errorf("Failed rendering %v hook: %w", hook, err))
+	}
+
+	// a job can't be updated, so remove the one left behind by the previous release first
+	err = s.kubernetesClient.Delete(ctx, kubernetes.Jobs, templateData.Namespace, hookData.JobName, false)
+	if err != nil {
+		return ErrApply.wrap(fmt.Errorf("Failed deleting previous %v hook job %v: %w", hook, hookData.JobName, err))
+	}
+	err = s.kubernetesClient.WaitForDeletion(ctx, kubernetes.Jobs, templateData.Namespace, hookData.JobName, time.Minute)
+	if err != nil {
+		return ErrApply.wrap(fmt.Errorf("Failed waiting for previous %v hook job %v to be deleted: %w", hook, hookData.JobName, err))
+	}
+
+	log.Info().Msgf("Running %v hook job %v...", hook, hookData.JobName)
+	err = s.kubernetesClient.Apply(ctx, templateData.Namespace, renderedTemplate.Bytes(), false)
+	if err != nil {
+		return ErrApply.wrap(fmt.Errorf("Failed applying %v hook: %w", hook, err))
+	}
+
+	err = s.waitForJobCompletion(ctx, templateData.Namespace, hookData.JobName, hookData.Name, hookParams.TimeoutDuration())
+	if err != nil {
+		return ErrHook.wrap(fmt.Errorf("The %v hook job %v didn't complete: %w", hook, hookData.JobName, err))
+	}
+
+	log.Info().Msgf("The %v hook job %v completed", hook, hookData.JobName)
+
+	return nil
+}
+
+// waitForJobCompletion waits for the job to complete or fail while streaming the logs of its pods into the build log
+func (s *service) waitForJobCompletion(ctx context.Context, namespace, jobName, container string, timeout time.Duration) (err error) {
+
+	logsCtx, cancelLogs := context.WithCancel(ctx)
+	defer cancelLogs()
+	logsDone := make(chan error, 1)
+	go func() {
+		logsDone <- s.kubernetesClient.StreamJobLogs(logsCtx, namespace, jobName, container)
+	}()
+
+	err = s.kubernetesClient.WaitForJobCompletion(ctx, namespace, jobName, timeout)
+
+	// give the log stream of the last pod some time to catch up before cutting it off
+	select {
+	case logsErr := <-logsDone:
+		if logsErr != nil {
+			log.Info().Msgf("Failed streaming logs of job %v: %v", jobName, logsErr)
+		}
+	case <-time.After(s.logsGracePeriod):
+	}
+
+	return err
+}
+
+// releaseSnapshot holds what a deploy-simple release overwrites in place, so a failed release can be restored to it
+type releaseSnapshot struct {
+	deploymentRevision int64
+	configMap          *corev1.ConfigMap
+	secret             *corev1.Secret
+}
+
+// runCronJob creates a one-off job from the deployed cronjob, labelled with the release id so its pods can be found
+func (s *service) runCronJob(ctx context.Context, params api.Params, templateData api.TemplateData, releaseID string) (err error) {
+
+	runID := api.SanitizeLabel(releaseID)
+	if runID == "" {
+		runID = fmt.Sprint(time.Now().Unix())
+	}
+	jobName := strings.TrimRight(strings.ToLower(fmt.Sprintf("%v-run-%v", templateData.Name, runID)), "-._")
+	if len(jobName) > 63 {
+		jobName = strings.TrimRight(jobName[:63], "-._")
+	}
+
+	labels := map[string]string{
+		"app":                     templateData.AppLabelSelector,
+		"estafette.io/release-id": runID,
+	}
+
+	if params.DryRun {
+		log.Info().Msgf("Dry run, not creating job %v from cronjob %v", jobName, templateData.Name)
+		return nil
+	}
+
+	log.Info().Msgf("Creating job %v from cronjob %v...", jobName, templateData.Name)
+	err = s.kubernetesClient.CreateJobFromCronJob(ctx, templateData.Namespace, templateData.Name, jobName, labels)
+	if err != nil {
+		return ErrApply.wrap(fmt.Errorf("Failed creating job %v from cronjob %v: %w", jobName, templateData.Name, err))
+	}
+
+	if !params.WaitForCompletion {
+		return nil
+	}
+
+	log.Info().Msgf("Waiting for job %v to complete within %v...", jobName, params.CompletionTimeoutDuration())
+	err = s.waitForJobCompletion(ctx, templateData.Namespace, jobName, templateData.Name, params.CompletionTimeoutDuration())
+	if err != nil {
+		return ErrRollout.wrap(err)
+	}
+
+	log.Info().Msgf("Job %v completed successfully", jobName)
+
+	return nil
+}
+
+// suspendCronJob only patches the suspend flag of the deployed cronjob without rendering and applying the manifests
+func (s *service) suspendCronJob(ctx context.Context, params api.Params, templateData api.TemplateData, suspend bool) (err error) {
+
+	if params.DryRun {
+		log.Info().Msgf("Dry run, not setting suspend to %v for cronjob %v", suspend, templateData.Name)
+		return nil
+	}
+
+	err = s.kubernetesClient.SuspendCronJob(ctx, templateData.Namespace, templateData.Name, suspend)
+	if errors.Is(err, kubernetes.ErrNotFound) {
+		return ErrValidation.wrap(fmt.Errorf("Cronjob %v doesn't exist in namespace %v, deploy it first", templateData.Name, templateData.Namespace))
+	}
+	if err != nil {
+		return ErrApply.wrap(fmt.Errorf("Failed setting suspend to %v for cronjob %v: %w", suspend, templateData.Name, err))
+	}
+
+	return nil
+}
+
+func (s *service) isCronJobSuspended(ctx context.Context, templateData api.TemplateData) bool {
+	cronJob, err := s.kubernetesClient.GetCronJob(ctx, templateData.Namespace, templateData.Name)
+	if err != nil {
+		if !errors.Is(err, kubernetes.ErrNotFound) {
+			log.Warn().Err(err).Msgf("Failed retrieving cronjob %v, not keeping its suspend state", templateData.Name)
+		}
+		return false
+	}
+	if cronJob.Spec.Suspend == nil || !*cronJob.Spec.Suspend {
+		return false
+	}
+
+	log.Info().Msgf("Cronjob %v is suspended, keeping it suspended; set suspend: false or run the resume-cronjob action to resume it", templateData.Name)
+
+	return true
+}
+
+func (s *service) takeReleaseSnapshot(ctx context.Context, params api.Params, templateData api.TemplateData) (snapshot *releaseSnapshot, err error) {
+	log.Info().Msg("Storing current deployment revision, configs and secrets to roll back to on failure...")
+
+	snapshot = &releaseSnapshot{}
+
+	deployment, err := s.kubernetesClient.GetDeployment(ctx, templateData.Namespace, templateData.NameWithTrack)
+	if err != nil && !errors.Is(err, kubernetes.ErrNotFound) {
+		return nil, fmt.Errorf("Failed retrieving deployment %v: %w", templateData.NameWithTrack, err)
 	}
+	if err == nil {
+		snapshot.deploymentRevision = kubernetes.DeploymentRevision(deployment)
+	}
+
+	if params.UseImmutableConfigs() {
+		// the previous revision refers to the configmap and secret of its own release, which are kept, so only the revision needs restoring
+		return snapshot, nil
+	}
+
+	snapshot.configMap, err = s.kubernetesClient.GetConfigMap(ctx, templateData.Namespace, templateData.ConfigmapName)
+	if err != nil && !errors.Is(err, kubernetes.ErrNotFound) {
+		return nil, fmt.Errorf("Failed retrieving configmap %v: %w", templateData.ConfigmapName, err)
+	}
+
+	snapshot.secret, err = s.kubernetesClient.GetSecret(ctx, templateData.Namespace, templateData.SecretsName)
+	if err != nil && !errors.Is(err, kubernetes.ErrNotFound) {
+		return nil, fmt.Errorf("Failed retrieving secret %v: %w", templateData.SecretsName, err)
+	}
+
+	return snapshot, nil
+}
+
+// rollbackOnFailure restores the snapshot taken before applying, if any, and returns the original error
+func (s *service) rollbackOnFailure(ctx context.Context, templateData api.TemplateData, snapshot *releaseSnapshot, err error) error {
+	if snapshot == nil {
+		return err
+	}
+
+	log.Warn().Err(err).Msg("Release failed, rolling back to the previous release...")
+	rollbackErr := s.restoreReleaseSnapshot(ctx, templateData, snapshot)
+	if rollbackErr != nil {
+		return fmt.Errorf("%w; rolling back to the previous release failed as well: %v", err, rollbackErr)
+	}
+	log.Info().Msg("Rolled back to the previous release")
+
+	return err
+}
+
+func (s *service) restoreReleaseSnapshot(ctx context.Context, templateData api.TemplateData, snapshot *releaseSnapshot) (err error) {
+
+	// restore configs and secrets first, so the pods of the previous revision start with the contents they were created with
+	if snapshot.configMap != nil {
+		err = s.kubernetesClient.UpdateConfigMapData(ctx, templateData.Namespace, snapshot.configMap.Name, snapshot.configMap.Data)
+		if err != nil {
+			return fmt.Errorf("Failed restoring configmap %v: %w", snapshot.configMap.Name, err)
+		}
+	}
+	if snapshot.secret != nil {
+		err = s.kubernetesClient.UpdateSecretData(ctx, templateData.Namespace, snapshot.secret.Name, snapshot.secret.Data)
+		if err != nil {
+			return fmt.Errorf("Failed restoring secret %v: %w", snapshot.secret.Name, err)
+		}
+	}
+
+	if snapshot.deploymentRevision == 0 {
+		log.Info().Msgf("Deployment %v didn't exist before this release, nothing to roll back to", templateData.NameWithTrack)
+		return nil
+	}
+
+	err = s.kubernetesClient.RollbackDeployment(ctx, templateData.Namespace, templateData.NameWithTrack, snapshot.deploymentRevision)
+	if err != nil {
+		return err
+	}
+
+	err = s.kubernetesClient.WaitForDeploymentRollout(ctx, templateData.Namespace, templateData.NameWithTrack, 0)
+	if err != nil {
+		return fmt.Errorf("Failed rolling out revision %v of deployment %v: %w", snapshot.deploymentRevision, templateData.NameWithTrack, err)
+	}
+
+	return nil
+}
+
+// Render renders the manifests a release would apply, without accessing gcp, the cluster or the container registry
+func (s *service) Render(ctx context.Context, credential *api.GKECredentials, releaseName, paramsYAML, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseAction, releaseID, gitBranch, gitRevision, triggeredBy string) (manifest []byte, err error) {
+
+	params, err := s.parametersClient.InitOffline(ctx, paramsYAML, credential, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID)
+	if err != nil {
+		return nil, ErrValidation.wrap(fmt.Errorf("Failed initializing parameters: %w", err))
+	}
+
+	tmpl, err := s.builderService.BuildTemplates(params, true)
+	if err != nil {
+		return nil, ErrRender.wrap(fmt.Errorf("Failed building templates: %w", err))
+	}
+	if tmpl == nil {
+		log.Info().Msgf("Action %v doesn't apply any manifests, nothing to render", params.Action)
+		return nil, nil
+	}
+
+	params.Configs.RenderedFileContent, err = s.builderService.RenderConfig(params)
+	if err != nil {
+		return nil, ErrRender.wrap(fmt.Errorf("Failed rendering config files: %w", err))
+	}
+
+	// without a cluster to retrieve the current number of replicas from they're set as in a first release
+	templateData := s.generatorService.GenerateTemplateData(params, -1, gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy)
+	templateData.APIVersions = s.builderService.GetAPIVersions(params.KubernetesVersion)
+
+	renderedTemplate, err := s.builderService.RenderTemplate(tmpl, templateData, false)
+	if err != nil {
+		return nil, ErrRender.wrap(fmt.Errorf("Failed rendering templates: %w", err))
+	}
+
+	// without a cluster the manifests can only be validated for an explicitly set kubernetes version
+	if params.KubernetesVersion != "" {
+		err = s.validateManifest(renderedTemplate.Bytes(), params.KubernetesVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return renderedTemplate.Bytes(), nil
+}
+
+// getKubernetesVersion returns the kubernetesVersion param if set, and the version of the gke cluster's control plane otherwise
+func (s *service) getKubernetesVersion(ctx context.Context, params api.Params, credential *api.GKECredentials) (string, error) {
+	if params.KubernetesVersion != "" {
+		return params.KubernetesVersion, nil
+	}
+
+	cluster, err := s.gcpClient.GetGKECluster(ctx, credential.AdditionalProperties.Project, credential.GetLocation(), credential.AdditionalProperties.Cluster)
+	if err != nil {
+		return "", err
+	}
+
+	return cluster.CurrentMasterVersion, nil
+}
+
+// validateManifest checks the rendered manifest against the schema for the kubernetes version, so fields and apiVersions the cluster doesn't serve fail the release before anything is applied
+func (s *service) validateManifest(manifest []byte, kubernetesVersion string) error {
+
+	log.Info().Msgf("Validating manifests against the schema for kubernetes %v...", kubernetesVersion)
+	manifestErrors, err := s.validatorService.ValidateManifest(manifest, kubernetesVersion)
+	if err != nil {
+		return ErrValidation.wrap(fmt.Errorf("Failed validating manifests: %w", err))
+	}
+	if len(manifestErrors) == 0 {
+		return nil
+	}
+
+	messages := []string{}
+	for _, manifestError := range manifestErrors {
+		log.Error().Msg(manifestError.Error())
+		messages = append(messages, manifestError.Error())
+	}
+
+	return ErrValidation.wrap(fmt.Errorf("Manifests are invalid for kubernetes %v: %v", kubernetesVersion, strings.Join(messages, "; ")))
+}
+
+func (s *service) AssistTroubleshooting(ctx context.Context, err error) {
+	if !s.assistTroubleshootingOnError {
+		return
+	}
+
+	templateData := s.templateDataForTroubleshooting
+	releaseID := s.releaseIDForTroubleshooting
+	buildVersion := s.buildVersionForTroubleshooting
+
+	log.Info().Msgf("Showing current ingresses, services, configmaps, secrets, deployments, jobs, cronjobs, statefulsets, daemonsets, poddisruptionbudgets, horizontalpodautoscalers, scaledobjects, pods, endpoints for app=%v...", s.paramsForTroubleshooting.App)
+	resources := []schema.GroupResource{kubernetes.Ingresses, kubernetes.Services, kubernetes.ConfigMaps, kubernetes.Secrets, kubernetes.Deployments, kubernetes.Jobs, kubernetes.CronJobs, kubernetes.StatefulSets, kubernetes.DaemonSets, kubernetes.PodDisruptionBudgets, kubernetes.HorizontalPodAutoscalers, kubernetes.ScaledObjects, kubernetes.Pods, kubernetes.Endpoints}
+	_ = s.kubernetesClient.PrintResources(ctx, resources, s.paramsForTroubleshooting.Namespace, fmt.Sprintf("app=%v", s.paramsForTroubleshooting.App))
+
+	if err != nil {
+		log.Info().Msg("Release failed, trying to show logs...")
+		if releaseID != "" {
+			_ = s.kubernetesClient.PrintPodLogs(ctx, templateData.Namespace, fmt.Sprintf("app=%v,estafette.io/release-id=%v", templateData.AppLabelSelector, api.SanitizeLabel(releaseID)), "", 10)
+		} else if buildVersion != "" {
+			_ = s.kubernetesClient.PrintPodLogs(ctx, templateData.Namespace, fmt.Sprintf("app=%v,version=%v", templateData.AppLabelSelector, api.SanitizeLabel(buildVersion)), "", 10)
+		}
+	} else if s.paramsForTroubleshooting.Action == api.ActionDeployCanary {
+		log.Info().Msg("Showing logs for canary deployment...")
+		_ = s.kubernetesClient.PrintPodLogs(ctx, s.paramsForTroubleshooting.Namespace, fmt.Sprintf("app=%v,track=canary", s.paramsForTroubleshooting.App), s.paramsForTroubleshooting.App, 50)
+	}
+}
+
+func (s *service) cleanup(ctx context.Context, params api.Params, templateData api.TemplateData) (err error) {
+
+	var steps []func() error
+
+	switch params.Kind {
+	case api.KindDeployment:
+		switch params.Action {
+		case api.ActionDeployCanary:
+			steps = []func() error{
+				func() error { return s.scaleCanaryDeployment(ctx, templateData.Name, templateData.Namespace, 1) },
+				func() error {
+					return s.deleteConfigsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteSecretsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+				},
+			}
+		case api.ActionDeployStable:
+			steps = []func() error{
+				func() error {
+					return s.deleteCanaryIngress(ctx, templateData, templateData.Name, templateData.Namespace)
+				},
+				func() error { return s.scaleCanaryDeployment(ctx, templateData.Name, templateData.Namespace, 0) },
+				func() error { return s.deleteResourcesForTypeSwitch(ctx, templateData.Name, templateData.Namespace) },
+				func() error {
+					return s.deleteConfigsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteSecretsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteIngressForVisibilityChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.removeWorkloadIdentityAnnotationForParamsChange(ctx, params, templateData, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.removeEstafetteCloudflareAnnotations(ctx, templateData, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.removeBackendConfigAnnotation(ctx, templateData, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.removeNegAnnotation(ctx, templateData, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteBackendConfigAndIAPOauthSecret(ctx, templateData, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteHorizontalPodAutoscaler(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteScaledObject(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+				},
+			}
+		case api.ActionRollbackCanary:
+			steps = []func() error{
+				func() error {
+					return s.deleteCanaryIngress(ctx, templateData, templateData.Name, templateData.Namespace)
+				},
+				func() error { return s.scaleCanaryDeployment(ctx, templateData.Name, templateData.Namespace, 0) },
+			}
+		case api.ActionDeploySimple:
+			steps = []func() error{
+				func() error {
+					return s.deleteCanaryIngress(ctx, templateData, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteResourcesForTypeSwitch(ctx, fmt.Sprintf("%v-canary", templateData.Name), templateData.Namespace)
+				},
+				func() error {
+					return s.deleteResourcesForTypeSwitch(ctx, fmt.Sprintf("%v-stable", templateData.Name), templateData.Namespace)
+				},
+				func() error {
+					return s.deleteConfigsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteSecretsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteIngressForVisibilityChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.removeWorkloadIdentityAnnotationForParamsChange(ctx, params, templateData, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.removeEstafetteCloudflareAnnotations(ctx, templateData, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.removeBackendConfigAnnotation(ctx, templateData, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.removeNegAnnotation(ctx, templateData, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteBackendConfigAndIAPOauthSecret(ctx, templateData, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteHorizontalPodAutoscaler(ctx, params, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteScaledObject(ctx, params, templateData.Name, templateData.Namespace)
+				},
+			}
+		}
+
+	case api.KindHeadlessDeployment:
+		switch params.Action {
+		case api.ActionDeployCanary:
+			steps = []func() error{
+				func() error { return s.scaleCanaryDeployment(ctx, templateData.Name, templateData.Namespace, 1) },
+				func() error {
+					return s.deleteConfigsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteSecretsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+				},
+			}
+		case api.ActionDeployStable:
+			steps = []func() error{
+				func() error { return s.scaleCanaryDeployment(ctx, templateData.Name, templateData.Namespace, 0) },
+				func() error { return s.deleteResourcesForTypeSwitch(ctx, templateData.Name, templateData.Namespace) },
+				func() error {
+					return s.deleteConfigsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteSecretsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
+				},
+				func() error {
+					return s.removeWorkloadIdentityAnnotationForParamsChange(ctx, params, templateData, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteHorizontalPodAutoscaler(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteScaledObject(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+				},
+			}
+		case api.ActionRollbackCanary:
+			steps = []func() error{
+				func() error { return s.scaleCanaryDeployment(ctx, templateData.Name, templateData.Namespace, 0) },
+			}
+		case api.ActionDeploySimple:
+			steps = []func() error{
+				func() error {
+					return s.deleteResourcesForTypeSwitch(ctx, fmt.Sprintf("%v-canary", templateData.Name), templateData.Namespace)
+				},
+				func() error {
+					return s.deleteResourcesForTypeSwitch(ctx, fmt.Sprintf("%v-stable", templateData.Name), templateData.Namespace)
+				},
+				func() error {
+					return s.deleteConfigsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteSecretsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
+				},
+				func() error {
+					return s.removeWorkloadIdentityAnnotationForParamsChange(ctx, params, templateData, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteHorizontalPodAutoscaler(ctx, params, templateData.Name, templateData.Namespace)
+				},
+				func() error {
+					return s.deleteScaledObject(ctx, params, templateData.Name, templateData.Namespace)
+				},
+			}
+		}
+
+	case api.KindStatefulset:
+		steps = []func() error{
+			func() error {
+				return s.deleteConfigsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
+			},
+			func() error {
+				return s.deleteSecretsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
+			},
+			func() error {
+				return s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
+			},
+			func() error {
+				return s.deleteIngressForVisibilityChange(ctx, templateData, templateData.Name, templateData.Namespace)
+			},
+			func() error {
+				return s.removeWorkloadIdentityAnnotationForParamsChange(ctx, params, templateData, templateData.Name, templateData.Namespace)
+			},
+			func() error {
+				return s.removeEstafetteCloudflareAnnotations(ctx, templateData, templateData.Name, templateData.Namespace)
+			},
+			func() error {
+				return s.removeBackendConfigAnnotation(ctx, templateData, templateData.Name, templateData.Namespace)
+			},
+			func() error {
+				return s.deleteBackendConfigAndIAPOauthSecret(ctx, templateData, templateData.Name, templateData.Namespace)
+			},
+		}
+
+	case api.KindDaemonSet:
+		steps = []func() error{
+			func() error {
+				return s.deleteConfigsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
+			},
+			func() error {
+				return s.deleteSecretsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
+			},
+			func() error {
+				return s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
+			},
+			func() error {
+				return s.removeWorkloadIdentityAnnotationForParamsChange(ctx, params, templateData, templateData.Name, templateData.Namespace)
+			},
+		}
+	}
+
+	for _, step := range steps {
+		err = step()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pruneImmutableConfigs deletes the configmaps and secrets earlier releases created with immutable configs, apart from the current ones
+// and the immutableConfigs.keep most recent others, which earlier revisions of the workload need to roll back to
+func (s *service) pruneImmutableConfigs(ctx context.Context, params api.Params, templateData api.TemplateData) (err error) {
+	if !params.UseImmutableConfigs() {
+		return nil
+	}
+
+	labelSelector := fmt.Sprintf("%v=%v", immutableConfigsForLabel, templateData.NameWithTrack)
+
+	configMaps, err := s.kubernetesClient.ListConfigMaps(ctx, templateData.Namespace, labelSelector)
+	if err != nil {
+		return fmt.Errorf("Failed retrieving configmaps of earlier releases of %v: %w", templateData.NameWithTrack, err)
+	}
+	configMapNames := []string{}
+	for _, configMap := range configMaps {
+		configMapNames = append(configMapNames, configMap.Name)
+	}
+	err = s.pruneImmutableConfigNames(ctx, kubernetes.ConfigMaps, templateData.Namespace, configMapNames, templateData.ConfigmapName, params.ImmutableConfigs.Keep)
+	if err != nil {
+		return err
+	}
+
+	secrets, err := s.kubernetesClient.ListSecrets(ctx, templateData.Namespace, labelSelector)
+	if err != nil {
+		return fmt.Errorf("Failed retrieving secrets of earlier releases of %v: %w", templateData.NameWithTrack, err)
+	}
+	secretNames := []string{}
+	for _, secret := range secrets {
+		secretNames = append(secretNames, secret.Name)
+	}
+
+	return s.pruneImmutableConfigNames(ctx, kubernetes.Secrets, templateData.Namespace, secretNames, templateData.SecretsName, params.ImmutableConfigs.Keep)
+}
+
+// pruneImmutableConfigNames deletes all names, ordered oldest first, except for the current one and the keep most recent others
+func (s *service) pruneImmutableConfigNames(ctx context.Context, resource schema.GroupResource, namespace string, names []string, current string, keep int) (err error) {
+	kept := 0
+	for i := len(names) - 1; i >= 0; i-- {
+		if names[i] == current {
+			continue
+		}
+		if kept < keep {
+			kept++
+			continue
+		}
+
+		log.Info().Msgf("Deleting %v %v of an earlier release...", resource.Resource, names[i])
+		err = s.deleteResource(ctx, resource, namespace, names[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getStaleObjects returns the objects in the manifest the inventory tracks, and the objects the inventory of the previous release of the track lists
+// that the manifest no longer has; without an inventory, like for the first release with pruning, nothing is stale
+func (s *service) getStaleObjects(ctx context.Context, templateData api.TemplateData, manifest []byte) (renderedObjects, staleObjects []kubernetes.ObjectReference, err error) {
+	renderedObjects, err = s.kubernetesClient.ManifestObjects(manifest, inventoryLabelSelector(templateData))
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed listing objects in manifest: %w", err)
+	}
+
+	name := inventoryName(templateData.NameWithTrack)
+	configMap, err := s.kubernetesClient.GetConfigMap(ctx, templateData.Namespace, name)
+	if errors.Is(err, kubernetes.ErrNotFound) {
+		return renderedObjects, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed retrieving inventory %v: %w", name, err)
+	}
+	previousObjects, err := kubernetes.ParseInventory(configMap.Data[inventoryKey])
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed parsing inventory %v: %w", name, err)
+	}
+
+	rendered := map[kubernetes.ObjectReference]bool{}
+	for _, object := range renderedObjects {
+		rendered[object] = true
+	}
+	for _, object := range previousObjects {
+		if !rendered[object] {
+			staleObjects = append(staleObjects, object)
+		}
+	}
+
+	return renderedObjects, staleObjects, nil
+}
+
+// pruneStaleObjects deletes the stale objects that are still labelled for the app and then records the rendered objects in the inventory of the track,
+// so a failed prune is retried by the next release
+func (s *service) pruneStaleObjects(ctx context.Context, templateData api.TemplateData, renderedObjects, staleObjects []kubernetes.ObjectReference) (err error) {
+	if len(staleObjects) > 0 {
+		log.Info().Msg("Pruning the objects the previous release rendered and this one doesn't...")
+		err = s.kubernetesClient.Prune(ctx, templateData.Namespace, staleObjects, inventoryLabelSelector(templateData), false)
+		if err != nil {
+			return err
+		}
+	}
+
+	labels := map[string]string{}
+	for key, value := range templateData.Labels {
+		labels[key] = value
+	}
+	labels["type"] = "inventory"
+
+	manifest, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      inventoryName(templateData.NameWithTrack),
+			"namespace": templateData.Namespace,
+			"labels":    labels,
+		},
+		"data": map[string]string{
+			inventoryKey: kubernetes.FormatInventory(renderedObjects),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.kubernetesClient.Apply(ctx, templateData.Namespace, manifest, false)
+	if err != nil {
+		return fmt.Errorf("Failed storing inventory %v: %w", inventoryName(templateData.NameWithTrack), err)
+	}
+
+	return nil
+}
+
+func inventoryName(nameWithTrack string) string {
+	return fmt.Sprintf("%v-inventory", nameWithTrack)
+}
+
+// inventoryLabelSelector selects the objects of the app the inventory tracks; the configmaps and secrets of immutable configs are left out,
+// since every release renders new ones and pruneImmutableConfigs keeps the earlier ones the workload needs to roll back to
+func inventoryLabelSelector(templateData api.TemplateData) string {
+	return fmt.Sprintf("app=%v,!%v", templateData.AppLabelSelector, immutableConfigsForLabel)
+}
+
+func (s *service) restartDeploymentIfRequired(ctx context.Context, params api.Params, templateData api.TemplateData) (err error) {
+	if params.Kind != api.KindDeployment && params.Kind != api.KindHeadlessDeployment {
+		return nil
+	}
+
+	switch params.Action {
+	case api.ActionRestartCanary:
+		return s.restartDeployment(ctx, fmt.Sprintf("%v-canary", templateData.Name), templateData.Namespace)
+	case api.ActionRestartStable:
+		return s.restartDeployment(ctx, fmt.Sprintf("%v-stable", templateData.Name), templateData.Namespace)
+	case api.ActionRestartSimple:
+		return s.restartDeployment(ctx, templateData.Name, templateData.Namespace)
+	}
+
+	return nil
+}
+
+// restartConsumingDeploymentsIfRequired restarts the deployments listed in shared.restartDeployments, so they pick up the changed shared configs or secrets
+func (s *service) restartConsumingDeploymentsIfRequired(ctx context.Context, params api.Params, templateData api.TemplateData, sharedDataChanged bool) (err error) {
+	if len(params.Shared.RestartDeployments) == 0 {
+		return nil
+	}
+	if !sharedDataChanged {
+		log.Info().Msg("Not restarting deployments that mount the shared data, because it hasn't changed")
+		return nil
+	}
+
+	for _, name := range params.Shared.RestartDeployments {
+		err = s.restartDeployment(ctx, name, templateData.Namespace)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *service) shiftTrafficToCanary(ctx context.Context, params api.Params, templateData api.TemplateData, releaseID string) (err error) {
+
+	canaryTmpl, err := s.builderService.GetProgressiveCanaryTemplate()
+	if err != nil {
+		return ErrRender.wrap(fmt.Errorf("Failed building canary service and ingress template: %w", err))
+	}
+
+	for i, step := range params.Canary.Steps {
+		log.Info().Msgf("Canary step %v/%v: routing %v%% of traffic to the canary...", i+1, len(params.Canary.Steps), step.Weight)
+
+		err = s.scaleCanaryDeploymentForWeight(ctx, templateData.Name, templateData.Namespace, step.Weight)
+		if err != nil {
+			return err
+		}
+
+		renderedTemplate, err := s.builderService.RenderTemplate(canaryTmpl, s.generatorService.GenerateCanaryTemplateData(templateData, step.Weight), false)
+		if err != nil {
+			return ErrRender.wrap(fmt.Errorf("Failed rendering canary service and ingress: %w", err))
+		}
+
+		err = s.kubernetesClient.Apply(ctx, templateData.Namespace, renderedTemplate.Bytes(), false)
+		if err != nil {
+			return ErrApply.wrap(fmt.Errorf("Failed applying canary weight %v: %w", step.Weight, err))
+		}
+
+		pause := step.PauseDuration()
+		if pause > 0 {
+			log.Info().Msgf("Canary step %v/%v: pausing for %v...", i+1, len(params.Canary.Steps), pause)
+			select {
+			case <-ctx.Done():
+				return ErrRollout.wrap(fmt.Errorf("Canary step %v/%v got interrupted: %w", i+1, len(params.Canary.Steps), ctx.Err()))
+			case <-time.After(pause):
+			}
+		}
+
+		if params.UseCanaryAnalysis() {
+			err = s.analyzeCanary(ctx, params, templateData, releaseID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// canaryQueryData is available in canary analysis queries to select either the canary or stable pods
+type canaryQueryData struct {
+	App       string
+	Namespace string
+	Track     string
+	Version   string
+	ReleaseID string
+}
+
+func (s *service) analyzeCanary(ctx context.Context, params api.Params, templateData api.TemplateData, releaseID string) (err error) {
+
+	// the stable pods carry the version and release id of the release that deployed them, so read them from the stable deployment
+	stableName := fmt.Sprintf("%v-stable", templateData.Name)
+	stable, err := s.kubernetesClient.GetDeployment(ctx, templateData.Namespace, stableName)
+	if err != nil {
+		// not being able to read the stable deployment says nothing about the canary, so don't roll it back for it
+		return ErrApply.wrap(fmt.Errorf("Failed retrieving deployment %v: %w", stableName, err))
+	}
+
+	canaryData := canaryQueryData{
+		App:       params.App,
+		Namespace: templateData.Namespace,
+		Track:     "canary",
+		Version:   api.SanitizeLabel(params.BuildVersion),
+		ReleaseID: api.SanitizeLabel(releaseID),
+	}
+	stableData := canaryQueryData{
+		App:       params.App,
+		Namespace: templateData.Namespace,
+		Track:     "stable",
+		Version:   stable.Spec.Template.Labels["version"],
+		ReleaseID: stable.Spec.Template.Labels["estafette.io/release-id"],
+	}
+
+	analysis := params.Canary.Analysis
+	for i := 1; i <= analysis.Iterations; i++ {
+		interval := analysis.IntervalDuration()
+		if interval > 0 {
+			log.Info().Msgf("Canary analysis %v/%v: waiting %v before evaluating metrics...", i, analysis.Iterations, interval)
+			select {
+			case <-ctx.Done():
+				return ErrRollout.wrap(fmt.Errorf("Canary analysis %v/%v got interrupted: %w", i, analysis.Iterations, ctx.Err()))
+			case <-time.After(interval):
+			}
+		}
+
+		// a metric without data says nothing about the canary, so it's evaluated again in the next iteration instead of counting as healthy
+		var inconclusive error
+		for _, metric := range analysis.Metrics {
+			breach, err := s.evaluateCanaryMetric(ctx, analysis.PrometheusURL, metric, canaryData, stableData)
+			if errors.Is(err, prometheus.ErrNoData) {
+				log.Warn().Err(err).Msgf("Canary analysis %v/%v: metric %v is inconclusive", i, analysis.Iterations, metric.Name)
+				inconclusive = err
+				continue
+			}
+			if err != nil {
+				// failing to query a metric says nothing about the canary either, so don't roll it back for it
+				return ErrApply.wrap(fmt.Errorf("Canary analysis %v/%v: %w", i, analysis.Iterations, err))
+			}
+			if breach != "" {
+				return ErrCanaryAnalysis.wrap(fmt.Errorf("Canary analysis %v/%v: %v", i, analysis.Iterations, breach))
+			}
+		}
+
+		if inconclusive != nil && i == analysis.Iterations {
+			return ErrApply.wrap(fmt.Errorf("Canary analysis is inconclusive after %v iterations: %w", analysis.Iterations, inconclusive))
+		}
+	}
+
+	log.Info().Msgf("Canary analysis succeeded after %v iterations", analysis.Iterations)
+
+	return nil
+}
+
+// evaluateCanaryMetric returns a description of how the canary breaches the thresholds of the metric, or an empty string if it doesn't
+func (s *service) evaluateCanaryMetric(ctx context.Context, prometheusURL string, metric api.CanaryMetricParams, canaryData, stableData canaryQueryData) (breach string, err error) {
+
+	canaryValue, err := s.queryCanaryMetric(ctx, prometheusURL, metric, canaryData)
+	if err != nil {
+		return "", err
+	}
+
+	if metric.Max != nil && canaryValue > *metric.Max {
+		return fmt.Sprintf("Metric %v for canary is %v, which is higher than the maximum of %v", metric.Name, canaryValue, *metric.Max), nil
+	}
+
+	if metric.MaxRatio > 0 {
+		stableValue, err := s.queryCanaryMetric(ctx, prometheusURL, metric, stableData)
+		if err != nil {
+			return "", err
+		}
+
+		log.Info().Msgf("Metric %v: canary %v, stable %v", metric.Name, canaryValue, stableValue)
+
+		if canaryValue > stableValue*metric.MaxRatio {
+			return fmt.Sprintf("Metric %v for canary is %v, which is more than %v times the stable value of %v", metric.Name, canaryValue, metric.MaxRatio, stableValue), nil
+		}
+	} else {
+		log.Info().Msgf("Metric %v: canary %v", metric.Name, canaryValue)
+	}
+
+	return "", nil
+}
+
+func (s *service) queryCanaryMetric(ctx context.Context, prometheusURL string, metric api.CanaryMetricParams, data canaryQueryData) (value float64, err error) {
+
+	tmpl, err := template.New(metric.Name).Parse(metric.Query)
+	if err != nil {
+		return 0, fmt.Errorf("Failed parsing query for metric %v: %w", metric.Name, err)
+	}
+	var query strings.Builder
+	err = tmpl.Execute(&query, data)
+	if err != nil {
+		return 0, fmt.Errorf("Failed rendering query for metric %v: %w", metric.Name, err)
+	}
+
+	value, err = s.prometheusClient.Query(ctx, prometheusURL, query.String())
+	if err != nil {
+		return 0, fmt.Errorf("Failed querying metric %v for track %v: %w", metric.Name, data.Track, err)
+	}
+	if math.IsNaN(value) {
+		// a ratio without any requests results in NaN, which is as inconclusive as no samples at all
+		return 0, fmt.Errorf("Metric %v for track %v is NaN: %w", metric.Name, data.Track, prometheus.ErrNoData)
+	}
+
+	return value, nil
+}
+
+func (s *service) scaleCanaryDeploymentForWeight(ctx context.Context, name, namespace string, weight int) (err error) {
+
+	// size the canary relative to stable so it can handle its share of the traffic
+	stableName := fmt.Sprintf("%v-stable", name)
+	stable, err := s.kubernetesClient.GetDeployment(ctx, namespace, stableName)
+	if err != nil {
+		return ErrApply.wrap(fmt.Errorf("Failed retrieving deployment %v: %w", stableName, err))
+	}
+	stableReplicas := 1
+	if stable.Spec.Replicas != nil {
+		stableReplicas = int(*stable.Spec.Replicas)
+	}
+	replicas := (stableReplicas*weight + 99) / 100
+	if replicas < 1 {
+		replicas = 1
+	}
+
+	err = s.scaleCanaryDeployment(ctx, name, namespace, replicas)
+	if err != nil {
+		return ErrApply.wrap(err)
+	}
+
+	err = s.kubernetesClient.WaitForDeploymentRollout(ctx, namespace, fmt.Sprintf("%v-canary", name), 0)
+	if err != nil {
+		return ErrRollout.wrap(err)
+	}
+
+	return nil
+}
+
+func (s *service) scaleCanaryDeployment(ctx context.Context, name, namespace string, replicas int) (err error) {
+	log.Info().Msgf("Scaling canary deployment to %v replicas...", replicas)
+	err = s.kubernetesClient.ScaleDeployment(ctx, namespace, fmt.Sprintf("%v-canary", name), replicas)
+	if err != nil {
+		return fmt.Errorf("Failed scaling canary deployment to %v replicas: %w", replicas, err)
+	}
+
+	return nil
+}
+
+func (s *service) restartDeployment(ctx context.Context, name, namespace string) (err error) {
+	log.Info().Msgf("Restarting deployment rollout...")
+	err = s.kubernetesClient.RestartDeployment(ctx, namespace, name)
+	if err != nil {
+		return ErrApply.wrap(fmt.Errorf("Failed restarting deployment %v: %w", name, err))
+	}
+	err = s.kubernetesClient.WaitForDeploymentRollout(ctx, namespace, name, 0)
+	if err != nil {
+		return ErrRollout.wrap(fmt.Errorf("Failed rolling out restarted deployment %v: %w", name, err))
+	}
+
+	return nil
+}
+
+func (s *service) deleteResourcesForTypeSwitch(ctx context.Context, name, namespace string) (err error) {
+	// clean up resources in case a switch from simple to canary releases or vice versa has been made
+	log.Info().Msg("Deleting simple type deployment, configmap, secret, hpa, inventory, scaledobject and pdb...")
+	err = s.deleteResource(ctx, kubernetes.Deployments, namespace, name)
+	if err != nil {
+		return
+	}
+	err = s.deleteResource(ctx, kubernetes.ConfigMaps, namespace, fmt.Sprintf("%v-configs", name))
+	if err != nil {
+		return
+	}
+	err = s.deleteResource(ctx, kubernetes.Secrets, namespace, fmt.Sprintf("%v-secrets", name))
+	if err != nil {
+		return
+	}
+	err = s.deleteResource(ctx, kubernetes.HorizontalPodAutoscalers, namespace, name)
+	if err != nil {
+		return
+	}
+	// the inventory of the track switched away from is outdated by the releases of the other track
+	err = s.deleteResource(ctx, kubernetes.ConfigMaps, namespace, inventoryName(name))
+	if err != nil {
+		return
+	}
+	err = s.deleteResource(ctx, kubernetes.ScaledObjects, namespace, name)
+	if err != nil && !errors.Is(err, kubernetes.ErrUnknownResource) {
+		return
+	}
+
+	return s.deleteResource(ctx, kubernetes.PodDisruptionBudgets, namespace, name)
+}
+
+func (s *service) deleteConfigsForParamsChange(ctx context.Context, params api.Params, name, namespace string) (err error) {
+	if len(params.Configs.Files) == 0 && len(params.Configs.InlineFiles) == 0 {
+		log.Info().Msg("Deleting application configs if it exists, because no configs are specified...")
+		return s.deleteResource(ctx, kubernetes.ConfigMaps, namespace, fmt.Sprintf("%v-configs", name))
+	}
+
+	return nil
 }
 
-func (s *service) deleteSecretsForParamsChange(ctx context.Context, params api.Params, name, namespace string) {
+func (s *service) deleteSecretsForParamsChange(ctx context.Context, params api.Params, name, namespace string) (err error) {
 	if !params.HasSecrets() {
 		log.Info().Msg("Deleting application secrets if it exists, because no secrets are specified...")
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "secret", fmt.Sprintf("%v-secrets", name), "-n", namespace, "--ignore-not-found=true"})
+		return s.deleteResource(ctx, kubernetes.Secrets, namespace, fmt.Sprintf("%v-secrets", name))
 	}
+
+	return nil
 }
 
-func (s *service) deleteServiceAccountSecretForParamsChange(ctx context.Context, params api.Params, name, namespace string) {
+func (s *service) deleteServiceAccountSecretForParamsChange(ctx context.Context, params api.Params, name, namespace string) (err error) {
 	if !params.UseGoogleCloudCredentials && params.LegacyGoogleCloudServiceAccountKeyFile == "" {
 		log.Info().Msg("Deleting service account secret if it exists, because no use of service account is specified...")
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "secret", fmt.Sprintf("%v-gcp-service-account", name), "-n", namespace, "--ignore-not-found=true"})
+		return s.deleteResource(ctx, kubernetes.Secrets, namespace, fmt.Sprintf("%v-gcp-service-account", name))
 	}
+
+	return nil
 }
 
-func (s *service) removeWorkloadIdentityAnnotationForParamsChange(ctx context.Context, params api.Params, templateData api.TemplateData, name, namespace string) {
+func (s *service) removeWorkloadIdentityAnnotationForParamsChange(ctx context.Context, params api.Params, templateData api.TemplateData, name, namespace string) (err error) {
 	if !*params.WorkloadIdentity {
 		log.Info().Msg("Removing iam.gke.io/gcp-service-account annotations on the serviceaccount if it exists")
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"annotate", "serviceaccount", name, "-n", namespace, "iam.gke.io/gcp-service-account-"})
+		return s.removeAnnotations(ctx, kubernetes.ServiceAccounts, namespace, name, "iam.gke.io/gcp-service-account")
 	}
+
+	return nil
 }
 
-func (s *service) deleteIngressForVisibilityChange(ctx context.Context, templateData api.TemplateData, name, namespace string) {
+func (s *service) deleteIngressForVisibilityChange(ctx context.Context, templateData api.TemplateData, name, namespace string) (err error) {
 	if !templateData.UseNginxIngress && !templateData.UseGCEIngress {
 		// public uses service of type loadbalancer and doesn't need ingress
 		log.Info().Msg("Deleting ingress if it exists, which is used for visibility private, iap or public-whitelist...")
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "ingress", name, "-n", namespace, "--ignore-not-found=true"})
+		return s.deleteResource(ctx, kubernetes.Ingresses, namespace, name)
+	}
+
+	return nil
+}
+
+func (s *service) deleteCanaryIngress(ctx context.Context, templateData api.TemplateData, name, namespace string) (err error) {
+	if templateData.UseNginxIngress {
+		// remove the canary ingress before the canary gets scaled down, so no traffic is routed to a service without endpoints
+		log.Info().Msg("Deleting canary ingress and service if they exist...")
+		err = s.deleteResource(ctx, kubernetes.Ingresses, namespace, fmt.Sprintf("%v-canary", name))
+		if err != nil {
+			return
+		}
+		return s.deleteResource(ctx, kubernetes.Services, namespace, fmt.Sprintf("%v-canary", name))
 	}
+
+	return nil
 }
 
-func (s *service) deleteBackendConfigAndIAPOauthSecret(ctx context.Context, templateData api.TemplateData, name, namespace string) {
+func (s *service) deleteBackendConfigAndIAPOauthSecret(ctx context.Context, templateData api.TemplateData, name, namespace string) (err error) {
 	if !templateData.Service.UseBackendConfigAnnotationOnService {
 		log.Info().Msg("Deleting iap oauth secret if it exists, because visibility is not set to iap...")
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "secret", fmt.Sprintf("%v--iap-oauth-credentials", name), "-n", namespace, "--ignore-not-found=true"})
+		err = s.deleteResource(ctx, kubernetes.Secrets, namespace, fmt.Sprintf("%v--iap-oauth-credentials", name))
+		if err != nil {
+			return
+		}
 		log.Info().Msg("Deleting iap backend config if it exists, because visibility is not set to iap...")
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "backendconfig", name, "-n", namespace, "--ignore-not-found=true"})
+		return s.deleteResource(ctx, kubernetes.BackendConfigs, namespace, name)
 	}
+
+	return nil
 }
 
-func (s *service) removePoddisruptionBudgetIfRequired(ctx context.Context, params api.Params, name, namespace string) {
+func (s *service) removePoddisruptionBudgetIfRequired(ctx context.Context, params api.Params, name, namespace string) (err error) {
 	if (params.Kind == api.KindDeployment || params.Kind == api.KindHeadlessDeployment) && (params.Action == api.ActionDeploySimple || params.Action == api.ActionDeployStable) {
 		// if there's a pdb that doesn't use maxUnavailable: 1 remove it so a new one can be created with correct settings
 		deletePoddisruptionBudget := false
-		maxUnavailable, err := foundation.GetCommandWithArgsOutput(ctx, "kubectl", []string{"get", "pdb", name, "-n", namespace, "-o=jsonpath={.spec.maxUnavailable}"})
+		pdb, err := s.kubernetesClient.GetPodDisruptionBudget(ctx, namespace, name)
 		if err == nil {
-			maxUnavailableInt, err := strconv.Atoi(maxUnavailable)
-			if err == nil {
-				if maxUnavailableInt != 1 {
-					log.Info().Msgf("MaxUnavailable from pdb %v is %v instead of 1", name, maxUnavailableInt)
-					deletePoddisruptionBudget = true
-				}
-			} else {
-				log.Info().Msgf("Failed reading maxUnavailable from pdb %v: %v", name, err)
+			maxUnavailable := pdb.Spec.MaxUnavailable
+			if maxUnavailable == nil || maxUnavailable.Type != intstr.Int {
+				log.Info().Msgf("Failed reading maxUnavailable from pdb %v: %v", name, maxUnavailable)
+				deletePoddisruptionBudget = true
+			} else if maxUnavailable.IntValue() != 1 {
+				log.Info().Msgf("MaxUnavailable from pdb %v is %v instead of 1", name, maxUnavailable.IntValue())
 				deletePoddisruptionBudget = true
 			}
 		} else {
@@ -392,109 +1621,202 @@ func (s *service) removePoddisruptionBudgetIfRequired(ctx context.Context, param
 		}
 
 		if deletePoddisruptionBudget {
-			foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "pdb", name, "-n", namespace, "--ignore-not-found=true"})
-		} else {
-			log.Info().Msgf("Poddisruptionbudet %v is fine, not removing it", name)
+			return s.deleteResource(ctx, kubernetes.PodDisruptionBudgets, namespace, name)
 		}
+
+		log.Info().Msgf("Poddisruptionbudet %v is fine, not removing it", name)
 	}
+
+	return nil
 }
-func (s *service) removeIngressIfRequired(ctx context.Context, params api.Params, templateData api.TemplateData, name, namespace string) {
+
+func (s *service) removeIngressIfRequired(ctx context.Context, params api.Params, templateData api.TemplateData, name, namespace string) (err error) {
 	if params.Kind == api.KindDeployment && (params.Action == api.ActionDeploySimple || params.Action == api.ActionDeployCanary || params.Action == api.ActionDeployStable) {
 		if templateData.UseNginxIngress {
 			// check if ingress exists and has kubernetes.io/ingress.class: gce, then delete it because of https://github.com/kubernetes/ingress-gce/issues/481
-			ingressClass, err := foundation.GetCommandWithArgsOutput(ctx, "kubectl", []string{"get", "ing", name, "-n", namespace, "-o=go-template={{index .metadata.annotations \"kubernetes.io/ingress.class\"}}"})
+			ingress, err := s.kubernetesClient.GetIngress(ctx, namespace, name)
 			if err == nil {
+				ingressClass := ingress.Annotations["kubernetes.io/ingress.class"]
 				if ingressClass == "gce" {
 					// delete the ingress so all related load balancers, etc get deleted
 					log.Info().Msg("Deleting ingress so the gce ingress controller removes the related load balancer...")
-					foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "ingress", name, "-n", namespace, "--ignore-not-found=true"})
-					foundation.RunCommandWithArgs(ctx, "kubectl", []string{"wait", "--for=delete", "ingress/" + name, "-n", namespace, "--timeout=3s"})
-				} else {
-					log.Info().Msgf("Ingress %v already has kubernetes.io/ingress.class: %v annotation, no need to delete the ingress", name, ingressClass)
+					return s.deleteResourceAndWait(ctx, kubernetes.Ingresses, namespace, name, 3*time.Second)
 				}
+				log.Info().Msgf("Ingress %v already has kubernetes.io/ingress.class: %v annotation, no need to delete the ingress", name, ingressClass)
 			} else {
 				log.Info().Msgf("Ingress %v or kubernetes.io/ingress.class annotation doesn't exist, no need to delete the ingress: %v", name, err)
 			}
 		} else if templateData.UseGCEIngress {
 			// check if ingress exists and has kubernetes.io/ingress.class: gce, then delete it to ensure there's no nginx ingress annotations lingering around
-			ingressClass, err := foundation.GetCommandWithArgsOutput(ctx, "kubectl", []string{"get", "ing", name, "-n", namespace, "-o=go-template={{ .spec.ingressClassName }}"})
+			ingress, err := s.kubernetesClient.GetIngress(ctx, namespace, name)
 			if err == nil {
+				ingressClass := ""
+				if ingress.Spec.IngressClassName != nil {
+					ingressClass = *ingress.Spec.IngressClassName
+				}
 				if ingressClass == "nginx-office" {
 					// delete the ingress so all related nginx ingress config gets deleted
 					log.Info().Msg("Deleting ingress so the nginx ingress controller removes related config...")
-					foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "ingress", name, "-n", namespace, "--ignore-not-found=true"})
-					foundation.RunCommandWithArgs(ctx, "kubectl", []string{"wait", "--for=delete", "ingress/" + name, "-n", namespace, "--timeout=3s"})
-				} else {
-					log.Info().Msgf("Ingress %v already has ingressClassName: %v already set, no need to delete the ingress", name, ingressClass)
+					return s.deleteResourceAndWait(ctx, kubernetes.Ingresses, namespace, name, 3*time.Second)
 				}
+				log.Info().Msgf("Ingress %v already has ingressClassName: %v already set, no need to delete the ingress", name, ingressClass)
 			} else {
 				log.Info().Msgf("Ingress %v or ingressClassName doesn't exist, no need to delete the ingress: %v", name, err)
 			}
 		}
 	}
+
+	return nil
 }
 
-func (s *service) deployGoogleEndpointsServiceIfRequired(ctx context.Context, params api.Params) {
+func (s *service) deployGoogleEndpointsServiceIfRequired(ctx context.Context, params api.Params) (err error) {
 	if params.Kind == api.KindDeployment && (params.Visibility == api.VisibilityESP || params.Visibility == api.VisibilityESPv2) && (params.Action == api.ActionDeploySimple || params.Action == api.ActionDeployCanary) {
-		err := s.gcpClient.DeployGoogleCloudEndpoints(ctx, params)
+		err = s.gcpClient.DeployGoogleCloudEndpoints(ctx, params)
 		if err != nil {
-			log.Fatal().Err(err).Msgf("Failed deploying endpoints service in project %v", params.EspEndpointsProjectID)
+			return fmt.Errorf("Failed deploying endpoints service in project %v: %w", params.EspEndpointsProjectID, err)
 		}
 	}
+
+	return nil
 }
 
-func (s *service) failIfCreatingNewPublicService(ctx context.Context, params api.Params, templateData api.TemplateData, name, namespace string) {
+func (s *service) failIfCreatingNewPublicService(ctx context.Context, params api.Params, templateData api.TemplateData, name, namespace string) (err error) {
 	if params.Kind == api.KindDeployment && params.Visibility == api.VisibilityPublic {
-		serviceType, err := foundation.GetCommandWithArgsOutput(ctx, "kubectl", []string{"get", "service", name, "-n", namespace, "-o=jsonpath={.spec.type}"})
+		service, err := s.kubernetesClient.GetService(ctx, namespace, name)
 		// fail if creating new public service or updating to public
 		if err != nil {
-			log.Fatal().Err(err).Msgf("Creating new public service is no longer supported, please use visibility esp or apigee.")
-		} else if serviceType != "LoadBalancer" {
-			log.Fatal().Msgf("Changing service visibility to public is no longer supported, please use visibility esp or apigee.")
+			return fmt.Errorf("Creating new public service is no longer supported, please use visibility esp or apigee: %w", err)
+		} else if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			return fmt.Errorf("Changing service visibility to public is no longer supported, please use visibility esp or apigee")
+		}
+	}
+
+	return nil
+}
+
+// checkSharedDataUpdate returns whether the release changes the configmap of kind config or secret of kind secret; since other applications
+// may mount it, it fails if keys would be removed from it unless shared.allowKeyRemoval is set
+func (s *service) checkSharedDataUpdate(ctx context.Context, params api.Params, templateData api.TemplateData) (changed bool, err error) {
+
+	var name string
+	desiredData := map[string]string{}
+	currentData := map[string]string{}
+
+	switch {
+	case params.Kind == api.KindConfig && len(templateData.ConfigmapFiles) > 0:
+		name = templateData.ConfigmapName
+		for key, value := range templateData.ConfigmapFiles {
+			// the configmap template strips trailing newlines
+			desiredData[key] = strings.TrimRight(value, "\n")
+		}
+		configMap, err := s.kubernetesClient.GetConfigMap(ctx, templateData.Namespace, name)
+		if errors.Is(err, kubernetes.ErrNotFound) {
+			return true, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("Failed retrieving configmap %v: %w", name, err)
+		}
+		for key, value := range configMap.Data {
+			currentData[key] = strings.TrimRight(value, "\n")
+		}
+
+	case params.Kind == api.KindSecret:
+		name = templateData.SecretsName
+		for key, value := range templateData.Secrets {
+			desiredData[key] = fmt.Sprintf("%v", value)
+		}
+		secret, err := s.kubernetesClient.GetSecret(ctx, templateData.Namespace, name)
+		if errors.Is(err, kubernetes.ErrNotFound) {
+			return true, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("Failed retrieving secret %v: %w", name, err)
+		}
+		for key, value := range secret.Data {
+			currentData[key] = base64.StdEncoding.EncodeToString(value)
+		}
+
+	default:
+		return false, nil
+	}
+
+	removedKeys := []string{}
+	for key, value := range currentData {
+		desiredValue, ok := desiredData[key]
+		if !ok {
+			removedKeys = append(removedKeys, key)
+		}
+		if desiredValue != value {
+			changed = true
+		}
+	}
+	if len(removedKeys) > 0 && !params.Shared.AllowKeyRemoval {
+		sort.Strings(removedKeys)
+		return false, fmt.Errorf("Releasing %v would remove keys %v, which other applications may still use; keep them or set shared.allowKeyRemoval to remove them", name, strings.Join(removedKeys, ", "))
+	}
+
+	return changed || len(desiredData) != len(currentData), nil
+}
+
+func (s *service) failIfCanaryWithoutStable(ctx context.Context, params api.Params, name, namespace string) (err error) {
+	if (params.UseProgressiveCanary() || params.UseCanaryAnalysis()) && (params.Action == api.ActionDeployCanary || params.Action == api.ActionDiffCanary) {
+		// the main service only targets stable pods with a progressive canary, so without stable deployment there would be no endpoints;
+		// canary analysis compares against the stable pods, so it needs them as well
+		stableName := fmt.Sprintf("%v-stable", name)
+		_, err := s.kubernetesClient.GetDeployment(ctx, namespace, stableName)
+		if err != nil {
+			return fmt.Errorf("Progressive canary and canary analysis require deployment %v to exist, run deploy-stable first: %w", stableName, err)
 		}
 	}
+
+	return nil
 }
 
-func (s *service) patchServiceIfRequired(ctx context.Context, params api.Params, templateData api.TemplateData, name, namespace string) {
+func (s *service) patchServiceIfRequired(ctx context.Context, params api.Params, templateData api.TemplateData, name, namespace string) (err error) {
 	if params.Kind == api.KindDeployment && templateData.Service.ServiceType == "ClusterIP" {
-		output, err := foundation.GetCommandWithArgsOutput(ctx, "kubectl", []string{"get", "service", templateData.Service.Name, "-n", namespace, "-o=jsonpath={.spec.type} {.spec.ports[*].nodePort}"})
+		serviceType := ""
+		numberOfPorts := 0
+		service, err := s.kubernetesClient.GetService(ctx, namespace, templateData.Service.Name)
 		if err != nil {
 			log.Info().Msgf("Failed retrieving service details: %v", err)
+		} else {
+			serviceType = string(service.Spec.Type)
+			numberOfPorts = len(service.Spec.Ports)
 		}
-		outputFields := strings.Fields(output)
-		serviceType := outputFields[0]
 		if err == nil && (serviceType == "NodePort" || serviceType == "LoadBalancer") {
 			log.Info().Msgf("Service is of type %v, patching it...", serviceType)
 
 			// brute force patch the service
 			nodePortRemovePatchStr := ""
-			for i := 0; i < len(outputFields)-1; i++ {
+			for i := 0; i < numberOfPorts; i++ {
 				nodePortRemovePatchStr += fmt.Sprintf("{\"op\": \"remove\", \"path\": \"/spec/ports/%d/nodePort\"}, ", i)
 			}
 			patchStr := "[{\"op\": \"remove\", \"path\": \"/spec/loadBalancerSourceRanges\"},{\"op\": \"remove\", \"path\": \"/spec/externalTrafficPolicy\"}, " + nodePortRemovePatchStr + "{\"op\": \"replace\", \"path\": \"/spec/type\", \"value\": \"ClusterIP\"}]"
-			err = foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"patch", "service", templateData.Service.Name, "-n", namespace, "--type", "json", "--patch", patchStr})
+			err = s.kubernetesClient.PatchService(ctx, namespace, templateData.Service.Name, types.JSONPatchType, []byte(patchStr))
 			if err != nil {
 				patchStr := "[{\"op\": \"remove\", \"path\": \"/spec/externalTrafficPolicy\"}, " + nodePortRemovePatchStr + "{\"op\": \"replace\", \"path\": \"/spec/type\", \"value\": \"ClusterIP\"}]"
-				err = foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"patch", "service", templateData.Service.Name, "-n", namespace, "--type", "json", "--patch", patchStr})
+				err = s.kubernetesClient.PatchService(ctx, namespace, templateData.Service.Name, types.JSONPatchType, []byte(patchStr))
 			}
 			if err != nil {
-				log.Fatal().Err(err).Msg(fmt.Sprintf("Failed patching service to change from %v to ClusterIP", serviceType))
+				return fmt.Errorf("Failed patching service to change from %v to ClusterIP: %w", serviceType, err)
 			}
 		} else {
 			log.Info().Msgf("Service is of type %v, no need to patch it", serviceType)
 		}
 	}
+
+	return nil
 }
 
 func (s *service) cleanupJobIfRequired(ctx context.Context, params api.Params, templateData api.TemplateData, name, namespace string) {
 	if params.Kind == api.KindJob {
-		err := foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"delete", "job", name, "-n", namespace, "--ignore-not-found=true"})
+		err := s.kubernetesClient.Delete(ctx, kubernetes.Jobs, namespace, name, false)
 		if err != nil {
 			log.Info().Msgf("Deleting job %v failed: %v", name, err)
 		}
 	}
 	if params.Kind == api.KindCronJob {
-		err := foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"delete", "cronjob", name, "-n", namespace, "--ignore-not-found=true"})
+		err := s.kubernetesClient.Delete(ctx, kubernetes.CronJobs, namespace, name, false)
 		if err != nil {
 			log.Info().Msgf("Deleting cronjob %v failed: %v", name, err)
 		}
@@ -503,21 +1825,25 @@ func (s *service) cleanupJobIfRequired(ctx context.Context, params api.Params, t
 
 func (s *service) getExistingNumberOfReplicas(ctx context.Context, params api.Params) int {
 	if params.Kind == api.KindDeployment || params.Kind == api.KindHeadlessDeployment {
-		if params.StrategyType == api.StrategyTypeAtomicUpdate {
-			replicas, err := foundation.GetCommandWithArgsOutput(ctx, "kubectl", []string{"get", "deploy", "-l", fmt.Sprintf("app in (%v),estafette.io/atomic-id,estafette.io/atomic-id notin (%v)", api.SanitizeLabel(params.App), params.AtomicID), "-n", params.Namespace, "--sort-by=.metadata.creationTimestamp", "-o=jsonpath={.items[-1:].spec.replicas}"})
+		if params.StrategyType == api.StrategyTypeAtomicUpdate || params.StrategyType == api.StrategyTypeBlueGreen {
+			deployments, err := s.kubernetesClient.ListDeployments(ctx, params.Namespace, fmt.Sprintf("app in (%v),estafette.io/atomic-id,estafette.io/atomic-id notin (%v)", api.SanitizeLabel(params.App), params.AtomicID))
 			if err != nil {
 				log.Info().Err(err).Msg("Failed retrieving replicas for previous atomic deployments. Ignoring setting replicas since there's no switch for deployment type...")
 				return -1
 			}
-			replicasInt, err := strconv.Atoi(replicas)
-			if err != nil {
-				log.Info().Err(err).Msgf("Failed converting replicas value %v for previous atomic deployments. Ignoring setting replicas since there's no switch for deployment type...", replicas)
+			if len(deployments) == 0 || deployments[len(deployments)-1].Spec.Replicas == nil {
+				log.Info().Msg("No replicas found for previous atomic deployments. Ignoring setting replicas since there's no switch for deployment type...")
 				return -1
 			}
+			replicasInt := int(*deployments[len(deployments)-1].Spec.Replicas)
 			log.Info().Msgf("Retrieved number of replicas for previous atomic deployments is %v; using it to set correct number of replicas switching deployment type...", replicasInt)
 			return replicasInt
 		}
 
+		if replicas := s.getReplicasForSwitchFromKeda(ctx, params); replicas > 0 {
+			return replicas
+		}
+
 		deploymentName := ""
 		if params.Action == api.ActionDeploySimple || params.Action == api.ActionDiffSimple {
 			deploymentName = params.App + "-stable"
@@ -525,16 +1851,16 @@ func (s *service) getExistingNumberOfReplicas(ctx context.Context, params api.Pa
 			deploymentName = params.App
 		}
 		if deploymentName != "" {
-			replicas, err := foundation.GetCommandWithArgsOutput(ctx, "kubectl", []string{"get", "deploy", deploymentName, "-n", params.Namespace, "-o=jsonpath={.spec.replicas}"})
+			deployment, err := s.kubernetesClient.GetDeployment(ctx, params.Namespace, deploymentName)
 			if err != nil {
 				log.Info().Msgf("Failed retrieving replicas for %v: %v ignoring setting replicas since there's no switch for deployment type...", deploymentName, err)
 				return -1
 			}
-			replicasInt, err := strconv.Atoi(replicas)
-			if err != nil {
-				log.Info().Msgf("Failed converting replicas value %v for %v: %v ignoring setting replicas since there's no switch for deployment type...", replicas, deploymentName, err)
+			if deployment.Spec.Replicas == nil {
+				log.Info().Msgf("No replicas set for %v, ignoring setting replicas since there's no switch for deployment type...", deploymentName)
 				return -1
 			}
+			replicasInt := int(*deployment.Spec.Replicas)
 			log.Info().Msgf("Retrieved number of replicas for %v is %v; using it to set correct number of replicas switching deployment type...", deploymentName, replicasInt)
 			return replicasInt
 		}
@@ -543,117 +1869,485 @@ func (s *service) getExistingNumberOfReplicas(ctx context.Context, params api.Pa
 	return -1
 }
 
-func (s *service) patchDeploymentIfRequired(ctx context.Context, params api.Params, name, namespace string) {
+// getReplicasForSwitchFromKeda returns the minimum number of replicas if keda scaled the deployment to zero and the hpa takes over,
+// since the hpa never scales up a deployment with zero replicas; otherwise it returns -1
+func (s *service) getReplicasForSwitchFromKeda(ctx context.Context, params api.Params) int {
+	if params.Autoscale.Enabled == nil || !*params.Autoscale.Enabled || params.UsesKedaAutoscaler() {
+		return -1
+	}
+
+	deploymentName := ""
+	if params.Action == api.ActionDeploySimple || params.Action == api.ActionDiffSimple {
+		deploymentName = params.App
+	} else if params.Action == api.ActionDeployStable || params.Action == api.ActionDiffStable {
+		deploymentName = params.App + "-stable"
+	}
+	if deploymentName == "" {
+		return -1
+	}
+
+	deployment, err := s.kubernetesClient.GetDeployment(ctx, params.Namespace, deploymentName)
+	if err != nil || deployment.Spec.Replicas == nil || *deployment.Spec.Replicas > 0 {
+		return -1
+	}
+
+	log.Info().Msgf("Deployment %v is scaled to zero, probably by keda; setting replicas to %v for the hpa to take over...", deploymentName, params.Autoscale.MinReplicas)
+	return params.Autoscale.MinReplicas
+}
+
+func (s *service) patchDeploymentIfRequired(ctx context.Context, params api.Params, name, namespace string) (err error) {
 	if (params.Kind == api.KindDeployment || params.Kind == api.KindHeadlessDeployment) && params.Action == api.ActionDeploySimple {
-		selectorLabels, err := foundation.GetCommandWithArgsOutput(ctx, "kubectl", []string{"get", "deploy", name, "-n", namespace, "-o=jsonpath={.spec.selector.matchLabels}"})
+		selectorLabels := map[string]string{}
+		deployment, err := s.kubernetesClient.GetDeployment(ctx, namespace, name)
 		if err != nil {
 			log.Info().Msgf("Failed retrieving deployment selector labels: %v", err)
+		} else if deployment.Spec.Selector != nil {
+			selectorLabels = deployment.Spec.Selector.MatchLabels
 		}
-		if err == nil && selectorLabels != fmt.Sprintf("map[app:%v]", name) {
+		if err == nil && (len(selectorLabels) != 1 || selectorLabels["app"] != name) {
 			log.Info().Msgf("Deployment selector labels %v not correct, patching it...", selectorLabels)
 
 			// patch the deployment
-			err = foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"patch", "deploy", name, "-n", namespace, "--type", "json", "--patch", fmt.Sprintf("[{\"op\": \"replace\", \"path\": \"/spec/selector/matchLabels\", \"value\": {\"app\":\"%v\"}}]", name)})
+			err = s.kubernetesClient.PatchDeployment(ctx, namespace, name, types.JSONPatchType, []byte(fmt.Sprintf("[{\"op\": \"replace\", \"path\": \"/spec/selector/matchLabels\", \"value\": {\"app\":\"%v\"}}]", name)))
 			if err != nil {
-				log.Fatal().Err(err).Msg(fmt.Sprintf("Failed patching deployment to change selector labels from %v to app=%v", selectorLabels, name))
+				return fmt.Errorf("Failed patching deployment to change selector labels from %v to app=%v: %w", selectorLabels, name, err)
 			}
 		} else {
 			log.Info().Msgf("Deployment selector labels %v are correct, not patching", selectorLabels)
 		}
 	}
+
+	return nil
 }
 
-func (s *service) removeEstafetteCloudflareAnnotations(ctx context.Context, templateData api.TemplateData, name, namespace string) {
+func (s *service) removeEstafetteCloudflareAnnotations(ctx context.Context, templateData api.TemplateData, name, namespace string) (err error) {
 	if !templateData.Service.UseDNSAnnotationsOnService {
 		// ingress is used and has the estafette.io/cloudflare annotations, so they should be removed from the service
 		log.Info().Msg("Removing estafette.io/cloudflare annotations on the service if they exists, since they're now set on the ingress instead...")
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"annotate", "svc", templateData.Service.Name, "-n", namespace, "estafette.io/cloudflare-dns-"})
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"annotate", "svc", templateData.Service.Name, "-n", namespace, "estafette.io/cloudflare-proxy-"})
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"annotate", "svc", templateData.Service.Name, "-n", namespace, "estafette.io/cloudflare-hostnames-"})
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"annotate", "svc", templateData.Service.Name, "-n", namespace, "estafette.io/cloudflare-state-"})
+		return s.removeAnnotations(ctx, kubernetes.Services, namespace, templateData.Service.Name, "estafette.io/cloudflare-dns", "estafette.io/cloudflare-proxy", "estafette.io/cloudflare-hostnames", "estafette.io/cloudflare-state")
 	}
+
+	return nil
 }
 
 func (s *service) removeExtensionCloudFlareExtensionStateAnnotation(ctx context.Context, params api.Params, name string, namespace string) {
 	if *params.DNS.UseExternalDNS && !*params.DNS.UseCloudflareEstafetteExtension {
 
 		log.Info().Msg("Removing CloudFlare extension DNS state ingress annotation ...")
-		_ = foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"annotate", "ing", name, "-n", namespace, "estafette.io/cloudflare-state-"})
+		_ = s.kubernetesClient.RemoveAnnotations(ctx, kubernetes.Ingresses, namespace, name, "estafette.io/cloudflare-state")
 		if params.Visibility == api.VisibilityApigee {
-			_ = foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"annotate", "ing", name + "-apigee", "-n", namespace, "estafette.io/cloudflare-state-"})
+			_ = s.kubernetesClient.RemoveAnnotations(ctx, kubernetes.Ingresses, namespace, name+"-apigee", "estafette.io/cloudflare-state")
 		}
 		if len(params.InternalHosts) > 0 {
-			_ = foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"annotate", "ing", name + "-internal", "-n", namespace, "estafette.io/cloudflare-state-"})
+			_ = s.kubernetesClient.RemoveAnnotations(ctx, kubernetes.Ingresses, namespace, name+"-internal", "estafette.io/cloudflare-state")
 		}
 
 		log.Info().Msg("Removing CloudFlare extension DNS state service annotation ...")
-		_ = foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"annotate", "svc", name, "-n", namespace, "estafette.io/cloudflare-state-"})
+		_ = s.kubernetesClient.RemoveAnnotations(ctx, kubernetes.Services, namespace, name, "estafette.io/cloudflare-state")
 	}
 }
 
-func (s *service) removeBackendConfigAnnotation(ctx context.Context, templateData api.TemplateData, name, namespace string) {
+func (s *service) removeBackendConfigAnnotation(ctx context.Context, templateData api.TemplateData, name, namespace string) (err error) {
 	if !templateData.Service.UseBackendConfigAnnotationOnService {
 		// iap is not used, so the beta.cloud.google.com/backend-config annotations should be removed from the service
 		log.Info().Msg("Removing beta.cloud.google.com/backend-config annotations on the service if they exists, since visibility is not set to iap...")
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"annotate", "svc", templateData.Service.Name, "-n", namespace, "beta.cloud.google.com/backend-config-"})
+		return s.removeAnnotations(ctx, kubernetes.Services, namespace, templateData.Service.Name, "beta.cloud.google.com/backend-config")
 	}
+
+	return nil
 }
 
-func (s *service) removeNegAnnotation(ctx context.Context, templateData api.TemplateData, name, namespace string) {
+func (s *service) removeNegAnnotation(ctx context.Context, templateData api.TemplateData, name, namespace string) (err error) {
 	if !templateData.Service.UseNegAnnotationOnService {
 		// cloud native load balancing is not used, so the beta.cloud.google.com/backend-config annotations should be removed from the service
 		log.Info().Msg("Removing cloud.google.com/neg annotations on the service if they exists, since visibility is not set to iap or containerNativeLoadBalancing is set to fals...")
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"annotate", "svc", templateData.Service.Name, "-n", namespace, "cloud.google.com/neg-"})
+		return s.removeAnnotations(ctx, kubernetes.Services, namespace, templateData.Service.Name, "cloud.google.com/neg")
+	}
+
+	return nil
+}
+
+func (s *service) deleteHorizontalPodAutoscaler(ctx context.Context, params api.Params, name, namespace string) (err error) {
+	if (params.Kind == api.KindDeployment || params.Kind == api.KindHeadlessDeployment) && (params.Action == api.ActionDeploySimple || params.Action == api.ActionDeployStable) {
+		if params.Autoscale.Enabled == nil || !*params.Autoscale.Enabled {
+			log.Info().Msgf("Deleting HorizontalPodAutoscaler %v, since autoscaling is disabled...", name)
+			return s.deleteResource(ctx, kubernetes.HorizontalPodAutoscalers, namespace, name)
+		}
+		if params.UsesKedaAutoscaler() {
+			// keda creates its own hpa named keda-hpa-<name>, which would fight over the replicas with the one from a previous release
+			log.Info().Msgf("Deleting HorizontalPodAutoscaler %v, since autoscaling is done by keda...", name)
+			return s.deleteResource(ctx, kubernetes.HorizontalPodAutoscalers, namespace, name)
+		}
 	}
+
+	return nil
 }
 
-func (s *service) deleteHorizontalPodAutoscaler(ctx context.Context, params api.Params, name, namespace string) {
-	if (params.Kind == api.KindDeployment || params.Kind == api.KindHeadlessDeployment) && (params.Autoscale.Enabled == nil || !*params.Autoscale.Enabled) && (params.Action == api.ActionDeploySimple || params.Action == api.ActionDeployStable) {
-		log.Info().Msgf("Deleting HorizontalPodAutoscaler %v, since autoscaling is disabled...", name)
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "hpa", name, "-n", namespace, "--ignore-not-found=true"})
+func (s *service) deleteScaledObject(ctx context.Context, params api.Params, name, namespace string) (err error) {
+	if (params.Kind == api.KindDeployment || params.Kind == api.KindHeadlessDeployment) && !params.UsesKedaAutoscaler() && (params.Action == api.ActionDeploySimple || params.Action == api.ActionDeployStable) {
+		log.Info().Msgf("Deleting ScaledObject %v if it exists, since autoscaling isn't done by keda...", name)
+		err = s.deleteResource(ctx, kubernetes.ScaledObjects, namespace, name)
+		if errors.Is(err, kubernetes.ErrUnknownResource) {
+			// keda isn't installed in the cluster, so there's no scaled object to delete
+			return nil
+		}
+		return err
 	}
+
+	return nil
 }
 
-func (s *service) handleAtomicUpdate(ctx context.Context, params api.Params, templateData api.TemplateData) {
+func (s *service) handleAtomicUpdate(ctx context.Context, params api.Params, templateData api.TemplateData) (err error) {
 	if params.StrategyType != api.StrategyTypeAtomicUpdate {
-		return
+		return nil
 	}
 
 	// update service in order to point to new deployment
 	log.Info().Msgf("Updating service selector to use the latest atomic id...")
 	atomicServiceTmpl, err := s.builderService.GetAtomicUpdateServiceTemplate()
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed building service template")
+		return ErrRender.wrap(fmt.Errorf("Failed building service template: %w", err))
 	}
 
 	renderedTemplate, err := s.builderService.RenderTemplate(atomicServiceTmpl, templateData, true)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed rendering templates")
+		return ErrRender.wrap(fmt.Errorf("Failed rendering templates: %w", err))
 	}
 
 	log.Info().Msg("Storing rendered service manifest on disk...")
-	err = ioutil.WriteFile("/service.yaml", renderedTemplate.Bytes(), 0600)
+	err = ioutil.WriteFile(filepath.Join(s.manifestDirectory, "service.yaml"), renderedTemplate.Bytes(), 0600)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed writing manifest")
+		return ErrRender.wrap(fmt.Errorf("Failed writing manifest: %w", err))
 	}
 
 	log.Info().Msg("Applying the service manifest...")
-	foundation.RunCommandWithArgs(ctx, "kubectl", []string{"apply", "-f", "/service.yaml", "-n", templateData.Namespace})
+	err = s.kubernetesClient.Apply(ctx, templateData.Namespace, renderedTemplate.Bytes(), false)
+	if err != nil {
+		return ErrApply.wrap(fmt.Errorf("Failed applying service manifest: %w", err))
+	}
 
 	// wait a bit to drain traffic to old deployment
-	sleepTime := 30
-	log.Info().Msgf("Waiting for %v seconds to drain traffic to previous deployment(s)...", sleepTime)
-	time.Sleep(time.Duration(sleepTime) * time.Second)
+	log.Info().Msgf("Waiting for %v to drain traffic to previous deployment(s)...", s.drainDuration)
+	time.Sleep(s.drainDuration)
 
 	// clean up old deployments, configmaps, secrets, hpa, pdb
 	log.Info().Msg("Cleaning up previous deployments, configmaps, secrets, hpas and pdbs...")
-	foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "deploy,hpa,pdb", "-l", fmt.Sprintf("app in (%v),estafette.io/atomic-id,estafette.io/atomic-id notin (%v)", api.SanitizeLabel(params.App), params.AtomicID), "-n", templateData.Namespace, "--ignore-not-found=true"})
-	foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "configmap,secret", "-l", fmt.Sprintf("app in (%v),type in (application),estafette.io/atomic-id,estafette.io/atomic-id notin (%v)", api.SanitizeLabel(params.App), params.AtomicID), "-n", templateData.Namespace, "--ignore-not-found=true"})
+	workloadResources := []schema.GroupResource{kubernetes.Deployments, kubernetes.HorizontalPodAutoscalers, kubernetes.ScaledObjects, kubernetes.PodDisruptionBudgets}
+	configResources := []schema.GroupResource{kubernetes.ConfigMaps, kubernetes.Secrets}
+
+	previousWorkloadsSelector, previousConfigsSelector := nonAtomicSelectors(params, templateData)
+
+	deletions := []labelSelectorDeletion{
+		{workloadResources, fmt.Sprintf("app in (%v),estafette.io/atomic-id,estafette.io/atomic-id notin (%v)", api.SanitizeLabel(params.App), params.AtomicID)},
+		{configResources, fmt.Sprintf("app in (%v),type in (application),estafette.io/atomic-id,estafette.io/atomic-id notin (%v)", api.SanitizeLabel(params.App), params.AtomicID)},
+		{workloadResources, previousWorkloadsSelector},
+		{configResources, previousConfigsSelector},
+	}
+
+	for _, d := range deletions {
+		err = s.kubernetesClient.DeleteByLabelSelector(ctx, d.resources, templateData.Namespace, d.labelSelector, false)
+		if err != nil {
+			return ErrCleanup.wrap(fmt.Errorf("Failed deleting resources with labels %v: %w", d.labelSelector, err))
+		}
+	}
+
+	return nil
+}
+
+// labelSelectorDeletion deletes all resources of the given types matching the label selector
+type labelSelectorDeletion struct {
+	resources     []schema.GroupResource
+	labelSelector string
+}
+
+// nonAtomicSelectors return the label selectors for workloads and configs deployed before switching to the AtomicUpdate or BlueGreen strategy type
+func nonAtomicSelectors(params api.Params, templateData api.TemplateData) (workloadsSelector, configsSelector string) {
 	if templateData.IncludeTrackLabel {
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "deploy,hpa,pdb", "-l", fmt.Sprintf("app in (%v),!estafette.io/atomic-id,track in (%v)", api.SanitizeLabel(params.App), templateData.TrackLabel), "-n", templateData.Namespace, "--ignore-not-found=true"})
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "configmap,secret", "-l", fmt.Sprintf("app in (%v),type in (application),!estafette.io/atomic-id,track in (%v)", api.SanitizeLabel(params.App), templateData.TrackLabel), "-n", templateData.Namespace, "--ignore-not-found=true"})
-	} else {
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "deploy,hpa,pdb", "-l", fmt.Sprintf("app in (%v),!estafette.io/atomic-id,!track", api.SanitizeLabel(params.App)), "-n", templateData.Namespace, "--ignore-not-found=true"})
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "configmap,secret", "-l", fmt.Sprintf("app in (%v),type in (application),!estafette.io/atomic-id,!track", api.SanitizeLabel(params.App)), "-n", templateData.Namespace, "--ignore-not-found=true"})
+		return fmt.Sprintf("app in (%v),!estafette.io/atomic-id,track in (%v)", api.SanitizeLabel(params.App), templateData.TrackLabel),
+			fmt.Sprintf("app in (%v),type in (application),!estafette.io/atomic-id,track in (%v)", api.SanitizeLabel(params.App), templateData.TrackLabel)
+	}
+
+	return fmt.Sprintf("app in (%v),!estafette.io/atomic-id,!track", api.SanitizeLabel(params.App)),
+		fmt.Sprintf("app in (%v),type in (application),!estafette.io/atomic-id,!track", api.SanitizeLabel(params.App))
+}
+
+func (s *service) handleBlueGreen(ctx context.Context, params api.Params, templateData api.TemplateData) (err error) {
+	if params.StrategyType != api.StrategyTypeBlueGreen || params.Action != api.ActionDeploySimple {
+		return nil
+	}
+
+	if len(params.BlueGreen.PreviewHosts) > 0 {
+		err = s.applyBlueGreenPreview(ctx, params, templateData)
+		if err != nil {
+			return err
+		}
+	}
+
+	activeAtomicID, _, err := s.getBlueGreenColors(ctx, templateData)
+	if err != nil {
+		return ErrApply.wrap(err)
+	}
+
+	// without an active color there's nothing to compare the new color with, so it gets promoted right away
+	if activeAtomicID == "" || params.BlueGreen.AutoPromote == nil || *params.BlueGreen.AutoPromote {
+		return s.promoteBlueGreenColor(ctx, params, templateData, params.AtomicID)
+	}
+
+	log.Info().Msgf("Color %v is deployed next to active color %v; run the promote action to switch traffic to it", params.AtomicID, activeAtomicID)
+
+	return s.pruneBlueGreenColors(ctx, params, templateData, activeAtomicID, params.AtomicID)
+}
+
+func (s *service) switchBlueGreenColor(ctx context.Context, params api.Params, templateData api.TemplateData) (err error) {
+
+	activeAtomicID, previousAtomicID, err := s.getBlueGreenColors(ctx, templateData)
+	if err != nil {
+		return ErrApply.wrap(err)
+	}
+
+	targetAtomicID := ""
+	switch params.Action {
+	case api.ActionPromote:
+		// promote the most recently deployed color
+		deployments, err := s.kubernetesClient.ListDeployments(ctx, templateData.Namespace, fmt.Sprintf("app in (%v),%v", api.SanitizeLabel(params.App), atomicIDLabel))
+		if err != nil {
+			return ErrApply.wrap(fmt.Errorf("Failed retrieving colors of %v: %w", params.App, err))
+		}
+		if len(deployments) == 0 {
+			return ErrValidation.wrap(fmt.Errorf("No color of %v found to promote, run deploy-simple first", params.App))
+		}
+		targetAtomicID = deployments[len(deployments)-1].Labels[atomicIDLabel]
+
+	case api.ActionSwitchBack:
+		if previousAtomicID == "" {
+			return ErrValidation.wrap(fmt.Errorf("Service %v has no previous color to switch back to", templateData.Name))
+		}
+		deployments, err := s.kubernetesClient.ListDeployments(ctx, templateData.Namespace, blueGreenColorSelector(params, previousAtomicID))
+		if err != nil {
+			return ErrApply.wrap(fmt.Errorf("Failed retrieving color %v: %w", previousAtomicID, err))
+		}
+		if len(deployments) == 0 {
+			return ErrValidation.wrap(fmt.Errorf("Previous color %v has been retired and can't be switched back to", previousAtomicID))
+		}
+		targetAtomicID = previousAtomicID
+	}
+
+	if params.DryRun {
+		log.Info().Msgf("Dry run, not switching service %v from color %v to color %v", templateData.Name, activeAtomicID, targetAtomicID)
+		return nil
+	}
+
+	return s.promoteBlueGreenColor(ctx, params, templateData, targetAtomicID)
+}
+
+// getBlueGreenColors returns the color the service currently routes traffic to and the color it routed traffic to before
+func (s *service) getBlueGreenColors(ctx context.Context, templateData api.TemplateData) (activeAtomicID, previousAtomicID string, err error) {
+	service, err := s.kubernetesClient.GetService(ctx, templateData.Namespace, templateData.Name)
+	if errors.Is(err, kubernetes.ErrNotFound) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("Failed retrieving service %v: %w", templateData.Name, err)
+	}
+
+	return service.Spec.Selector[atomicIDLabel], service.Annotations[previousAtomicIDAnnotation], nil
+}
+
+func (s *service) applyBlueGreenPreview(ctx context.Context, params api.Params, templateData api.TemplateData) (err error) {
+	log.Info().Msgf("Routing preview hosts %v to color %v...", strings.Join(params.BlueGreen.PreviewHosts, ", "), templateData.AtomicID)
+
+	previewTmpl, err := s.builderService.GetBlueGreenPreviewTemplate()
+	if err != nil {
+		return ErrRender.wrap(fmt.Errorf("Failed building preview service and ingress template: %w", err))
+	}
+
+	renderedTemplate, err := s.builderService.RenderTemplate(previewTmpl, s.generatorService.GenerateBlueGreenPreviewTemplateData(templateData, params.BlueGreen.PreviewHosts), false)
+	if err != nil {
+		return ErrRender.wrap(fmt.Errorf("Failed rendering preview service and ingress: %w", err))
+	}
+
+	err = s.kubernetesClient.Apply(ctx, templateData.Namespace, renderedTemplate.Bytes(), false)
+	if err != nil {
+		return ErrApply.wrap(fmt.Errorf("Failed applying preview service and ingress: %w", err))
+	}
+
+	return nil
+}
+
+func (s *service) promoteBlueGreenColor(ctx context.Context, params api.Params, templateData api.TemplateData, atomicID string) (err error) {
+
+	activeAtomicID, _, err := s.getBlueGreenColors(ctx, templateData)
+	if err != nil {
+		return ErrApply.wrap(err)
+	}
+	if activeAtomicID == atomicID {
+		log.Info().Msgf("Color %v is already active", atomicID)
+		return s.pruneBlueGreenColors(ctx, params, templateData, atomicID)
+	}
+
+	// point the service at the color; the selector change is instant, so no new pods are needed
+	log.Info().Msgf("Switching service %v to color %v...", templateData.Name, atomicID)
+	serviceData := templateData
+	serviceData.IncludeAtomicIDSelector = true
+	serviceData.AtomicID = atomicID
+	serviceData.Labels = map[string]string{}
+	for k, v := range templateData.Labels {
+		if k != atomicIDLabel {
+			serviceData.Labels[k] = v
+		}
+	}
+
+	serviceTmpl, err := s.builderService.GetAtomicUpdateServiceTemplate()
+	if err != nil {
+		return ErrRender.wrap(fmt.Errorf("Failed building service template: %w", err))
+	}
+	renderedTemplate, err := s.builderService.RenderTemplate(serviceTmpl, serviceData, true)
+	if err != nil {
+		return ErrRender.wrap(fmt.Errorf("Failed rendering service: %w", err))
+	}
+	err = s.kubernetesClient.Apply(ctx, templateData.Namespace, renderedTemplate.Bytes(), false)
+	if err != nil {
+		return ErrApply.wrap(fmt.Errorf("Failed applying service manifest: %w", err))
+	}
+
+	if activeAtomicID != "" {
+		patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, previousAtomicIDAnnotation, activeAtomicID)
+		err = s.kubernetesClient.PatchService(ctx, templateData.Namespace, templateData.Name, types.MergePatchType, []byte(patch))
+		if err != nil {
+			return ErrApply.wrap(fmt.Errorf("Failed recording previous color %v on service %v: %w", activeAtomicID, templateData.Name, err))
+		}
+
+		// keep the previous color around for a while to switch back to
+		retireAfter := time.Now().Add(params.BlueGreen.KeepPreviousDuration()).UTC().Format(time.RFC3339)
+		log.Info().Msgf("Keeping previous color %v until %v...", activeAtomicID, retireAfter)
+		err = s.setBlueGreenColorRetireAfter(ctx, params, templateData, activeAtomicID, retireAfter)
+		if err != nil {
+			return ErrApply.wrap(err)
+		}
+	}
+
+	// a color that's switched back to might have been scheduled for retirement before
+	err = s.setBlueGreenColorRetireAfter(ctx, params, templateData, atomicID, "")
+	if err != nil {
+		return ErrApply.wrap(err)
+	}
+
+	return s.pruneBlueGreenColors(ctx, params, templateData, atomicID)
+}
+
+// setBlueGreenColorRetireAfter sets the retire-after annotation on the deployments of a color, or removes it if retireAfter is empty
+func (s *service) setBlueGreenColorRetireAfter(ctx context.Context, params api.Params, templateData api.TemplateData, atomicID, retireAfter string) (err error) {
+	deployments, err := s.kubernetesClient.ListDeployments(ctx, templateData.Namespace, blueGreenColorSelector(params, atomicID))
+	if err != nil {
+		return fmt.Errorf("Failed retrieving color %v: %w", atomicID, err)
+	}
+
+	for _, deployment := range deployments {
+		if retireAfter == "" {
+			err = s.removeAnnotations(ctx, kubernetes.Deployments, templateData.Namespace, deployment.Name, retireAfterAnnotation)
+		} else {
+			patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, retireAfterAnnotation, retireAfter)
+			err = s.kubernetesClient.PatchDeployment(ctx, templateData.Namespace, deployment.Name, types.MergePatchType, []byte(patch))
+		}
+		if err != nil {
+			return fmt.Errorf("Failed updating retirement of color %v: %w", atomicID, err)
+		}
+	}
+
+	return nil
+}
+
+// pruneBlueGreenColors deletes all colors except the ones to keep and the ones whose retire-after time hasn't passed yet
+func (s *service) pruneBlueGreenColors(ctx context.Context, params api.Params, templateData api.TemplateData, keepAtomicIDs ...string) (err error) {
+	deployments, err := s.kubernetesClient.ListDeployments(ctx, templateData.Namespace, fmt.Sprintf("app in (%v),%v", api.SanitizeLabel(params.App), atomicIDLabel))
+	if err != nil {
+		return ErrCleanup.wrap(fmt.Errorf("Failed retrieving colors of %v: %w", params.App, err))
+	}
+
+	workloadResources := []schema.GroupResource{kubernetes.Deployments, kubernetes.HorizontalPodAutoscalers, kubernetes.ScaledObjects, kubernetes.PodDisruptionBudgets}
+	configResources := []schema.GroupResource{kubernetes.ConfigMaps, kubernetes.Secrets}
+
+	deletions := []labelSelectorDeletion{}
+
+	for _, deployment := range deployments {
+		atomicID := deployment.Labels[atomicIDLabel]
+		if contains(keepAtomicIDs, atomicID) {
+			continue
+		}
+		if retireAfter, err := time.Parse(time.RFC3339, deployment.Annotations[retireAfterAnnotation]); err == nil && time.Now().Before(retireAfter) {
+			log.Info().Msgf("Keeping color %v until %v", atomicID, retireAfter.Format(time.RFC3339))
+			continue
+		}
+
+		log.Info().Msgf("Retiring color %v...", atomicID)
+		deletions = append(deletions,
+			labelSelectorDeletion{workloadResources, blueGreenColorSelector(params, atomicID)},
+			labelSelectorDeletion{configResources, fmt.Sprintf("app in (%v),type in (application),%v in (%v)", api.SanitizeLabel(params.App), atomicIDLabel, atomicID)},
+		)
+	}
+
+	// once a color is active, workloads from before switching to BlueGreen are no longer served
+	previousWorkloadsSelector, previousConfigsSelector := nonAtomicSelectors(params, templateData)
+	deletions = append(deletions,
+		labelSelectorDeletion{workloadResources, previousWorkloadsSelector},
+		labelSelectorDeletion{configResources, previousConfigsSelector},
+	)
+
+	for _, d := range deletions {
+		err = s.kubernetesClient.DeleteByLabelSelector(ctx, d.resources, templateData.Namespace, d.labelSelector, false)
+		if err != nil {
+			return ErrCleanup.wrap(fmt.Errorf("Failed deleting resources with labels %v: %w", d.labelSelector, err))
+		}
+	}
+
+	return nil
+}
+
+func blueGreenColorSelector(params api.Params, atomicID string) string {
+	return fmt.Sprintf("app in (%v),%v in (%v)", api.SanitizeLabel(params.App), atomicIDLabel, atomicID)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *service) deleteResource(ctx context.Context, resource schema.GroupResource, namespace, name string) (err error) {
+	err = s.kubernetesClient.Delete(ctx, resource, namespace, name, false)
+	if err != nil {
+		return fmt.Errorf("Failed deleting %v %v: %w", resource.String(), name, err)
+	}
+
+	return nil
+}
+
+func (s *service) deleteResourceAndWait(ctx context.Context, resource schema.GroupResource, namespace, name string, timeout time.Duration) (err error) {
+	err = s.deleteResource(ctx, resource, namespace, name)
+	if err != nil {
+		return
+	}
+
+	err = s.kubernetesClient.WaitForDeletion(ctx, resource, namespace, name, timeout)
+	if err != nil {
+		return fmt.Errorf("Failed waiting for deletion of %v %v: %w", resource.String(), name, err)
+	}
+
+	return nil
+}
+
+func (s *service) removeAnnotations(ctx context.Context, resource schema.GroupResource, namespace, name string, annotations ...string) (err error) {
+	err = s.kubernetesClient.RemoveAnnotations(ctx, resource, namespace, name, annotations...)
+	if errors.Is(err, kubernetes.ErrNotFound) {
+		log.Info().Msgf("%v %v doesn't exist, no need to remove annotations", resource.String(), name)
+		return nil
 	}
+	if err != nil {
+		return fmt.Errorf("Failed removing annotations from %v %v: %w", resource.String(), name, err)
+	}
+
+	return nil
 }
@@ -0,0 +1,1590 @@
+package extension
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/estafette/estafette-extension-gke/api"
+	"github.com/estafette/estafette-extension-gke/clients/gcp"
+	"github.com/estafette/estafette-extension-gke/clients/kubernetes"
+	"github.com/estafette/estafette-extension-gke/clients/parameters"
+	"github.com/estafette/estafette-extension-gke/clients/prometheus"
+	"github.com/estafette/estafette-extension-gke/services/builder"
+	"github.com/estafette/estafette-extension-gke/services/generator"
+	"github.com/estafette/estafette-extension-gke/services/validator"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	containerv1 "google.golang.org/api/container/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestRun(t *testing.T) {
+
+	t.Run("ReturnsErrValidationIfParametersInitFails", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		mocks.parametersClient.EXPECT().Init(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(api.Params{}, errors.New("invalid params"))
+		mocks.setDefaults(testParams(api.ActionDeploySimple))
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrValidation))
+	})
+
+	t.Run("ReturnsErrApplyIfLoadingKubeConfigFails", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		mocks.gcpClient.EXPECT().LoadGKEClusterKubeConfig(gomock.Any(), gomock.Any()).Return("", errors.New("no access"))
+		mocks.setDefaults(testParams(api.ActionDeploySimple))
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrApply))
+	})
+
+	t.Run("ReturnsErrRenderIfBuildingTemplatesFails", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		mocks.builderService.EXPECT().BuildTemplates(gomock.Any(), true).Return(nil, errors.New("missing template"))
+		mocks.setDefaults(testParams(api.ActionDeploySimple))
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrRender))
+	})
+
+	t.Run("ReturnsErrValidationIfDryrunFails", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		mocks.kubernetesClient.EXPECT().Apply(gomock.Any(), gomock.Any(), gomock.Any(), true).Return(kubernetes.ErrInvalidManifest)
+		mocks.setDefaults(testParams(api.ActionDeploySimple))
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrValidation))
+		assert.True(t, errors.Is(err, kubernetes.ErrInvalidManifest))
+	})
+
+	t.Run("ReturnsErrValidationIfManifestIsInvalidForKubernetesVersionOfCluster", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		mocks.gcpClient.EXPECT().GetGKECluster(a, a, a, a).Return(&containerv1.Cluster{CurrentMasterVersion: "1.25.8-gke.1000"}, nil)
+		mocks.validatorService.EXPECT().ValidateManifest(a, "1.25.8-gke.1000").Return([]validator.ManifestError{{Document: 3, Kind: "CronJob", Name: "myapp", Field: "apiVersion", Message: "apiVersion batch/v1beta1 isn't served"}}, nil)
+		mocks.kubernetesClient.EXPECT().Apply(a, a, a, a).Return(errors.New("Apply should not be called")).AnyTimes()
+		mocks.setDefaults(testParams(api.ActionDeploySimple))
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrValidation))
+		assert.Contains(t, err.Error(), "document 3 (CronJob myapp) field apiVersion: apiVersion batch/v1beta1 isn't served")
+	})
+
+	t.Run("ValidatesManifestForKubernetesVersionParamInsteadOfCluster", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		params.KubernetesVersion = "1.24"
+		mocks.gcpClient.EXPECT().GetGKECluster(a, a, a, a).Return(nil, errors.New("GetGKECluster should not be called")).AnyTimes()
+		mocks.validatorService.EXPECT().ValidateManifest(a, "1.24").Return([]validator.ManifestError{}, nil).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("RendersAPIVersionsForKubernetesVersionOfCluster", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		apiVersions := api.APIVersionsData{PodDisruptionBudget: "policy/v1", CronJob: "batch/v1", HorizontalPodAutoscaler: "autoscaling/v2beta2"}
+		mocks.gcpClient.EXPECT().GetGKECluster(a, a, a, a).Return(&containerv1.Cluster{CurrentMasterVersion: "1.22.17-gke.100"}, nil)
+		mocks.builderService.EXPECT().BuildTemplates(a, a).DoAndReturn(func(params api.Params, includePodDisruptionBudget bool) (*template.Template, error) {
+			assert.Equal(t, "1.22.17-gke.100", params.KubernetesVersion)
+			return template.New("kubernetes.yaml"), nil
+		}).Times(2)
+		mocks.builderService.EXPECT().GetAPIVersions("1.22.17-gke.100").Return(apiVersions).Times(1)
+		mocks.builderService.EXPECT().RenderTemplate(a, a, a).DoAndReturn(func(tmpl *template.Template, data api.TemplateData, logTemplate bool) (bytes.Buffer, error) {
+			assert.Equal(t, apiVersions, data.APIVersions)
+			return *bytes.NewBufferString("kind: Deployment"), nil
+		}).AnyTimes()
+		mocks.setDefaults(testParams(api.ActionDeploySimple))
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("ReturnsErrApplyIfApplyFails", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		mocks.kubernetesClient.EXPECT().Apply(gomock.Any(), gomock.Any(), gomock.Any(), false).Return(kubernetes.ErrRejected)
+		mocks.setDefaults(testParams(api.ActionDeploySimple))
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrApply))
+		assert.True(t, errors.Is(err, kubernetes.ErrRejected))
+	})
+
+	t.Run("ReturnsErrRolloutIfDeploymentDoesNotRollOut", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		mocks.kubernetesClient.EXPECT().WaitForDeploymentRollout(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(kubernetes.ErrRolloutFailed)
+		mocks.setDefaults(testParams(api.ActionDeploySimple))
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrRollout))
+		assert.True(t, errors.Is(err, kubernetes.ErrRolloutFailed))
+	})
+
+	t.Run("ReturnsErrRolloutIfRestartedDeploymentDoesNotRollOut", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		mocks.builderService.EXPECT().BuildTemplates(a, a).Return(nil, nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().RestartDeployment(a, "mynamespace", "myapp").Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().WaitForDeploymentRollout(a, "mynamespace", "myapp", a).Return(kubernetes.ErrRolloutFailed).Times(1)
+		mocks.setDefaults(testParams(api.ActionRestartSimple))
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrRollout))
+		assert.True(t, errors.Is(err, kubernetes.ErrRolloutFailed))
+	})
+
+	t.Run("ReturnsErrCleanupIfScalingCanaryFails", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		mocks.kubernetesClient.EXPECT().ScaleDeployment(gomock.Any(), gomock.Any(), "myapp-canary", 1).Return(errors.New("forbidden"))
+		mocks.setDefaults(testParams(api.ActionDeployCanary))
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrCleanup))
+	})
+
+	t.Run("ReturnsNilIfReleaseSucceeds", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		mocks.setDefaults(testParams(api.ActionDeploySimple))
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("DoesNotApplyForRealIfDryRun", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		params := testParams(api.ActionDeploySimple)
+		params.DryRun = true
+		mocks.kubernetesClient.EXPECT().Apply(gomock.Any(), gomock.Any(), gomock.Any(), false).Return(errors.New("applied for real")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("ShiftsTrafficToCanaryInStepsAndPromotesToStable", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeployCanary)
+		params.Canary.Steps = []api.CanaryStepParams{{Weight: 10}, {Weight: 50}}
+		stableReplicas := int32(4)
+		trackData := map[api.ActionType]api.TemplateData{
+			api.ActionDeployCanary: {Name: "myapp", NameWithTrack: "myapp-canary", Namespace: "mynamespace", UseNginxIngress: true},
+			api.ActionDeployStable: {Name: "myapp", NameWithTrack: "myapp-stable", Namespace: "mynamespace", UseNginxIngress: true},
+		}
+		mocks.parametersClient.EXPECT().Init(a, a, a, a, a, a, a, a, a, a, a).Return(params, nil).Times(1)
+		mocks.kubernetesClient.EXPECT().GetDeployment(a, a, "myapp-stable").Return(&appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: &stableReplicas}}, nil).AnyTimes()
+		mocks.generatorService.EXPECT().GenerateTemplateData(a, a, a, a, a, a, a, a, a).DoAndReturn(func(params api.Params, currentReplicas int, gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy string) api.TemplateData {
+			return trackData[params.Action]
+		}).Times(2)
+		gomock.InOrder(
+			mocks.generatorService.EXPECT().GenerateCanaryTemplateData(a, 10).Return(api.TemplateData{}).Times(1),
+			mocks.generatorService.EXPECT().GenerateCanaryTemplateData(a, 50).Return(api.TemplateData{}).Times(1),
+		)
+		mocks.kubernetesClient.EXPECT().ScaleDeployment(a, a, "myapp-canary", 2).Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().WaitForDeploymentRollout(a, "mynamespace", "myapp-stable", a).Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().Delete(a, kubernetes.Ingresses, a, "myapp-canary", false).Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().ScaleDeployment(a, a, "myapp-canary", 0).Return(nil).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("ReturnsErrValidationIfProgressiveCanaryHasNoStableDeployment", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		params := testParams(api.ActionDeployCanary)
+		params.Canary.Steps = []api.CanaryStepParams{{Weight: 10}}
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrValidation))
+		assert.Contains(t, err.Error(), "run deploy-stable first")
+	})
+
+	t.Run("ReturnsErrRolloutIfCanaryDoesNotRollOutDuringStep", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeployCanary)
+		params.Canary.Steps = []api.CanaryStepParams{{Weight: 10}}
+		mocks.kubernetesClient.EXPECT().GetDeployment(a, a, "myapp-stable").Return(&appsv1.Deployment{}, nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().WaitForDeploymentRollout(a, a, a, a).Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().WaitForDeploymentRollout(a, a, a, a).Return(kubernetes.ErrRolloutFailed).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrRollout))
+	})
+
+	t.Run("QueriesPrometheusForCanaryAndStableTrackWithRenderedQuery", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeployCanary)
+		params.BuildVersion = "1.0.0"
+		params.Canary.Analysis = testCanaryAnalysis(2, nil)
+		mocks.kubernetesClient.EXPECT().GetDeployment(a, a, "myapp-stable").Return(testStableDeployment(), nil).AnyTimes()
+		mocks.prometheusClient.EXPECT().Query(a, "http://prometheus", `sum(rate(http_errors_total{app="myapp",track="canary",version="1.0.0",release="1"}[5m]))`).Return(0.2, nil).Times(1)
+		mocks.prometheusClient.EXPECT().Query(a, "http://prometheus", `sum(rate(http_errors_total{app="myapp",track="stable",version="0.9.0",release="0"}[5m]))`).Return(0.1, nil).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("RollsBackCanaryAndReturnsErrCanaryAnalysisIfCanaryExceedsMaxRatio", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeployCanary)
+		params.Canary.Analysis = testCanaryAnalysis(2, nil)
+		mocks.kubernetesClient.EXPECT().GetDeployment(a, a, "myapp-stable").Return(testStableDeployment(), nil).AnyTimes()
+		mocks.prometheusClient.EXPECT().Query(a, a, a).Return(0.3, nil).Times(1)
+		mocks.prometheusClient.EXPECT().Query(a, a, a).Return(0.1, nil).Times(1)
+		mocks.kubernetesClient.EXPECT().ScaleDeployment(a, a, "myapp-canary", 0).Return(nil).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrCanaryAnalysis))
+		assert.Contains(t, err.Error(), "more than 2 times the stable value")
+	})
+
+	t.Run("RollsBackCanaryIfCanaryExceedsMax", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		max := 0.5
+		params := testParams(api.ActionDeployCanary)
+		params.Canary.Analysis = testCanaryAnalysis(0, &max)
+		mocks.kubernetesClient.EXPECT().GetDeployment(a, a, "myapp-stable").Return(testStableDeployment(), nil).AnyTimes()
+		mocks.prometheusClient.EXPECT().Query(a, a, a).Return(0.6, nil).Times(1)
+		mocks.kubernetesClient.EXPECT().ScaleDeployment(a, a, "myapp-canary", 0).Return(nil).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrCanaryAnalysis))
+	})
+
+	t.Run("DoesNotRollBackCanaryIfRetrievingStableDeploymentFails", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeployCanary)
+		params.Canary.Analysis = testCanaryAnalysis(2, nil)
+		mocks.kubernetesClient.EXPECT().ScaleDeployment(a, a, "myapp-canary", 0).Return(errors.New("canary should not be rolled back")).AnyTimes()
+		gomock.InOrder(
+			mocks.kubernetesClient.EXPECT().GetDeployment(a, a, "myapp-stable").Return(testStableDeployment(), nil).Times(1),
+			mocks.kubernetesClient.EXPECT().GetDeployment(a, a, "myapp-stable").Return(nil, kubernetes.ErrTimeout).Times(1),
+		)
+		mocks.prometheusClient.EXPECT().Query(a, a, a).Return(0.0, errors.New("Query should not be called")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrApply))
+		assert.False(t, errors.Is(err, ErrCanaryAnalysis))
+		assert.True(t, errors.Is(err, kubernetes.ErrTimeout))
+	})
+
+	t.Run("DoesNotRollBackCanaryIfPrometheusQueryFails", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeployCanary)
+		params.Canary.Analysis = testCanaryAnalysis(2, nil)
+		mocks.generatorService.EXPECT().GenerateTemplateData(a, a, a, a, a, a, a, a, a).Return(api.TemplateData{Name: "myapp", NameWithTrack: "myapp-canary", Namespace: "mynamespace", UseNginxIngress: true}).AnyTimes()
+		mocks.kubernetesClient.EXPECT().GetDeployment(a, a, "myapp-stable").Return(testStableDeployment(), nil).AnyTimes()
+		mocks.prometheusClient.EXPECT().Query(a, a, a).Return(0.0, prometheus.ErrQueryFailed).Times(1)
+		mocks.kubernetesClient.EXPECT().Delete(a, kubernetes.Ingresses, a, "myapp-canary", a).Return(errors.New("canary ingress should not be deleted")).AnyTimes()
+		mocks.kubernetesClient.EXPECT().ScaleDeployment(a, a, "myapp-canary", 0).Return(errors.New("canary should not be rolled back")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrApply))
+		assert.False(t, errors.Is(err, ErrCanaryAnalysis))
+		assert.True(t, errors.Is(err, prometheus.ErrQueryFailed))
+	})
+
+	t.Run("EvaluatesMetricWithoutDataAgainInNextIteration", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeployCanary)
+		params.Canary.Analysis = testCanaryAnalysis(2, nil)
+		params.Canary.Analysis.Iterations = 2
+		mocks.kubernetesClient.EXPECT().GetDeployment(a, a, "myapp-stable").Return(testStableDeployment(), nil).AnyTimes()
+		gomock.InOrder(
+			mocks.prometheusClient.EXPECT().Query(a, a, a).Return(0.0, prometheus.ErrNoData).Times(1),
+			mocks.prometheusClient.EXPECT().Query(a, a, a).Return(0.1, nil).Times(2),
+		)
+		mocks.kubernetesClient.EXPECT().ScaleDeployment(a, a, "myapp-canary", 0).Return(errors.New("canary should not be rolled back")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("ReturnsErrorWithoutRollingBackCanaryIfMetricIsStillInconclusiveInLastIteration", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeployCanary)
+		params.Canary.Analysis = testCanaryAnalysis(2, nil)
+		params.Canary.Analysis.Iterations = 2
+		mocks.kubernetesClient.EXPECT().GetDeployment(a, a, "myapp-stable").Return(testStableDeployment(), nil).AnyTimes()
+		gomock.InOrder(
+			mocks.prometheusClient.EXPECT().Query(a, a, a).Return(0.0, prometheus.ErrNoData).Times(1),
+			mocks.prometheusClient.EXPECT().Query(a, a, a).Return(math.NaN(), nil).Times(1),
+		)
+		mocks.kubernetesClient.EXPECT().ScaleDeployment(a, a, "myapp-canary", 0).Return(errors.New("canary should not be rolled back")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrApply))
+		assert.False(t, errors.Is(err, ErrCanaryAnalysis))
+		assert.True(t, errors.Is(err, prometheus.ErrNoData))
+	})
+
+	t.Run("RollsBackProgressiveCanaryIfAnalysisFailsDuringStep", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeployCanary)
+		params.Canary.Steps = []api.CanaryStepParams{{Weight: 10}, {Weight: 50}}
+		params.Canary.Analysis = testCanaryAnalysis(2, nil)
+		mocks.kubernetesClient.EXPECT().GetDeployment(a, a, "myapp-stable").Return(testStableDeployment(), nil).AnyTimes()
+		mocks.generatorService.EXPECT().GenerateCanaryTemplateData(a, 10).Return(api.TemplateData{}).Times(1)
+		mocks.prometheusClient.EXPECT().Query(a, a, a).Return(1.0, nil).Times(1)
+		mocks.prometheusClient.EXPECT().Query(a, a, a).Return(0.1, nil).Times(1)
+		mocks.kubernetesClient.EXPECT().ScaleDeployment(a, a, "myapp-canary", 0).Return(nil).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrCanaryAnalysis))
+	})
+
+	t.Run("RestoresConfigsAndRollsBackDeploymentIfRolloutFailsWithRollbackOnFailure", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		params.RollbackOnFailure = true
+		previousConfigs := map[string]string{"config.yaml": "previous"}
+		previousSecrets := map[string][]byte{"password": []byte("previous")}
+		mocks.generatorService.EXPECT().GenerateTemplateData(a, a, a, a, a, a, a, a, a).Return(api.TemplateData{Name: "myapp", NameWithTrack: "myapp", Namespace: "mynamespace", ConfigmapName: "myapp-configs", SecretsName: "myapp-secrets"}).AnyTimes()
+		mocks.kubernetesClient.EXPECT().GetDeployment(a, "mynamespace", "myapp").Return(testDeploymentAtRevision(3), nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().GetConfigMap(a, "mynamespace", "myapp-configs").Return(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "myapp-configs"}, Data: previousConfigs}, nil).Times(1)
+		mocks.kubernetesClient.EXPECT().GetSecret(a, "mynamespace", "myapp-secrets").Return(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "myapp-secrets"}, Data: previousSecrets}, nil).Times(1)
+		gomock.InOrder(
+			mocks.kubernetesClient.EXPECT().WaitForDeploymentRollout(a, "mynamespace", "myapp", a).Return(kubernetes.ErrRolloutFailed).Times(1),
+			mocks.kubernetesClient.EXPECT().UpdateConfigMapData(a, "mynamespace", "myapp-configs", previousConfigs).Return(nil).Times(1),
+			mocks.kubernetesClient.EXPECT().UpdateSecretData(a, "mynamespace", "myapp-secrets", previousSecrets).Return(nil).Times(1),
+			mocks.kubernetesClient.EXPECT().RollbackDeployment(a, "mynamespace", "myapp", int64(3)).Return(nil).Times(1),
+			mocks.kubernetesClient.EXPECT().WaitForDeploymentRollout(a, "mynamespace", "myapp", a).Return(nil).Times(1),
+		)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrRollout))
+		assert.NotContains(t, err.Error(), "rolling back")
+	})
+
+	t.Run("RestoresSharedConfigMapIfRolloutFailsWithRollbackOnFailure", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		params.RollbackOnFailure = true
+		previousConfigs := map[string]string{"config.yaml": "previous"}
+		mocks.generatorService.EXPECT().GenerateTemplateData(a, a, a, a, a, a, a, a, a).Return(api.TemplateData{Name: "myapp", NameWithTrack: "myapp", Namespace: "mynamespace", ConfigmapName: "shared-configs", SecretsName: "myapp-secrets"}).AnyTimes()
+		mocks.kubernetesClient.EXPECT().GetDeployment(a, "mynamespace", "myapp").Return(testDeploymentAtRevision(3), nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().GetConfigMap(a, "mynamespace", "shared-configs").Return(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "shared-configs"}, Data: previousConfigs}, nil).Times(1)
+		mocks.kubernetesClient.EXPECT().GetConfigMap(a, "mynamespace", "myapp-configs").Return(nil, errors.New("GetConfigMap of myapp-configs should not be called")).AnyTimes()
+		mocks.kubernetesClient.EXPECT().WaitForDeploymentRollout(a, "mynamespace", "myapp", a).Return(kubernetes.ErrRolloutFailed).Times(1)
+		mocks.kubernetesClient.EXPECT().UpdateConfigMapData(a, "mynamespace", "shared-configs", previousConfigs).Return(nil).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrRollout))
+		assert.NotContains(t, err.Error(), "rolling back")
+	})
+
+	t.Run("OnlyRollsBackDeploymentRevisionWithImmutableConfigs", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		params.RollbackOnFailure = true
+		params.ImmutableConfigs = api.ImmutableConfigsParams{Enabled: true, Keep: 1}
+		mocks.generatorService.EXPECT().GenerateTemplateData(a, a, a, a, a, a, a, a, a).Return(api.TemplateData{Name: "myapp", NameWithTrack: "myapp", Namespace: "mynamespace", ConfigmapName: "myapp-configs-c", SecretsName: "myapp-secrets-b"}).AnyTimes()
+		mocks.kubernetesClient.EXPECT().GetDeployment(a, "mynamespace", "myapp").Return(testDeploymentAtRevision(3), nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().GetConfigMap(a, a, a).Return(nil, errors.New("GetConfigMap should not be called")).AnyTimes()
+		mocks.kubernetesClient.EXPECT().GetSecret(a, a, a).Return(nil, errors.New("GetSecret should not be called")).AnyTimes()
+		mocks.kubernetesClient.EXPECT().UpdateConfigMapData(a, a, a, a).Return(errors.New("UpdateConfigMapData should not be called")).AnyTimes()
+		mocks.kubernetesClient.EXPECT().UpdateSecretData(a, a, a, a).Return(errors.New("UpdateSecretData should not be called")).AnyTimes()
+		gomock.InOrder(
+			mocks.kubernetesClient.EXPECT().WaitForDeploymentRollout(a, "mynamespace", "myapp", a).Return(kubernetes.ErrRolloutFailed).Times(1),
+			mocks.kubernetesClient.EXPECT().RollbackDeployment(a, "mynamespace", "myapp", int64(3)).Return(nil).Times(1),
+			mocks.kubernetesClient.EXPECT().WaitForDeploymentRollout(a, "mynamespace", "myapp", a).Return(nil).Times(1),
+		)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrRollout))
+		assert.NotContains(t, err.Error(), "rolling back")
+	})
+
+	t.Run("DoesNotRollBackIfRollbackOnFailureIsDisabled", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		mocks.kubernetesClient.EXPECT().GetDeployment(a, "mynamespace", "myapp").Return(testDeploymentAtRevision(3), nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().WaitForDeploymentRollout(a, a, a, a).Return(kubernetes.ErrRolloutFailed).Times(1)
+		mocks.kubernetesClient.EXPECT().RollbackDeployment(a, a, a, a).Return(errors.New("rollback not expected")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrRollout))
+		assert.NotContains(t, err.Error(), "rollback not expected")
+	})
+
+	t.Run("ReturnsReleaseAndRollbackErrorIfRollbackFails", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		params.RollbackOnFailure = true
+		mocks.kubernetesClient.EXPECT().GetDeployment(a, "mynamespace", "myapp").Return(testDeploymentAtRevision(3), nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().Apply(a, a, a, false).Return(kubernetes.ErrRejected).Times(1)
+		mocks.kubernetesClient.EXPECT().RollbackDeployment(a, a, a, a).Return(kubernetes.ErrNotFound).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrApply))
+		assert.Contains(t, err.Error(), "rolling back to the previous release failed as well")
+	})
+
+	t.Run("SwitchesServiceToNewColorAndKeepsPreviousColorIfBlueGreenAutoPromotes", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testBlueGreenParams(api.ActionDeploySimple)
+		mocks.kubernetesClient.EXPECT().GetService(a, "mynamespace", "myapp").Return(testBlueGreenService("0", ""), nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().ListDeployments(a, "mynamespace", "app in (myapp),estafette.io/atomic-id in (0)").Return([]appsv1.Deployment{testBlueGreenDeployment("0", "")}, nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().PatchService(a, "mynamespace", "myapp", types.MergePatchType, []byte(`{"metadata":{"annotations":{"estafette.io/previous-atomic-id":"0"}}}`)).Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().PatchDeployment(a, "mynamespace", "myapp-0", types.MergePatchType, a).Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().DeleteByLabelSelector(a, a, a, "app in (myapp),estafette.io/atomic-id in (0)", a).Return(errors.New("previous color must be kept")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("RoutesPreviewHostsToNewColorWithoutSwitchingServiceIfBlueGreenDoesNotAutoPromote", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		falseValue := false
+		params := testBlueGreenParams(api.ActionDeploySimple)
+		params.BlueGreen.AutoPromote = &falseValue
+		params.BlueGreen.PreviewHosts = []string{"myapp-preview.example.com"}
+		mocks.kubernetesClient.EXPECT().GetService(a, "mynamespace", "myapp").Return(testBlueGreenService("0", ""), nil).AnyTimes()
+		mocks.generatorService.EXPECT().GenerateBlueGreenPreviewTemplateData(a, []string{"myapp-preview.example.com"}).Return(api.TemplateData{Name: "myapp-preview"}).Times(1)
+		mocks.builderService.EXPECT().GetAtomicUpdateServiceTemplate().Return(nil, errors.New("service must not be switched")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("PromotesMostRecentlyDeployedColor", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testBlueGreenParams(api.ActionPromote)
+		mocks.kubernetesClient.EXPECT().GetService(a, "mynamespace", "myapp").Return(testBlueGreenService("0", ""), nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().ListDeployments(a, "mynamespace", "app in (myapp),estafette.io/atomic-id").Return([]appsv1.Deployment{testBlueGreenDeployment("0", ""), testBlueGreenDeployment("1", "")}, nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().PatchService(a, "mynamespace", "myapp", types.MergePatchType, []byte(`{"metadata":{"annotations":{"estafette.io/previous-atomic-id":"0"}}}`)).Return(nil).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("SwitchesBackToPreviousColorWithoutRedeploying", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testBlueGreenParams(api.ActionSwitchBack)
+		mocks.kubernetesClient.EXPECT().GetService(a, "mynamespace", "myapp").Return(testBlueGreenService("2", "1"), nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().ListDeployments(a, "mynamespace", "app in (myapp),estafette.io/atomic-id in (1)").Return([]appsv1.Deployment{testBlueGreenDeployment("1", "2021-01-01T00:00:00Z")}, nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().ListDeployments(a, "mynamespace", "app in (myapp),estafette.io/atomic-id in (2)").Return([]appsv1.Deployment{testBlueGreenDeployment("2", "")}, nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().PatchService(a, "mynamespace", "myapp", types.MergePatchType, []byte(`{"metadata":{"annotations":{"estafette.io/previous-atomic-id":"2"}}}`)).Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().PatchDeployment(a, "mynamespace", "myapp-2", types.MergePatchType, a).Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().RemoveAnnotations(a, kubernetes.Deployments, "mynamespace", "myapp-1", "estafette.io/retire-after").Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().WaitForDeploymentRollout(a, a, a, a).Return(errors.New("nothing must be rolled out")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("ReturnsErrValidationIfSwitchingBackWithoutPreviousColor", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testBlueGreenParams(api.ActionSwitchBack)
+		mocks.kubernetesClient.EXPECT().GetService(a, "mynamespace", "myapp").Return(testBlueGreenService("2", ""), nil).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrValidation))
+	})
+
+	t.Run("RetiresColorsWhoseRetireAfterTimeHasPassed", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testBlueGreenParams(api.ActionPromote)
+		future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		mocks.kubernetesClient.EXPECT().GetService(a, "mynamespace", "myapp").Return(testBlueGreenService("2", "1"), nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().ListDeployments(a, "mynamespace", "app in (myapp),estafette.io/atomic-id").Return([]appsv1.Deployment{testBlueGreenDeployment("0", "2021-01-01T00:00:00Z"), testBlueGreenDeployment("1", future), testBlueGreenDeployment("2", "")}, nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().DeleteByLabelSelector(a, a, "mynamespace", "app in (myapp),estafette.io/atomic-id in (0)", false).Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().DeleteByLabelSelector(a, a, "mynamespace", "app in (myapp),estafette.io/atomic-id in (1)", false).Return(errors.New("color must be kept")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("RunsPreDeployHookJobBeforeApplyingManifests", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testHookParams()
+		hookData := api.TemplateData{Name: "myapp", JobName: "myapp-pre-deploy", Namespace: "mynamespace"}
+		mocks.generatorService.EXPECT().GenerateHookTemplateData(a, "pre-deploy", *params.Hooks.PreDeploy).Return(hookData).Times(1)
+		mocks.builderService.EXPECT().RenderTemplate(a, hookData, false).Return(*bytes.NewBufferString("kind: Job"), nil).Times(1)
+		gomock.InOrder(
+			mocks.kubernetesClient.EXPECT().Delete(a, kubernetes.Jobs, "mynamespace", "myapp-pre-deploy", false).Return(nil).Times(1),
+			mocks.kubernetesClient.EXPECT().Apply(a, "mynamespace", []byte("kind: Job"), false).Return(nil).Times(1),
+			mocks.kubernetesClient.EXPECT().WaitForJobCompletion(a, "mynamespace", "myapp-pre-deploy", 10*time.Minute).Return(nil).Times(1),
+			mocks.kubernetesClient.EXPECT().Apply(a, "mynamespace", []byte("kind: Deployment"), false).Return(nil).Times(1),
+		)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("ReturnsErrHookAndDoesNotApplyManifestsIfPreDeployHookFails", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testHookParams()
+		hookData := api.TemplateData{Name: "myapp", JobName: "myapp-pre-deploy", Namespace: "mynamespace"}
+		mocks.generatorService.EXPECT().GenerateHookTemplateData(a, "pre-deploy", a).Return(hookData).Times(1)
+		mocks.builderService.EXPECT().RenderTemplate(a, hookData, false).Return(*bytes.NewBufferString("kind: Job"), nil).Times(1)
+		mocks.kubernetesClient.EXPECT().WaitForJobCompletion(a, "mynamespace", "myapp-pre-deploy", a).Return(kubernetes.ErrJobFailed).Times(1)
+		mocks.kubernetesClient.EXPECT().Apply(a, a, []byte("kind: Deployment"), false).Return(errors.New("manifests applied after failed hook")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrHook))
+		assert.True(t, errors.Is(err, kubernetes.ErrJobFailed))
+		assert.NotContains(t, err.Error(), "manifests applied after failed hook")
+	})
+
+	t.Run("RollsBackIfPostDeployHookFailsWithRollbackOnFailure", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testHookParams()
+		params.Hooks.PostDeploy = params.Hooks.PreDeploy
+		params.Hooks.PreDeploy = nil
+		params.RollbackOnFailure = true
+		hookData := api.TemplateData{Name: "myapp", JobName: "myapp-post-deploy", Namespace: "mynamespace"}
+		mocks.generatorService.EXPECT().GenerateHookTemplateData(a, "post-deploy", a).Return(hookData).Times(1)
+		mocks.kubernetesClient.EXPECT().GetDeployment(a, "mynamespace", "myapp").Return(testDeploymentAtRevision(3), nil).AnyTimes()
+		gomock.InOrder(
+			mocks.kubernetesClient.EXPECT().WaitForDeploymentRollout(a, "mynamespace", "myapp", a).Return(nil).Times(1),
+			mocks.kubernetesClient.EXPECT().WaitForJobCompletion(a, "mynamespace", "myapp-post-deploy", a).Return(kubernetes.ErrTimeout).Times(1),
+			mocks.kubernetesClient.EXPECT().RollbackDeployment(a, "mynamespace", "myapp", int64(3)).Return(nil).Times(1),
+		)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrHook))
+		assert.True(t, errors.Is(err, kubernetes.ErrTimeout))
+	})
+
+	t.Run("WaitsForJobAndReturnsErrRolloutWithFailureReasonIfJobFails", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testJobParams()
+		params.WaitForCompletion = true
+		params.CompletionTimeout = "30m"
+		mocks.generatorService.EXPECT().GenerateTemplateData(a, a, a, a, a, a, a, a, a).Return(api.TemplateData{Name: "myjob", NameWithTrack: "myjob", JobName: "myjob", Namespace: "mynamespace"}).AnyTimes()
+		mocks.kubernetesClient.EXPECT().StreamJobLogs(a, "mynamespace", "myjob", "myjob").Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().WaitForJobCompletion(a, "mynamespace", "myjob", 30*time.Minute).Return(fmt.Errorf("%w: job \"myjob\" failed with reason BackoffLimitExceeded", kubernetes.ErrJobFailed)).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrRollout))
+		assert.True(t, errors.Is(err, kubernetes.ErrJobFailed))
+		assert.Contains(t, err.Error(), "BackoffLimitExceeded")
+	})
+
+	t.Run("DoesNotWaitForJobIfWaitForCompletionIsDisabled", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testJobParams()
+		mocks.kubernetesClient.EXPECT().WaitForJobCompletion(a, a, a, a).Return(kubernetes.ErrJobFailed).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("CreatesJobFromCronJobLabelledWithReleaseIDAndWaitsForIt", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testRunCronJobParams()
+		params.WaitForCompletion = true
+		params.CompletionTimeout = "30m"
+		mocks.generatorService.EXPECT().GenerateTemplateData(a, a, a, a, a, a, a, a, a).Return(api.TemplateData{Name: "myjob", NameWithTrack: "myjob", Namespace: "mynamespace", AppLabelSelector: "myjob"}).AnyTimes()
+		mocks.kubernetesClient.EXPECT().Apply(a, a, a, a).Return(errors.New("Apply should not be called")).AnyTimes()
+		gomock.InOrder(
+			mocks.kubernetesClient.EXPECT().CreateJobFromCronJob(a, "mynamespace", "myjob", "myjob-run-1", map[string]string{"app": "myjob", "estafette.io/release-id": "1"}).Return(nil).Times(1),
+			mocks.kubernetesClient.EXPECT().WaitForJobCompletion(a, "mynamespace", "myjob-run-1", 30*time.Minute).Return(nil).Times(1),
+		)
+		mocks.kubernetesClient.EXPECT().StreamJobLogs(a, "mynamespace", "myjob-run-1", "myjob").Return(nil).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("ReturnsErrRolloutIfJobCreatedFromCronJobFails", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testRunCronJobParams()
+		params.WaitForCompletion = true
+		params.CompletionTimeout = "30m"
+		mocks.kubernetesClient.EXPECT().WaitForJobCompletion(a, "mynamespace", "myapp-run-1", a).Return(kubernetes.ErrJobFailed).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrRollout))
+		assert.True(t, errors.Is(err, kubernetes.ErrJobFailed))
+	})
+
+	t.Run("DoesNotCreateJobFromCronJobIfDryRun", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testRunCronJobParams()
+		params.DryRun = true
+		mocks.kubernetesClient.EXPECT().CreateJobFromCronJob(a, a, a, a, a).Return(errors.New("CreateJobFromCronJob should not be called")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("OnlyPatchesSuspendFlagIfActionIsSuspendCronJob", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testRunCronJobParams()
+		params.Action = api.ActionSuspendCronJob
+		mocks.builderService.EXPECT().RenderTemplate(a, a, a).Return(bytes.Buffer{}, errors.New("RenderTemplate should not be called")).AnyTimes()
+		mocks.kubernetesClient.EXPECT().SuspendCronJob(a, "mynamespace", "myapp", true).Return(nil).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("ReturnsErrValidationIfCronJobToResumeDoesNotExist", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testRunCronJobParams()
+		params.Action = api.ActionResumeCronJob
+		mocks.kubernetesClient.EXPECT().SuspendCronJob(a, "mynamespace", "myapp", false).Return(kubernetes.ErrNotFound).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrValidation))
+	})
+
+	t.Run("KeepsDeployedCronJobSuspendedIfSuspendParamIsNotSet", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testRunCronJobParams()
+		params.Action = api.ActionDeploySimple
+		trueValue := true
+		mocks.kubernetesClient.EXPECT().GetCronJob(a, "mynamespace", "myapp").Return(&batchv1.CronJob{Spec: batchv1.CronJobSpec{Suspend: &trueValue}}, nil).Times(1)
+		mocks.builderService.EXPECT().RenderTemplate(a, gomock.AssignableToTypeOf(api.TemplateData{}), true).DoAndReturn(func(tmpl *template.Template, data api.TemplateData, validate bool) (bytes.Buffer, error) {
+			assert.True(t, data.Suspend)
+			return *bytes.NewBufferString("kind: CronJob"), nil
+		}).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("WaitsForDaemonSetRolloutIfKindIsDaemonSet", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		params.Kind = api.KindDaemonSet
+		mocks.kubernetesClient.EXPECT().WaitForDaemonSetRollout(a, "mynamespace", "myapp", a).Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().WaitForDeploymentRollout(a, a, a, a).Return(errors.New("WaitForDeploymentRollout should not be called")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("ReturnsErrRolloutIfDaemonSetDoesNotRollOut", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		params.Kind = api.KindDaemonSet
+		mocks.kubernetesClient.EXPECT().WaitForDaemonSetRollout(a, a, a, a).Return(kubernetes.ErrTimeout).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrRollout))
+	})
+
+	t.Run("ReturnsErrValidationIfSharedSecretKeysAreRemoved", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testSharedSecretParams()
+		mocks.generatorService.EXPECT().GenerateTemplateData(a, a, a, a, a, a, a, a, a).Return(testSharedSecretTemplateData()).AnyTimes()
+		mocks.kubernetesClient.EXPECT().GetSecret(a, "mynamespace", "shared-credentials").Return(testSharedSecret(map[string]string{"password": "secret", "username": "admin"}), nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().Apply(a, a, a, false).Return(errors.New("Apply should not be called")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.True(t, errors.Is(err, ErrValidation))
+	})
+
+	t.Run("RemovesSharedSecretKeysIfAllowKeyRemovalIsSet", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testSharedSecretParams()
+		params.Shared.AllowKeyRemoval = true
+		mocks.generatorService.EXPECT().GenerateTemplateData(a, a, a, a, a, a, a, a, a).Return(testSharedSecretTemplateData()).AnyTimes()
+		mocks.kubernetesClient.EXPECT().GetSecret(a, "mynamespace", "shared-credentials").Return(testSharedSecret(map[string]string{"password": "secret", "username": "admin"}), nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().Apply(a, a, a, false).Return(nil).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("RestartsConsumingDeploymentsIfSharedSecretChanges", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testSharedSecretParams()
+		mocks.generatorService.EXPECT().GenerateTemplateData(a, a, a, a, a, a, a, a, a).Return(testSharedSecretTemplateData()).AnyTimes()
+		mocks.kubernetesClient.EXPECT().GetSecret(a, "mynamespace", "shared-credentials").Return(testSharedSecret(map[string]string{"password": "oldsecret"}), nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().RestartDeployment(a, "mynamespace", "myapp").Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().RestartDeployment(a, "mynamespace", "myotherapp").Return(nil).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("DoesNotRestartConsumingDeploymentsIfSharedSecretIsUnchanged", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testSharedSecretParams()
+		mocks.generatorService.EXPECT().GenerateTemplateData(a, a, a, a, a, a, a, a, a).Return(testSharedSecretTemplateData()).AnyTimes()
+		mocks.kubernetesClient.EXPECT().GetSecret(a, "mynamespace", "shared-credentials").Return(testSharedSecret(map[string]string{"password": "secret"}), nil).AnyTimes()
+		mocks.kubernetesClient.EXPECT().RestartDeployment(a, a, a).Return(errors.New("RestartDeployment should not be called")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("PrunesImmutableConfigsOfEarlierReleasesBeyondKeep", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		params.ImmutableConfigs = api.ImmutableConfigsParams{Enabled: true, Keep: 1}
+		mocks.generatorService.EXPECT().GenerateTemplateData(a, a, a, a, a, a, a, a, a).Return(api.TemplateData{Name: "myapp", NameWithTrack: "myapp", Namespace: "mynamespace", AppLabelSelector: "myapp", ConfigmapName: "myapp-configs-c", SecretsName: "myapp-secrets-b"}).AnyTimes()
+		mocks.kubernetesClient.EXPECT().ListConfigMaps(a, "mynamespace", "estafette.io/immutable-configs-for=myapp").Return([]corev1.ConfigMap{testConfigMap("myapp-configs-a"), testConfigMap("myapp-configs-b"), testConfigMap("myapp-configs-c"), testConfigMap("myapp-configs-d")}, nil).Times(1)
+		mocks.kubernetesClient.EXPECT().ListSecrets(a, "mynamespace", "estafette.io/immutable-configs-for=myapp").Return([]corev1.Secret{{ObjectMeta: metav1.ObjectMeta{Name: "myapp-secrets-a"}}, {ObjectMeta: metav1.ObjectMeta{Name: "myapp-secrets-b"}}}, nil).Times(1)
+		mocks.kubernetesClient.EXPECT().Delete(a, kubernetes.ConfigMaps, "mynamespace", "myapp-configs-a", false).Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().Delete(a, kubernetes.ConfigMaps, "mynamespace", "myapp-configs-b", false).Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().Delete(a, kubernetes.ConfigMaps, "mynamespace", "myapp-configs-c", a).Return(errors.New("Delete of the current configmap should not be called")).AnyTimes()
+		mocks.kubernetesClient.EXPECT().Delete(a, kubernetes.ConfigMaps, "mynamespace", "myapp-configs-d", a).Return(errors.New("Delete of the most recent earlier configmap should not be called")).AnyTimes()
+		mocks.kubernetesClient.EXPECT().Delete(a, kubernetes.Secrets, "mynamespace", "myapp-secrets-a", a).Return(errors.New("Delete of the most recent earlier secret should not be called")).AnyTimes()
+		mocks.kubernetesClient.EXPECT().Delete(a, kubernetes.Secrets, "mynamespace", "myapp-secrets-b", a).Return(errors.New("Delete of the current secret should not be called")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("DoesNotPruneConfigsIfImmutableConfigsIsDisabled", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		mocks.kubernetesClient.EXPECT().ListConfigMaps(a, a, a).Return(nil, errors.New("ListConfigMaps should not be called")).AnyTimes()
+		mocks.kubernetesClient.EXPECT().ListSecrets(a, a, a).Return(nil, errors.New("ListSecrets should not be called")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("PrunesObjectsInInventoryThatAreNoLongerRenderedAndStoresNewInventory", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		trueValue := true
+		params.Inventory.Prune = &trueValue
+		rendered := []kubernetes.ObjectReference{{Group: "apps", Kind: "Deployment", Name: "myapp"}, {Kind: "Service", Name: "myapp"}}
+		mocks.kubernetesClient.EXPECT().ManifestObjects(a, "app=myapp,!estafette.io/immutable-configs-for").Return(rendered, nil).Times(1)
+		mocks.kubernetesClient.EXPECT().GetConfigMap(a, "mynamespace", "myapp-inventory").Return(testInventory("Deployment.apps/myapp\nHorizontalPodAutoscaler.autoscaling/myapp\nService/myapp"), nil).Times(1)
+		mocks.kubernetesClient.EXPECT().Prune(a, "mynamespace", []kubernetes.ObjectReference{{Group: "autoscaling", Kind: "HorizontalPodAutoscaler", Name: "myapp"}}, "app=myapp,!estafette.io/immutable-configs-for", false).Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().Apply(a, "mynamespace", []byte(`{"apiVersion":"v1","data":{"objects":"Deployment.apps/myapp\nService/myapp"},"kind":"ConfigMap","metadata":{"labels":{"type":"inventory"},"name":"myapp-inventory","namespace":"mynamespace"}}`), false).Return(nil).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("LeavesConfigsAndSecretsOfImmutableConfigsOutOfInventoryPruning", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		trueValue := true
+		params.Inventory.Prune = &trueValue
+		params.ImmutableConfigs = api.ImmutableConfigsParams{Enabled: true, Keep: 1}
+		mocks.generatorService.EXPECT().GenerateTemplateData(a, a, a, a, a, a, a, a, a).Return(api.TemplateData{Name: "myapp", NameWithTrack: "myapp", Namespace: "mynamespace", AppLabelSelector: "myapp", ConfigmapName: "myapp-configs-b", SecretsName: "myapp-secrets-b"}).AnyTimes()
+		mocks.kubernetesClient.EXPECT().ManifestObjects(a, "app=myapp,!estafette.io/immutable-configs-for").Return([]kubernetes.ObjectReference{{Group: "apps", Kind: "Deployment", Name: "myapp"}}, nil).Times(1)
+		mocks.kubernetesClient.EXPECT().GetConfigMap(a, "mynamespace", "myapp-inventory").Return(testInventory("Deployment.apps/myapp"), nil).Times(1)
+		mocks.kubernetesClient.EXPECT().Prune(a, a, a, a, a).Return(errors.New("Prune should not be called")).AnyTimes()
+		mocks.kubernetesClient.EXPECT().ListConfigMaps(a, "mynamespace", "estafette.io/immutable-configs-for=myapp").Return([]corev1.ConfigMap{testConfigMap("myapp-configs-a"), testConfigMap("myapp-configs-b")}, nil).Times(1)
+		mocks.kubernetesClient.EXPECT().Delete(a, kubernetes.ConfigMaps, "mynamespace", "myapp-configs-a", a).Return(errors.New("Delete of the configmap immutableConfigs.keep keeps should not be called")).AnyTimes()
+		mocks.kubernetesClient.EXPECT().Apply(a, "mynamespace", []byte(`{"apiVersion":"v1","data":{"objects":"Deployment.apps/myapp"},"kind":"ConfigMap","metadata":{"labels":{"type":"inventory"},"name":"myapp-inventory","namespace":"mynamespace"}}`), false).Return(nil).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("OnlyPerformsDryrunOfPruningIfDryRun", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		params.DryRun = true
+		trueValue := true
+		params.Inventory.Prune = &trueValue
+		mocks.kubernetesClient.EXPECT().ManifestObjects(a, a).Return([]kubernetes.ObjectReference{{Group: "apps", Kind: "Deployment", Name: "myapp"}}, nil).Times(1)
+		mocks.kubernetesClient.EXPECT().GetConfigMap(a, "mynamespace", "myapp-inventory").Return(testInventory("Deployment.apps/myapp\nService/myapp"), nil).Times(1)
+		mocks.kubernetesClient.EXPECT().Prune(a, "mynamespace", []kubernetes.ObjectReference{{Kind: "Service", Name: "myapp"}}, "app=myapp,!estafette.io/immutable-configs-for", true).Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().Prune(a, a, a, a, false).Return(errors.New("Prune should not be called for real")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("DoesNotPruneIfInventoryPruneIsDisabled", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		falseValue := false
+		params.Inventory.Prune = &falseValue
+		mocks.kubernetesClient.EXPECT().ManifestObjects(a, a).Return(nil, errors.New("ManifestObjects should not be called")).AnyTimes()
+		mocks.kubernetesClient.EXPECT().Prune(a, a, a, a, a).Return(errors.New("Prune should not be called")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("DeletesHorizontalPodAutoscalerIfAutoscaleTypeIsKeda", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		trueValue := true
+		params.Autoscale.Enabled = &trueValue
+		params.Autoscale.Type = api.AutoscalerTypeKeda
+		mocks.kubernetesClient.EXPECT().Delete(a, kubernetes.HorizontalPodAutoscalers, "mynamespace", "myapp", false).Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().Delete(a, kubernetes.ScaledObjects, "mynamespace", "myapp", a).Return(errors.New("Delete ScaledObject should not be called")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("DeletesScaledObjectIfAutoscaleTypeIsHPA", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		trueValue := true
+		params.Autoscale.Enabled = &trueValue
+		params.Autoscale.Type = api.AutoscalerTypeHPA
+		mocks.kubernetesClient.EXPECT().Delete(a, kubernetes.ScaledObjects, "mynamespace", "myapp", false).Return(nil).Times(1)
+		mocks.kubernetesClient.EXPECT().Delete(a, kubernetes.HorizontalPodAutoscalers, "mynamespace", "myapp", a).Return(errors.New("Delete HorizontalPodAutoscaler should not be called")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("IgnoresScaledObjectCleanupIfKedaIsNotInstalled", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		mocks.kubernetesClient.EXPECT().Delete(a, kubernetes.ScaledObjects, a, a, a).Return(fmt.Errorf("Can't resolve scaledobjects.keda.sh: %w", kubernetes.ErrUnknownResource)).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("SetsReplicasToMinReplicasIfKedaScaledDeploymentToZeroAndHPATakesOver", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		trueValue := true
+		params.Autoscale.Enabled = &trueValue
+		params.Autoscale.Type = api.AutoscalerTypeHPA
+		params.Autoscale.MinReplicas = 3
+		zeroReplicas := int32(0)
+		mocks.kubernetesClient.EXPECT().GetDeployment(a, "mynamespace", "myapp").Return(&appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: &zeroReplicas}}, nil).AnyTimes()
+		mocks.generatorService.EXPECT().GenerateTemplateData(a, 3, a, a, a, a, a, a, a).Return(api.TemplateData{Name: "myapp", NameWithTrack: "myapp", Namespace: "mynamespace", AppLabelSelector: "myapp"}).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		err := runTestService(ctx, service)
+
+		assert.Nil(t, err)
+	})
+}
+
+func TestRender(t *testing.T) {
+
+	t.Run("RendersManifestWithoutAccessingGCPOrTheCluster", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		mocks.parametersClient.EXPECT().Init(a, a, a, a, a, a, a, a, a, a, a).Return(params, errors.New("Init should not be called")).AnyTimes()
+		mocks.gcpClient.EXPECT().LoadGKEClusterKubeConfig(a, a).Return("", errors.New("LoadGKEClusterKubeConfig should not be called")).AnyTimes()
+		mocks.kubernetesClient.EXPECT().Init(a, a).Return(errors.New("Init should not be called")).AnyTimes()
+		mocks.generatorService.EXPECT().GenerateTemplateData(a, -1, a, a, a, a, a, a, a).Return(api.TemplateData{Name: "myapp", Namespace: "mynamespace"}).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		manifest, err := service.Render(ctx, &api.GKECredentials{}, "production", "", "github.com", "estafette", "myapp", "myapp", "1.0.0", "deploy-simple", "1", "main", "abc", "me")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "kind: Deployment", string(manifest))
+	})
+
+	t.Run("ReturnsErrValidationIfManifestIsInvalidForKubernetesVersionParam", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		params := testParams(api.ActionDeploySimple)
+		params.KubernetesVersion = "1.25"
+		mocks.validatorService.EXPECT().ValidateManifest([]byte("kind: Deployment"), "1.25").Return([]validator.ManifestError{{Document: 1, Kind: "Deployment", Name: "myapp", Field: "spec.replicass", Message: "unknown field"}}, nil)
+		mocks.setDefaults(params)
+
+		// act
+		_, err := service.Render(ctx, &api.GKECredentials{}, "production", "", "github.com", "estafette", "myapp", "myapp", "1.0.0", "deploy-simple", "1", "main", "abc", "me")
+
+		assert.True(t, errors.Is(err, ErrValidation))
+	})
+
+	t.Run("DoesNotValidateManifestWithoutKubernetesVersionParam", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		mocks.validatorService.EXPECT().ValidateManifest(a, a).Return(nil, errors.New("ValidateManifest should not be called")).AnyTimes()
+		mocks.setDefaults(params)
+
+		// act
+		_, err := service.Render(ctx, &api.GKECredentials{}, "production", "", "github.com", "estafette", "myapp", "myapp", "1.0.0", "deploy-simple", "1", "main", "abc", "me")
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("RendersAPIVersionsForKubernetesVersionParam", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		params.KubernetesVersion = "1.20"
+		apiVersions := api.APIVersionsData{PodDisruptionBudget: "policy/v1beta1", CronJob: "batch/v1beta1", HorizontalPodAutoscaler: "autoscaling/v2beta2"}
+		mocks.builderService.EXPECT().GetAPIVersions("1.20").Return(apiVersions).Times(1)
+		mocks.builderService.EXPECT().RenderTemplate(a, a, a).DoAndReturn(func(tmpl *template.Template, data api.TemplateData, logTemplate bool) (bytes.Buffer, error) {
+			assert.Equal(t, apiVersions, data.APIVersions)
+			return *bytes.NewBufferString("kind: Deployment"), nil
+		}).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		_, err := service.Render(ctx, &api.GKECredentials{}, "production", "", "github.com", "estafette", "myapp", "myapp", "1.0.0", "deploy-simple", "1", "main", "abc", "me")
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("ReturnsErrValidationIfParametersAreInvalid", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionDeploySimple)
+		mocks.parametersClient.EXPECT().InitOffline(a, a, a, a, a, a, a, a, a, a, a).Return(params, errors.New("Not all valid fields are set")).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		_, err := service.Render(ctx, &api.GKECredentials{}, "production", "", "github.com", "estafette", "myapp", "myapp", "1.0.0", "deploy-simple", "1", "main", "abc", "me")
+
+		assert.True(t, errors.Is(err, ErrValidation))
+	})
+
+	t.Run("ReturnsEmptyManifestIfActionDoesNotApplyManifests", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, mocks := newTestService(t)
+		a := gomock.Any()
+		params := testParams(api.ActionRestartSimple)
+		mocks.builderService.EXPECT().BuildTemplates(a, a).Return(nil, nil).Times(1)
+		mocks.setDefaults(params)
+
+		// act
+		manifest, err := service.Render(ctx, &api.GKECredentials{}, "production", "", "github.com", "estafette", "myapp", "myapp", "1.0.0", "restart-simple", "1", "main", "abc", "me")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(manifest))
+	})
+}
+
+type testMocks struct {
+	parametersClient *parameters.MockClient
+	gcpClient        *gcp.MockClient
+	kubernetesClient *kubernetes.MockClient
+	builderService   *builder.MockService
+	generatorService *generator.MockService
+	validatorService *validator.MockService
+	prometheusClient *prometheus.MockClient
+}
+
+func newTestService(t *testing.T) (*service, testMocks) {
+	ctrl := gomock.NewController(t)
+
+	mocks := testMocks{
+		parametersClient: parameters.NewMockClient(ctrl),
+		gcpClient:        gcp.NewMockClient(ctrl),
+		kubernetesClient: kubernetes.NewMockClient(ctrl),
+		builderService:   builder.NewMockService(ctrl),
+		generatorService: generator.NewMockService(ctrl),
+		validatorService: validator.NewMockService(ctrl),
+		prometheusClient: prometheus.NewMockClient(ctrl),
+	}
+
+	return &service{
+		parametersClient:  mocks.parametersClient,
+		gcpClient:         mocks.gcpClient,
+		kubernetesClient:  mocks.kubernetesClient,
+		builderService:    mocks.builderService,
+		generatorService:  mocks.generatorService,
+		validatorService:  mocks.validatorService,
+		prometheusClient:  mocks.prometheusClient,
+		manifestDirectory: t.TempDir(),
+		logsGracePeriod:   time.Second,
+	}, mocks
+}
+
+// setDefaults lets every call succeed; expectations set before calling it take precedence
+func (m testMocks) setDefaults(params api.Params) {
+	a := gomock.Any()
+
+	m.parametersClient.EXPECT().Init(a, a, a, a, a, a, a, a, a, a, a).Return(params, nil).AnyTimes()
+	m.parametersClient.EXPECT().InitOffline(a, a, a, a, a, a, a, a, a, a, a).Return(params, nil).AnyTimes()
+
+	m.gcpClient.EXPECT().LoadGKEClusterKubeConfig(a, a).Return("gke_project_europe-west1_cluster", nil).AnyTimes()
+	m.gcpClient.EXPECT().DeployGoogleCloudEndpoints(a, a).Return(nil).AnyTimes()
+	m.gcpClient.EXPECT().GetGKECluster(a, a, a, a).Return(&containerv1.Cluster{CurrentMasterVersion: "1.21.14-gke.2100"}, nil).AnyTimes()
+
+	m.validatorService.EXPECT().ValidateManifest(a, a).Return([]validator.ManifestError{}, nil).AnyTimes()
+
+	m.builderService.EXPECT().BuildTemplates(a, a).Return(template.New("kubernetes.yaml"), nil).AnyTimes()
+	m.builderService.EXPECT().RenderConfig(a).Return(map[string]string{}, nil).AnyTimes()
+	m.builderService.EXPECT().RenderTemplate(a, a, a).Return(*bytes.NewBufferString("kind: Deployment"), nil).AnyTimes()
+	m.builderService.EXPECT().GetAtomicUpdateServiceTemplate().Return(template.New("service.yaml"), nil).AnyTimes()
+	m.builderService.EXPECT().GetProgressiveCanaryTemplate().Return(template.New("canary.yaml"), nil).AnyTimes()
+	m.builderService.EXPECT().GetBlueGreenPreviewTemplate().Return(template.New("preview.yaml"), nil).AnyTimes()
+	m.builderService.EXPECT().GetHookTemplate(a).Return(template.New("hook.yaml"), nil).AnyTimes()
+	m.builderService.EXPECT().GetAPIVersions(a).Return(api.APIVersionsData{PodDisruptionBudget: "policy/v1", CronJob: "batch/v1", HorizontalPodAutoscaler: "autoscaling/v2"}).AnyTimes()
+
+	m.generatorService.EXPECT().GenerateTemplateData(a, a, a, a, a, a, a, a, a).Return(api.TemplateData{Name: "myapp", NameWithTrack: "myapp", Namespace: "mynamespace", AppLabelSelector: "myapp"}).AnyTimes()
+	m.generatorService.EXPECT().GenerateCanaryTemplateData(a, a).Return(api.TemplateData{Name: "myapp-canary", Namespace: "mynamespace"}).AnyTimes()
+	m.generatorService.EXPECT().GenerateBlueGreenPreviewTemplateData(a, a).Return(api.TemplateData{Name: "myapp-preview", Namespace: "mynamespace"}).AnyTimes()
+	m.generatorService.EXPECT().GenerateHookTemplateData(a, a, a).Return(api.TemplateData{Name: "myapp", JobName: "myapp-hook", Namespace: "mynamespace"}).AnyTimes()
+
+	m.kubernetesClient.EXPECT().Init(a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().Apply(a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().Diff(a, a, a).Return("", nil).AnyTimes()
+	m.kubernetesClient.EXPECT().Delete(a, a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().DeleteByLabelSelector(a, a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().WaitForDeletion(a, a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().RemoveAnnotations(a, a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().RemoveAnnotations(a, a, a, a, a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().GetService(a, a, a).Return(nil, kubernetes.ErrNotFound).AnyTimes()
+	m.kubernetesClient.EXPECT().PatchService(a, a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().GetDeployment(a, a, a).Return(nil, kubernetes.ErrNotFound).AnyTimes()
+	m.kubernetesClient.EXPECT().ListDeployments(a, a, a).Return(nil, nil).AnyTimes()
+	m.kubernetesClient.EXPECT().PatchDeployment(a, a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().ScaleDeployment(a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().RestartDeployment(a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().WaitForDeploymentRollout(a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().WaitForStatefulSetRollout(a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().WaitForDaemonSetRollout(a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().WaitForJobCompletion(a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().StreamJobLogs(a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().CreateJobFromCronJob(a, a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().GetCronJob(a, a, a).Return(nil, kubernetes.ErrNotFound).AnyTimes()
+	m.kubernetesClient.EXPECT().SuspendCronJob(a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().GetPodDisruptionBudget(a, a, a).Return(nil, kubernetes.ErrNotFound).AnyTimes()
+	m.kubernetesClient.EXPECT().GetIngress(a, a, a).Return(nil, kubernetes.ErrNotFound).AnyTimes()
+
+	m.kubernetesClient.EXPECT().RollbackDeployment(a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().GetConfigMap(a, a, a).Return(nil, kubernetes.ErrNotFound).AnyTimes()
+	m.kubernetesClient.EXPECT().UpdateConfigMapData(a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().ListConfigMaps(a, a, a).Return(nil, nil).AnyTimes()
+	m.kubernetesClient.EXPECT().GetSecret(a, a, a).Return(nil, kubernetes.ErrNotFound).AnyTimes()
+	m.kubernetesClient.EXPECT().UpdateSecretData(a, a, a, a).Return(nil).AnyTimes()
+	m.kubernetesClient.EXPECT().ListSecrets(a, a, a).Return(nil, nil).AnyTimes()
+	m.kubernetesClient.EXPECT().ManifestObjects(a, a).Return(nil, nil).AnyTimes()
+	m.kubernetesClient.EXPECT().Prune(a, a, a, a, a).Return(nil).AnyTimes()
+
+	m.prometheusClient.EXPECT().Query(a, a, a).Return(0.0, nil).AnyTimes()
+}
+
+func testInventory(objects string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-inventory"},
+		Data:       map[string]string{"objects": objects},
+	}
+}
+
+func testParams(action api.ActionType) api.Params {
+	falseValue := false
+
+	return api.Params{
+		Action:           action,
+		Kind:             api.KindDeployment,
+		App:              "myapp",
+		Namespace:        "mynamespace",
+		Visibility:       api.VisibilityPrivate,
+		WorkloadIdentity: &falseValue,
+		DNS: api.DNSParams{
+			UseExternalDNS:                  &falseValue,
+			UseCloudflareEstafetteExtension: &falseValue,
+		},
+	}
+}
+
+func testCanaryAnalysis(maxRatio float64, max *float64) api.CanaryAnalysisParams {
+	return api.CanaryAnalysisParams{
+		PrometheusURL: "http://prometheus",
+		Iterations:    1,
+		Metrics: []api.CanaryMetricParams{
+			{
+				Name:     "errors",
+				Query:    `sum(rate(http_errors_total{app="{{.App}}",track="{{.Track}}",version="{{.Version}}",release="{{.ReleaseID}}"}[5m]))`,
+				MaxRatio: maxRatio,
+				Max:      max,
+			},
+		},
+	}
+}
+
+func testDeploymentAtRevision(revision int) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				"deployment.kubernetes.io/revision": strconv.Itoa(revision),
+			},
+		},
+	}
+}
+
+func testJobParams() api.Params {
+	params := testParams(api.ActionDeploySimple)
+	params.Kind = api.KindJob
+	params.App = "myjob"
+
+	return params
+}
+
+func testSharedSecretParams() api.Params {
+	params := testParams(api.ActionDeploySimple)
+	params.Kind = api.KindSecret
+	params.Secrets.Keys = map[string]interface{}{
+		"password": base64.StdEncoding.EncodeToString([]byte("secret")),
+	}
+	params.Shared.Name = "shared-credentials"
+	params.Shared.RestartDeployments = []string{"myapp", "myotherapp"}
+
+	return params
+}
+
+func testSharedSecretTemplateData() api.TemplateData {
+	return api.TemplateData{
+		Name:             "shared-credentials",
+		NameWithTrack:    "shared-credentials",
+		Namespace:        "mynamespace",
+		AppLabelSelector: "shared-credentials",
+		Secrets: map[string]interface{}{
+			"password": base64.StdEncoding.EncodeToString([]byte("secret")),
+		},
+		SecretsName: "shared-credentials",
+	}
+}
+
+func testSharedSecret(data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared-credentials"},
+		Data:       map[string][]byte{},
+	}
+	for key, value := range data {
+		secret.Data[key] = []byte(value)
+	}
+
+	return secret
+}
+
+func testConfigMap(name string) corev1.ConfigMap {
+	return corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	}
+}
+
+func testRunCronJobParams() api.Params {
+	params := testParams(api.ActionRunCronJob)
+	params.Kind = api.KindCronJob
+
+	return params
+}
+
+func testHookParams() api.Params {
+	params := testParams(api.ActionDeploySimple)
+	params.Hooks.PreDeploy = &api.HookParams{
+		Command: []string{"/migrate"},
+		Timeout: "10m",
+	}
+
+	return params
+}
+
+func testBlueGreenParams(action api.ActionType) api.Params {
+	params := testParams(action)
+	params.StrategyType = api.StrategyTypeBlueGreen
+	params.AtomicID = "1"
+	params.BlueGreen.KeepPrevious = "1h"
+
+	return params
+}
+
+func testBlueGreenService(activeAtomicID, previousAtomicID string) *corev1.Service {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", Annotations: map[string]string{}},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "myapp", "estafette.io/atomic-id": activeAtomicID}},
+	}
+	if previousAtomicID != "" {
+		service.Annotations["estafette.io/previous-atomic-id"] = previousAtomicID
+	}
+
+	return service
+}
+
+func testBlueGreenDeployment(atomicID, retireAfter string) appsv1.Deployment {
+	deployment := appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "myapp-" + atomicID,
+			Labels:      map[string]string{"app": "myapp", "estafette.io/atomic-id": atomicID},
+			Annotations: map[string]string{},
+		},
+	}
+	if retireAfter != "" {
+		deployment.Annotations["estafette.io/retire-after"] = retireAfter
+	}
+
+	return deployment
+}
+
+func testStableDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"version":                 "0.9.0",
+						"estafette.io/release-id": "0",
+					},
+				},
+			},
+		},
+	}
+}
+
+func runTestService(ctx context.Context, s *service) error {
+	return s.Run(ctx, &api.GKECredentials{}, "production", "", "github.com", "estafette", "myapp", "myapp", "1.0.0", "", "1", "main", "abc", "me")
+}
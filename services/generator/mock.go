@@ -62,6 +62,48 @@ func (mr *MockServiceMockRecorder) BuildSidecar(sidecar, params interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildSidecar", reflect.TypeOf((*MockService)(nil).BuildSidecar), sidecar, params)
 }
 
+// GenerateBlueGreenPreviewTemplateData mocks base method.
+func (m *MockService) GenerateBlueGreenPreviewTemplateData(data api.TemplateData, hosts []string) api.TemplateData {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateBlueGreenPreviewTemplateData", data, hosts)
+	ret0, _ := ret[0].(api.TemplateData)
+	return ret0
+}
+
+// GenerateBlueGreenPreviewTemplateData indicates an expected call of GenerateBlueGreenPreviewTemplateData.
+func (mr *MockServiceMockRecorder) GenerateBlueGreenPreviewTemplateData(data, hosts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateBlueGreenPreviewTemplateData", reflect.TypeOf((*MockService)(nil).GenerateBlueGreenPreviewTemplateData), data, hosts)
+}
+
+// GenerateCanaryTemplateData mocks base method.
+func (m *MockService) GenerateCanaryTemplateData(data api.TemplateData, weight int) api.TemplateData {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateCanaryTemplateData", data, weight)
+	ret0, _ := ret[0].(api.TemplateData)
+	return ret0
+}
+
+// GenerateCanaryTemplateData indicates an expected call of GenerateCanaryTemplateData.
+func (mr *MockServiceMockRecorder) GenerateCanaryTemplateData(data, weight interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateCanaryTemplateData", reflect.TypeOf((*MockService)(nil).GenerateCanaryTemplateData), data, weight)
+}
+
+// GenerateHookTemplateData mocks base method.
+func (m *MockService) GenerateHookTemplateData(data api.TemplateData, hook string, hookParams api.HookParams) api.TemplateData {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateHookTemplateData", data, hook, hookParams)
+	ret0, _ := ret[0].(api.TemplateData)
+	return ret0
+}
+
+// GenerateHookTemplateData indicates an expected call of GenerateHookTemplateData.
+func (mr *MockServiceMockRecorder) GenerateHookTemplateData(data, hook, hookParams interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateHookTemplateData", reflect.TypeOf((*MockService)(nil).GenerateHookTemplateData), data, hook, hookParams)
+}
+
 // GenerateTemplateData mocks base method.
 func (m *MockService) GenerateTemplateData(params api.Params, currentReplicas int, gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy string) api.TemplateData {
 	m.ctrl.T.Helper()
@@ -3,8 +3,11 @@ package generator
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
@@ -16,6 +19,9 @@ import (
 //go:generate mockgen -package=generator -destination ./mock.go -source=service.go
 type Service interface {
 	GenerateTemplateData(params api.Params, currentReplicas int, gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy string) api.TemplateData
+	GenerateCanaryTemplateData(data api.TemplateData, weight int) api.TemplateData
+	GenerateBlueGreenPreviewTemplateData(data api.TemplateData, hosts []string) api.TemplateData
+	GenerateHookTemplateData(data api.TemplateData, hook string, hookParams api.HookParams) api.TemplateData
 	BuildSidecar(sidecar *api.SidecarParams, params api.Params) api.SidecarData
 	AddEnvironmentVariableIfNotSet(environmentVariables map[string]interface{}, name, value string) map[string]interface{}
 	IsSimpleEnvvarValue(i interface{}) bool
@@ -36,6 +42,7 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 	data := api.TemplateData{
 		Name:                    params.App,
 		NameWithTrack:           params.App,
+		JobName:                 params.App,
 		Namespace:               params.Namespace,
 		Schedule:                params.Schedule,
 		ConcurrencyPolicy:       params.ConcurrencyPolicy,
@@ -58,11 +65,18 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 		InternalIngressPath: params.Basepath,
 		AllowHTTP:           params.AllowHTTP,
 
-		IncludeReplicas: currentReplicas > 0 || ((params.Autoscale.Enabled == nil || !*params.Autoscale.Enabled || params.StrategyType == "Recreate") && params.Replicas > 0),
+		IncludeReplicas: currentReplicas > 0 || (currentReplicas == 0 && params.UsesKedaAutoscaler()) || ((params.Autoscale.Enabled == nil || !*params.Autoscale.Enabled || params.StrategyType == "Recreate") && params.Replicas > 0),
 
 		MinReplicas:         params.Autoscale.MinReplicas,
 		MaxReplicas:         params.Autoscale.MaxReplicas,
 		TargetCPUPercentage: params.Autoscale.CPUPercentage,
+		AutoscaleMetrics:    buildAutoscaleMetrics(params.Autoscale.Metrics),
+		AutoscaleScaleUp:    buildAutoscaleScalingRules(params.Autoscale.Behavior.ScaleUp),
+		AutoscaleScaleDown:  buildAutoscaleScalingRules(params.Autoscale.Behavior.ScaleDown),
+		KedaPollingInterval: params.Autoscale.Keda.PollingInterval,
+		KedaCooldownPeriod:  params.Autoscale.Keda.CooldownPeriod,
+		KedaScaleToZero:     params.Autoscale.Keda.ScaleToZero,
+		KedaTriggers:        buildKedaTriggers(params.Autoscale.Keda.Triggers),
 
 		UseHpaScaler:                params.Autoscale.Safety.Enabled,
 		HpaScalerPromQuery:          params.Autoscale.Safety.PromQuery,
@@ -169,6 +183,10 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 		data.BackoffLimit = *params.BackoffLimit
 	}
 
+	if params.Suspend != nil {
+		data.Suspend = *params.Suspend
+	}
+
 	if params.DisableServiceAccountKeyRotation != nil {
 		data.DisableServiceAccountKeyRotation = *params.DisableServiceAccountKeyRotation
 	}
@@ -242,6 +260,7 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 		data.HasTolerations = true
 		data.Tolerations = append(data.Tolerations, params.Tolerations...)
 	}
+	data.NodeSelector = params.NodeSelector
 
 	if params.InitContainers != nil {
 		data.HasInitContainers = true
@@ -304,7 +323,8 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 		data.Container.PreStopSleepSeconds = *params.Container.Lifecycle.PrestopSleepSeconds
 	}
 
-	if currentReplicas > 0 {
+	if currentReplicas > 0 || (currentReplicas == 0 && params.UsesKedaAutoscaler()) {
+		// a deployment keda scaled to zero stays at zero until keda activates it
 		data.Replicas = currentReplicas
 	} else if (params.Autoscale.Enabled != nil && !*params.Autoscale.Enabled) || params.StrategyType == "Recreate" || params.Replicas > data.MinReplicas {
 		data.Replicas = params.Replicas
@@ -347,16 +367,21 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 		data.TrackLabel = "stable"
 	}
 
+	// with a progressive canary the canary gets its traffic through a separate canary ingress, so the main service only targets stable pods
+	if params.UseProgressiveCanary() && data.IncludeTrackLabel {
+		data.ServiceTrackSelector = "stable"
+	}
+
 	switch params.StrategyType {
 	case api.StrategyTypeRollingUpdate:
 		data.StrategyType = string(params.StrategyType)
 	case api.StrategyTypeRecreate:
 		data.StrategyType = string(params.StrategyType)
-	case api.StrategyTypeAtomicUpdate:
+	case api.StrategyTypeAtomicUpdate, api.StrategyTypeBlueGreen:
 		data.StrategyType = string(api.StrategyTypeRollingUpdate)
 	}
 
-	if params.StrategyType == api.StrategyTypeAtomicUpdate && params.Action == api.ActionDeploySimple && params.AtomicID != "" {
+	if (params.StrategyType == api.StrategyTypeAtomicUpdate || params.StrategyType == api.StrategyTypeBlueGreen) && params.Action == api.ActionDeploySimple && params.AtomicID != "" {
 		data.NameWithTrack += "-" + params.AtomicID
 		data.IncludeAtomicIDSelector = true
 		data.AtomicID = params.AtomicID
@@ -365,6 +390,12 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 		data.PodLabels["estafette.io/atomic-id"] = params.AtomicID
 	}
 
+	// let the certificate include the preview hosts, so the preview ingress can reuse it
+	if params.StrategyType == api.StrategyTypeBlueGreen && len(params.BlueGreen.PreviewHosts) > 0 {
+		data.AllHosts = append(append([]string{}, data.AllHosts...), params.BlueGreen.PreviewHosts...)
+		data.AllHostsJoined = strings.Join(data.AllHosts, ",")
+	}
+
 	// set some additional labels similar to helm charts in order to unify alerting and dashboards
 	data.PodLabels["app.kubernetes.io/name"] = data.Name
 	data.PodLabels["app.kubernetes.io/instance"] = data.NameWithTrack
@@ -373,6 +404,39 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 
 	data.ConfigmapFiles = params.Configs.RenderedFileContent
 
+	// configs and secrets are named after the workload that mounts them, unless they're shared with other applications
+	data.ConfigmapName = data.NameWithTrack + "-configs"
+	data.SecretsName = data.NameWithTrack + "-secrets"
+	if params.Kind == api.KindConfig && params.Shared.Name != "" {
+		data.ConfigmapName = params.Shared.Name
+	}
+	if params.Kind == api.KindSecret {
+		data.SecretsName = params.Shared.Name
+	}
+
+	// configs and secrets are updated in place, so put a checksum of their content on the pod template to roll the pods when only the content changes
+	if data.MountConfigmap {
+		data.ConfigmapChecksum = checksum(data.ConfigmapFiles)
+	}
+	if data.MountApplicationSecrets {
+		secrets := map[string]string{}
+		for key, value := range data.Secrets {
+			secrets[key] = fmt.Sprintf("%v", value)
+		}
+		data.SecretsChecksum = checksum(secrets)
+	}
+
+	// with immutable configs every change gets new content-addressed objects, so the pod template of earlier revisions keeps referring to its own
+	if params.UseImmutableConfigs() {
+		data.ImmutableConfigs = true
+		if data.MountConfigmap {
+			data.ConfigmapName = fmt.Sprintf("%v-configs-%v", data.NameWithTrack, data.ConfigmapChecksum[:10])
+		}
+		if data.MountApplicationSecrets {
+			data.SecretsName = fmt.Sprintf("%v-secrets-%v", data.NameWithTrack, data.SecretsChecksum[:10])
+		}
+	}
+
 	data.ManifestData = map[string]interface{}{}
 	for k, v := range params.Manifests.Data {
 		data.ManifestData[k] = v
@@ -562,6 +626,190 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 	return data
 }
 
+func (s *service) GenerateCanaryTemplateData(data api.TemplateData, weight int) api.TemplateData {
+
+	canaryName := fmt.Sprintf("%v-canary", data.Name)
+
+	canaryData := data
+	canaryData.Name = canaryName
+	canaryData.Service = api.ServiceData{
+		ServiceType: string(api.ServiceTypeClusterIP),
+		Name:        canaryName,
+	}
+	canaryData.ServiceTrackSelector = "canary"
+	canaryData.UseCanaryIngress = true
+	canaryData.CanaryWeight = weight
+
+	canaryData.Labels = map[string]string{}
+	for k, v := range data.Labels {
+		canaryData.Labels[k] = v
+	}
+	canaryData.Labels["track"] = "canary"
+
+	// dns records and probes are owned by the main ingress and service
+	canaryData.UseDNSAnnotationsOnIngress = false
+	canaryData.UsePrometheusProbe = false
+
+	// reuse the certificate of the main ingress, since no certificate gets issued for the canary ingress
+	if !canaryData.UseCertificateSecret {
+		canaryData.UseCertificateSecret = true
+		canaryData.CertificateSecretName = fmt.Sprintf("%v-letsencrypt-certificate", data.Name)
+	}
+
+	return canaryData
+}
+
+func (s *service) GenerateBlueGreenPreviewTemplateData(data api.TemplateData, hosts []string) api.TemplateData {
+
+	previewName := fmt.Sprintf("%v-preview", data.Name)
+
+	previewData := data
+	previewData.Name = previewName
+	previewData.Service = api.ServiceData{
+		ServiceType: string(api.ServiceTypeClusterIP),
+		Name:        previewName,
+	}
+	previewData.Hosts = hosts
+	previewData.HostsJoined = strings.Join(hosts, ",")
+
+	// probes are owned by the main service
+	previewData.UsePrometheusProbe = false
+
+	// reuse the certificate of the main ingress, which includes the preview hosts
+	if !previewData.UseCertificateSecret {
+		previewData.UseCertificateSecret = true
+		previewData.CertificateSecretName = fmt.Sprintf("%v-letsencrypt-certificate", data.Name)
+	}
+
+	return previewData
+}
+
+func (s *service) GenerateHookTemplateData(data api.TemplateData, hook string, hookParams api.HookParams) api.TemplateData {
+
+	hookData := data
+	hookData.JobName = fmt.Sprintf("%v-%v", data.NameWithTrack, hook)
+	hookData.RestartPolicy = "Never"
+	hookData.Completions = 1
+	hookData.Parallelism = 1
+	if hookParams.BackoffLimit != nil {
+		hookData.BackoffLimit = *hookParams.BackoffLimit
+	}
+
+	hookData.Container.Command = hookParams.Command
+	hookData.Container.Args = hookParams.Args
+
+	hookData.PodLabels = map[string]string{}
+	for k, v := range data.PodLabels {
+		hookData.PodLabels[k] = v
+	}
+	hookData.PodLabels["estafette.io/hook"] = hook
+
+	// custom sidecars keep running after the hook finishes, which would prevent the job from ever completing
+	hookData.HasCustomSidecars = false
+	hookData.CustomSidecars = nil
+
+	return hookData
+}
+
+// checksum returns a sha256 hash of the keys and values, which is the same for the same content regardless of map ordering
+func checksum(data map[string]string) string {
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		// length prefixes keep a key or value containing the separator from colliding with another split of the same bytes
+		fmt.Fprintf(hash, "%d:%v%d:%v", len(key), key, len(data[key]), data[key])
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// buildAutoscaleMetrics maps the autoscale metrics to the metric types and targets of the autoscaling/v2 api
+func buildAutoscaleMetrics(metrics []api.AutoscaleMetricParams) []api.AutoscaleMetricData {
+
+	metricsData := []api.AutoscaleMetricData{}
+	for _, metric := range metrics {
+		metricData := api.AutoscaleMetricData{
+			MetricName:                metric.Name,
+			MetricSelector:            metric.Selector,
+			DescribedObjectAPIVersion: metric.Object.APIVersion,
+			DescribedObjectKind:       metric.Object.Kind,
+			DescribedObjectName:       metric.Object.Name,
+			AverageUtilization:        metric.AverageUtilization,
+			AverageValue:              metric.AverageValue,
+			Value:                     metric.Value,
+		}
+
+		switch metric.Type {
+		case api.AutoscaleMetricTypeMemory:
+			metricData.Type = "Resource"
+			metricData.ResourceName = "memory"
+		case api.AutoscaleMetricTypePods:
+			metricData.Type = "Pods"
+		case api.AutoscaleMetricTypeObject:
+			metricData.Type = "Object"
+		case api.AutoscaleMetricTypeExternal:
+			metricData.Type = "External"
+		}
+
+		switch {
+		case metric.AverageUtilization > 0:
+			metricData.TargetType = "Utilization"
+		case metric.AverageValue != "":
+			metricData.TargetType = "AverageValue"
+		default:
+			metricData.TargetType = "Value"
+		}
+
+		metricsData = append(metricsData, metricData)
+	}
+
+	return metricsData
+}
+
+func buildKedaTriggers(triggers []api.AutoscaleKedaTriggerParams) []api.KedaTriggerData {
+
+	triggersData := []api.KedaTriggerData{}
+	for _, trigger := range triggers {
+		triggersData = append(triggersData, api.KedaTriggerData{
+			Type:              string(trigger.Type),
+			Metadata:          trigger.Metadata,
+			AuthenticationRef: trigger.AuthenticationRef,
+		})
+	}
+
+	return triggersData
+}
+
+func buildAutoscaleScalingRules(rules *api.AutoscaleScalingRulesParams) *api.AutoscaleScalingRulesData {
+	if rules == nil {
+		return nil
+	}
+
+	rulesData := &api.AutoscaleScalingRulesData{
+		HasStabilizationWindowSeconds: rules.StabilizationWindowSeconds != nil,
+		SelectPolicy:                  string(rules.SelectPolicy),
+		Policies:                      []api.AutoscalePolicyData{},
+	}
+	if rules.StabilizationWindowSeconds != nil {
+		rulesData.StabilizationWindowSeconds = *rules.StabilizationWindowSeconds
+	}
+	for _, policy := range rules.Policies {
+		rulesData.Policies = append(rulesData.Policies, api.AutoscalePolicyData{
+			Type:          string(policy.Type),
+			Value:         policy.Value,
+			PeriodSeconds: policy.PeriodSeconds,
+		})
+	}
+
+	return rulesData
+}
+
 func (s *service) BuildSidecar(sidecar *api.SidecarParams, params api.Params) api.SidecarData {
 	builtSidecar := api.SidecarData{
 		Type:                       string(sidecar.Type),
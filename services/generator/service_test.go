@@ -632,6 +632,91 @@ func TestGenerateTemplateData(t *testing.T) {
 		assert.Equal(t, 5, templateData.MinReplicas)
 	})
 
+	t.Run("SetsAutoscaleMetricsToAutoscalingV2MetricTypesAndTargets", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Autoscale: api.AutoscaleParams{
+				Metrics: []api.AutoscaleMetricParams{
+					{Type: api.AutoscaleMetricTypeMemory, AverageUtilization: 75},
+					{Type: api.AutoscaleMetricTypePods, Name: "http_requests_per_second", AverageValue: "100"},
+					{Type: api.AutoscaleMetricTypeObject, Name: "requests-per-second", Object: api.AutoscaleMetricObjectParams{Kind: "Ingress", Name: "myapp"}, Value: "2k"},
+				},
+			},
+		}
+
+		// act
+		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.Equal(t, []api.AutoscaleMetricData{
+			{Type: "Resource", ResourceName: "memory", TargetType: "Utilization", AverageUtilization: 75},
+			{Type: "Pods", MetricName: "http_requests_per_second", TargetType: "AverageValue", AverageValue: "100"},
+			{Type: "Object", MetricName: "requests-per-second", DescribedObjectKind: "Ingress", DescribedObjectName: "myapp", TargetType: "Value", Value: "2k"},
+		}, templateData.AutoscaleMetrics)
+	})
+
+	t.Run("SetsAutoscaleScalingRulesForBehaviorParam", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		zero := 0
+		params := api.Params{
+			Autoscale: api.AutoscaleParams{
+				Behavior: api.AutoscaleBehaviorParams{
+					ScaleUp: &api.AutoscaleScalingRulesParams{
+						StabilizationWindowSeconds: &zero,
+						Policies:                   []api.AutoscalePolicyParams{{Type: api.AutoscalePolicyTypePercent, Value: 100, PeriodSeconds: 15}},
+					},
+				},
+			},
+		}
+
+		// act
+		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.Equal(t, &api.AutoscaleScalingRulesData{
+			HasStabilizationWindowSeconds: true,
+			StabilizationWindowSeconds:    0,
+			Policies:                      []api.AutoscalePolicyData{{Type: "Percent", Value: 100, PeriodSeconds: 15}},
+		}, templateData.AutoscaleScaleUp)
+		assert.Nil(t, templateData.AutoscaleScaleDown)
+	})
+
+	t.Run("SetsKedaTriggersForKedaAutoscaleParams", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Autoscale: api.AutoscaleParams{
+				Type: api.AutoscalerTypeKeda,
+				Keda: api.AutoscaleKedaParams{
+					PollingInterval: 15,
+					ScaleToZero:     true,
+					Triggers: []api.AutoscaleKedaTriggerParams{
+						{Type: api.KedaTriggerTypeGCPPubSub, Metadata: map[string]string{"subscriptionName": "mysubscription"}, AuthenticationRef: "gcp-workload-identity"},
+					},
+				},
+			},
+		}
+
+		// act
+		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.Equal(t, 15, templateData.KedaPollingInterval)
+		assert.Equal(t, 0, templateData.KedaCooldownPeriod)
+		assert.True(t, templateData.KedaScaleToZero)
+		assert.Equal(t, []api.KedaTriggerData{
+			{Type: "gcp-pubsub", Metadata: map[string]string{"subscriptionName": "mysubscription"}, AuthenticationRef: "gcp-workload-identity"},
+		}, templateData.KedaTriggers)
+	})
+
 	t.Run("SetsMaxReplicasToAutoscaleMaxReplicasParam", func(t *testing.T) {
 
 		ctx := context.Background()
@@ -1791,6 +1876,190 @@ func TestGenerateTemplateData(t *testing.T) {
 		}, templateData.Tolerations[1])
 	})
 
+	t.Run("SetsNodeSelectorToNodeSelectorParam", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			NodeSelector: map[string]string{
+				"cloud.google.com/gke-nodepool": "monitoring",
+			},
+		}
+
+		// act
+		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.Equal(t, map[string]string{"cloud.google.com/gke-nodepool": "monitoring"}, templateData.NodeSelector)
+	})
+
+	t.Run("SetsConfigmapAndSecretsNameToNameWithTrackWithSuffix", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:    "myapp",
+			Action: api.ActionDeployCanary,
+		}
+
+		// act
+		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.Equal(t, "myapp-canary-configs", templateData.ConfigmapName)
+		assert.Equal(t, "myapp-canary-secrets", templateData.SecretsName)
+	})
+
+	t.Run("SetsConfigmapNameToSharedNameParamIfKindIsConfig", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:  "myapp",
+			Kind: api.KindConfig,
+			Shared: api.SharedParams{
+				Name: "shared-configs",
+			},
+		}
+
+		// act
+		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.Equal(t, "shared-configs", templateData.ConfigmapName)
+	})
+
+	t.Run("SetsSecretsNameToSharedNameParamIfKindIsSecret", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:  "myapp",
+			Kind: api.KindSecret,
+			Shared: api.SharedParams{
+				Name: "shared-credentials",
+			},
+		}
+
+		// act
+		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.Equal(t, "shared-credentials", templateData.SecretsName)
+	})
+
+	t.Run("SetsConfigmapChecksumThatOnlyChangesWithConfigContent", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Configs: api.ConfigsParams{
+				InlineFiles:         map[string]string{"config.yaml": "key: value", "other.yaml": "other: value"},
+				RenderedFileContent: map[string]string{"config.yaml": "key: value", "other.yaml": "other: value"},
+			},
+		}
+		changedParams := params
+		changedParams.Configs.RenderedFileContent = map[string]string{"config.yaml": "key: changed", "other.yaml": "other: value"}
+
+		// act
+		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		sameTemplateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		changedTemplateData := service.GenerateTemplateData(changedParams, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.Equal(t, 64, len(templateData.ConfigmapChecksum))
+		assert.Equal(t, templateData.ConfigmapChecksum, sameTemplateData.ConfigmapChecksum)
+		assert.NotEqual(t, templateData.ConfigmapChecksum, changedTemplateData.ConfigmapChecksum)
+	})
+
+	t.Run("SetsSecretsChecksumThatChangesWithSecretEnvironmentVariables", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Secrets: api.SecretsParams{
+				Keys: map[string]interface{}{"password": "c2VjcmV0"},
+			},
+		}
+		changedParams := params
+		changedParams.Container.SecretEnvironmentVariables = map[string]interface{}{"TOKEN": "token"}
+
+		// act
+		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		changedTemplateData := service.GenerateTemplateData(changedParams, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.Equal(t, 64, len(templateData.SecretsChecksum))
+		assert.NotEqual(t, templateData.SecretsChecksum, changedTemplateData.SecretsChecksum)
+	})
+
+	t.Run("SetsConfigmapAndSecretsNameWithChecksumIfImmutableConfigsIsEnabled", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App: "myapp",
+			Configs: api.ConfigsParams{
+				InlineFiles:         map[string]string{"config.yaml": "key: value"},
+				RenderedFileContent: map[string]string{"config.yaml": "key: value"},
+			},
+			Secrets: api.SecretsParams{
+				Keys: map[string]interface{}{"password": "c2VjcmV0"},
+			},
+			ImmutableConfigs: api.ImmutableConfigsParams{
+				Enabled: true,
+			},
+		}
+
+		// act
+		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.True(t, templateData.ImmutableConfigs)
+		assert.Equal(t, "myapp-configs-"+templateData.ConfigmapChecksum[:10], templateData.ConfigmapName)
+		assert.Equal(t, "myapp-secrets-"+templateData.SecretsChecksum[:10], templateData.SecretsName)
+	})
+
+	t.Run("LeavesChecksumsEmptyIfNoConfigsOrSecretsAreMounted", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{}
+
+		// act
+		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.Equal(t, "", templateData.ConfigmapChecksum)
+		assert.Equal(t, "", templateData.SecretsChecksum)
+	})
+
+	t.Run("SetsSuspendIfSuspendParamIsTrue", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		trueValue := true
+		params := api.Params{
+			Kind:    api.KindCronJob,
+			Suspend: &trueValue,
+		}
+
+		// act
+		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.True(t, templateData.Suspend)
+	})
+
 	t.Run("SetsMountConfigmapToTrueIfConfigFilesParamsLengthIsLargerThanZero", func(t *testing.T) {
 
 		ctx := context.Background()
@@ -2218,6 +2487,113 @@ func TestGenerateTemplateData(t *testing.T) {
 		assert.Equal(t, "stable", templateData.TrackLabel)
 	})
 
+	t.Run("AppendsAtomicIDToNameWithTrackIfStrategyTypeIsBlueGreen", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:          "myapp",
+			Kind:         api.KindDeployment,
+			Action:       api.ActionDeploySimple,
+			StrategyType: api.StrategyTypeBlueGreen,
+			AtomicID:     "5",
+		}
+
+		// act
+		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.Equal(t, "myapp-5", templateData.NameWithTrack)
+		assert.True(t, templateData.IncludeAtomicIDSelector)
+		assert.Equal(t, "5", templateData.PodLabels["estafette.io/atomic-id"])
+		assert.Equal(t, "RollingUpdate", templateData.StrategyType)
+	})
+
+	t.Run("AddsBlueGreenPreviewHostsToAllHosts", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:          "myapp",
+			Kind:         api.KindDeployment,
+			Action:       api.ActionDeploySimple,
+			StrategyType: api.StrategyTypeBlueGreen,
+			Hosts:        []string{"ci.estafette.io"},
+			BlueGreen: api.BlueGreenParams{
+				PreviewHosts: []string{"ci-preview.estafette.io"},
+			},
+		}
+
+		// act
+		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.Equal(t, "ci.estafette.io,ci-preview.estafette.io", templateData.AllHostsJoined)
+		assert.Equal(t, "ci.estafette.io", templateData.HostsJoined)
+	})
+
+	t.Run("SetsServiceTrackSelectorToStableIfCanaryStepsAreSetAndParamsTypeIsCanary", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:    "myapp",
+			Kind:   api.KindDeployment,
+			Action: api.ActionDeployCanary,
+			Canary: api.CanaryParams{
+				Steps: []api.CanaryStepParams{{Weight: 10}},
+			},
+		}
+
+		// act
+		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.Equal(t, "stable", templateData.ServiceTrackSelector)
+	})
+
+	t.Run("DoesNotSetServiceTrackSelectorIfCanaryStepsAreNotSet", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:    "myapp",
+			Kind:   api.KindDeployment,
+			Action: api.ActionDeployCanary,
+		}
+
+		// act
+		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.Equal(t, "", templateData.ServiceTrackSelector)
+	})
+
+	t.Run("DoesNotSetServiceTrackSelectorIfCanaryStepsAreSetAndParamsTypeIsSimple", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:    "myapp",
+			Kind:   api.KindDeployment,
+			Action: api.ActionDeploySimple,
+			Canary: api.CanaryParams{
+				Steps: []api.CanaryStepParams{{Weight: 10}},
+			},
+		}
+
+		// act
+		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.Equal(t, "", templateData.ServiceTrackSelector)
+	})
+
 	t.Run("SetsAdditionalVolumeMountsToVolumeMountsParam", func(t *testing.T) {
 
 		ctx := context.Background()
@@ -2538,6 +2914,27 @@ func TestGenerateTemplateData(t *testing.T) {
 		assert.Equal(t, 3, templateData.Replicas)
 	})
 
+	t.Run("KeepsReplicasAtZeroIfCurrentReplicasIsZeroAndKedaAutoscalerIsUsed", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Autoscale: api.AutoscaleParams{
+				Enabled:     &trueValue,
+				Type:        api.AutoscalerTypeKeda,
+				MinReplicas: 3,
+			},
+		}
+
+		// act
+		templateData := service.GenerateTemplateData(params, 0, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.True(t, templateData.IncludeReplicas)
+		assert.Equal(t, 0, templateData.Replicas)
+	})
+
 	t.Run("SetsScheduleToScheduleParam", func(t *testing.T) {
 
 		ctx := context.Background()
@@ -3019,3 +3416,265 @@ func TestGenerateTemplateData(t *testing.T) {
 		assert.Equal(t, "google-apigee.com,estafette-apigee.io,test-app-apigee", templateData.ApigeeHostsJoined)
 	})
 }
+
+func TestGenerateCanaryTemplateData(t *testing.T) {
+
+	t.Run("NamesServiceAndIngressAfterCanary", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		data := api.TemplateData{
+			Name:   "myapp",
+			Labels: map[string]string{"app": "myapp"},
+		}
+
+		// act
+		canaryData := service.GenerateCanaryTemplateData(data, 25)
+
+		assert.Equal(t, "myapp-canary", canaryData.Name)
+		assert.Equal(t, "myapp-canary", canaryData.Service.Name)
+		assert.Equal(t, "ClusterIP", canaryData.Service.ServiceType)
+		assert.Equal(t, "canary", canaryData.ServiceTrackSelector)
+	})
+
+	t.Run("SetsCanaryWeight", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		data := api.TemplateData{
+			Name:   "myapp",
+			Labels: map[string]string{"app": "myapp"},
+		}
+
+		// act
+		canaryData := service.GenerateCanaryTemplateData(data, 25)
+
+		assert.True(t, canaryData.UseCanaryIngress)
+		assert.Equal(t, 25, canaryData.CanaryWeight)
+	})
+
+	t.Run("AddsTrackLabelWithoutChangingOriginalLabels", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		data := api.TemplateData{
+			Name:   "myapp",
+			Labels: map[string]string{"app": "myapp"},
+		}
+
+		// act
+		canaryData := service.GenerateCanaryTemplateData(data, 25)
+
+		assert.Equal(t, "canary", canaryData.Labels["track"])
+		_, hasTrack := data.Labels["track"]
+		assert.False(t, hasTrack)
+	})
+
+	t.Run("DisablesDNSAnnotationsOnIngress", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		data := api.TemplateData{
+			Name:                       "myapp",
+			UseDNSAnnotationsOnIngress: true,
+		}
+
+		// act
+		canaryData := service.GenerateCanaryTemplateData(data, 25)
+
+		assert.False(t, canaryData.UseDNSAnnotationsOnIngress)
+	})
+
+	t.Run("ReusesLetsEncryptCertificateOfMainIngress", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		data := api.TemplateData{
+			Name: "myapp",
+		}
+
+		// act
+		canaryData := service.GenerateCanaryTemplateData(data, 25)
+
+		assert.True(t, canaryData.UseCertificateSecret)
+		assert.Equal(t, "myapp-letsencrypt-certificate", canaryData.CertificateSecretName)
+	})
+
+	t.Run("KeepsCertificateSecretIfSet", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		data := api.TemplateData{
+			Name:                  "myapp",
+			UseCertificateSecret:  true,
+			CertificateSecretName: "my-certificate",
+		}
+
+		// act
+		canaryData := service.GenerateCanaryTemplateData(data, 25)
+
+		assert.Equal(t, "my-certificate", canaryData.CertificateSecretName)
+	})
+}
+
+func TestGenerateBlueGreenPreviewTemplateData(t *testing.T) {
+
+	t.Run("NamesServiceAndIngressAfterPreview", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		data := api.TemplateData{
+			Name:                    "myapp",
+			IncludeAtomicIDSelector: true,
+			AtomicID:                "5",
+		}
+
+		// act
+		previewData := service.GenerateBlueGreenPreviewTemplateData(data, []string{"ci-preview.estafette.io"})
+
+		assert.Equal(t, "myapp-preview", previewData.Name)
+		assert.Equal(t, "myapp-preview", previewData.Service.Name)
+		assert.Equal(t, "ClusterIP", previewData.Service.ServiceType)
+		assert.True(t, previewData.IncludeAtomicIDSelector)
+		assert.Equal(t, "5", previewData.AtomicID)
+	})
+
+	t.Run("SetsHostsToPreviewHosts", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		data := api.TemplateData{
+			Name:        "myapp",
+			Hosts:       []string{"ci.estafette.io"},
+			HostsJoined: "ci.estafette.io",
+		}
+
+		// act
+		previewData := service.GenerateBlueGreenPreviewTemplateData(data, []string{"ci-preview.estafette.io", "ci-preview2.estafette.io"})
+
+		assert.Equal(t, []string{"ci-preview.estafette.io", "ci-preview2.estafette.io"}, previewData.Hosts)
+		assert.Equal(t, "ci-preview.estafette.io,ci-preview2.estafette.io", previewData.HostsJoined)
+	})
+
+	t.Run("ReusesLetsEncryptCertificateOfMainIngress", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		data := api.TemplateData{
+			Name: "myapp",
+		}
+
+		// act
+		previewData := service.GenerateBlueGreenPreviewTemplateData(data, []string{"ci-preview.estafette.io"})
+
+		assert.True(t, previewData.UseCertificateSecret)
+		assert.Equal(t, "myapp-letsencrypt-certificate", previewData.CertificateSecretName)
+	})
+}
+
+func TestGenerateHookTemplateData(t *testing.T) {
+
+	t.Run("NamesJobAfterNameWithTrackAndHook", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		data := api.TemplateData{
+			Name:          "myapp",
+			NameWithTrack: "myapp-canary",
+			JobName:       "myapp",
+		}
+
+		// act
+		hookData := service.GenerateHookTemplateData(data, "pre-deploy", api.HookParams{})
+
+		assert.Equal(t, "myapp-canary-pre-deploy", hookData.JobName)
+		assert.Equal(t, "myapp", hookData.Name)
+		assert.Equal(t, "myapp-canary", hookData.NameWithTrack)
+	})
+
+	t.Run("RunsCommandAndArgsOnceWithoutRestarting", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		backoffLimit := 2
+		data := api.TemplateData{
+			Name:          "myapp",
+			NameWithTrack: "myapp",
+			RestartPolicy: "OnFailure",
+			Completions:   3,
+			Parallelism:   3,
+			BackoffLimit:  6,
+		}
+
+		// act
+		hookData := service.GenerateHookTemplateData(data, "post-deploy", api.HookParams{Command: []string{"/smoke-test"}, Args: []string{"--host", "myapp"}, BackoffLimit: &backoffLimit})
+
+		assert.Equal(t, "Never", hookData.RestartPolicy)
+		assert.Equal(t, 1, hookData.Completions)
+		assert.Equal(t, 1, hookData.Parallelism)
+		assert.Equal(t, 2, hookData.BackoffLimit)
+		assert.Equal(t, []string{"/smoke-test"}, hookData.Container.Command)
+		assert.Equal(t, []string{"--host", "myapp"}, hookData.Container.Args)
+	})
+
+	t.Run("AddsHookLabelToPodLabelsWithoutChangingOriginal", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		data := api.TemplateData{
+			Name:      "myapp",
+			PodLabels: map[string]string{"app": "myapp"},
+		}
+
+		// act
+		hookData := service.GenerateHookTemplateData(data, "pre-deploy", api.HookParams{})
+
+		assert.Equal(t, "pre-deploy", hookData.PodLabels["estafette.io/hook"])
+		assert.Equal(t, "myapp", hookData.PodLabels["app"])
+		_, hasHookLabel := data.PodLabels["estafette.io/hook"]
+		assert.False(t, hasHookLabel)
+	})
+
+	t.Run("LeavesOutCustomSidecars", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		data := api.TemplateData{
+			Name:              "myapp",
+			HasCustomSidecars: true,
+			CustomSidecars:    []*map[string]interface{}{{"name": "mysidecar"}},
+		}
+
+		// act
+		hookData := service.GenerateHookTemplateData(data, "pre-deploy", api.HookParams{})
+
+		assert.False(t, hookData.HasCustomSidecars)
+		assert.Nil(t, hookData.CustomSidecars)
+	})
+}
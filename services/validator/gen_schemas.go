@@ -0,0 +1,225 @@
+//go:build ignore
+// +build ignore
+
+// gen_schemas writes a schema per supported Kubernetes version to the schemas directory, in the definitions format of the Kubernetes OpenAPI spec;
+// the definitions are generated from the k8s.io/api types, and each version only contains the apiVersions it serves;
+// info.x-types-version records the version of the types, so the validator knows which schemas may lack fields
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2beta1 "k8s.io/api/autoscaling/v2beta1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var versions = []int{21, 22, 23, 24, 25, 26, 27}
+
+// typesVersion is the minor version of kubernetes 1.x the k8s.io/api types in go.mod belong to; the schemas of newer versions lack the fields added since
+const typesVersion = 21
+
+type servedType struct {
+	group, version, kind string
+	object               interface{}
+	// minor versions of kubernetes 1.x that serve the apiVersion, with 0 meaning unbounded
+	since, removedIn int
+}
+
+var servedTypes = []servedType{
+	{"", "v1", "Namespace", corev1.Namespace{}, 0, 0},
+	{"", "v1", "ServiceAccount", corev1.ServiceAccount{}, 0, 0},
+	{"", "v1", "Service", corev1.Service{}, 0, 0},
+	{"", "v1", "Secret", corev1.Secret{}, 0, 0},
+	{"", "v1", "ConfigMap", corev1.ConfigMap{}, 0, 0},
+	{"", "v1", "PersistentVolumeClaim", corev1.PersistentVolumeClaim{}, 0, 0},
+	{"apps", "v1", "Deployment", appsv1.Deployment{}, 0, 0},
+	{"apps", "v1", "StatefulSet", appsv1.StatefulSet{}, 0, 0},
+	{"apps", "v1", "DaemonSet", appsv1.DaemonSet{}, 0, 0},
+	{"batch", "v1", "Job", batchv1.Job{}, 0, 0},
+	{"batch", "v1", "CronJob", batchv1.CronJob{}, 21, 0},
+	{"batch", "v1beta1", "CronJob", batchv1beta1.CronJob{}, 0, 25},
+	{"networking.k8s.io", "v1", "Ingress", networkingv1.Ingress{}, 0, 0},
+	{"networking.k8s.io", "v1beta1", "Ingress", networkingv1beta1.Ingress{}, 0, 22},
+	{"extensions", "v1beta1", "Ingress", extensionsv1beta1.Ingress{}, 0, 22},
+	{"policy", "v1", "PodDisruptionBudget", policyv1.PodDisruptionBudget{}, 21, 0},
+	{"policy", "v1beta1", "PodDisruptionBudget", policyv1beta1.PodDisruptionBudget{}, 0, 25},
+	{"autoscaling", "v1", "HorizontalPodAutoscaler", autoscalingv1.HorizontalPodAutoscaler{}, 0, 0},
+	// autoscaling/v2 graduated v2beta2 without changes, and isn't in this version of k8s.io/api yet
+	{"autoscaling", "v2", "HorizontalPodAutoscaler", autoscalingv2beta2.HorizontalPodAutoscaler{}, 23, 0},
+	{"autoscaling", "v2beta1", "HorizontalPodAutoscaler", autoscalingv2beta1.HorizontalPodAutoscaler{}, 0, 25},
+	{"autoscaling", "v2beta2", "HorizontalPodAutoscaler", autoscalingv2beta2.HorizontalPodAutoscaler{}, 0, 26},
+}
+
+var (
+	intOrStringType = reflect.TypeOf(intstr.IntOrString{})
+	quantityType    = reflect.TypeOf(resource.Quantity{})
+	timeType        = reflect.TypeOf(metav1.Time{})
+	microTimeType   = reflect.TypeOf(metav1.MicroTime{})
+	durationType    = reflect.TypeOf(metav1.Duration{})
+	rawExtension    = reflect.TypeOf(runtime.RawExtension{})
+)
+
+func main() {
+
+	for _, minor := range versions {
+		definitions := map[string]interface{}{}
+		for _, t := range servedTypes {
+			if (t.since > 0 && minor < t.since) || (t.removedIn > 0 && minor >= t.removedIn) {
+				continue
+			}
+
+			objectType := reflect.TypeOf(t.object)
+			name := definitionName(objectType)
+			if t.group == "autoscaling" && t.version == "v2" {
+				name = "io.k8s.api.autoscaling.v2.HorizontalPodAutoscaler"
+			}
+
+			definition := schemaForStruct(objectType, definitions)
+			definition["x-kubernetes-group-version-kind"] = []map[string]string{{"group": t.group, "version": t.version, "kind": t.kind}}
+			definitions[name] = definition
+		}
+
+		data, err := json.Marshal(map[string]interface{}{
+			"swagger": "2.0",
+			"info": map[string]string{
+				"title":           "Kubernetes",
+				"version":         fmt.Sprintf("v1.%v", minor),
+				"x-types-version": fmt.Sprintf("v1.%v", typesVersion),
+			},
+			"definitions": definitions,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		err = ioutil.WriteFile(filepath.Join("schemas", fmt.Sprintf("v1.%v.json", minor)), append(data, '\n'), 0644)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// definitionName returns the name the Kubernetes OpenAPI spec uses for a type, like io.k8s.api.apps.v1.Deployment
+func definitionName(t reflect.Type) string {
+	parts := strings.Split(t.PkgPath(), "/")
+	domain := strings.Split(parts[0], ".")
+	for i, j := 0, len(domain)-1; i < j; i, j = i+1, j-1 {
+		domain[i], domain[j] = domain[j], domain[i]
+	}
+
+	return strings.Join(append(append(domain, parts[1:]...), t.Name()), ".")
+}
+
+func schemaForType(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+
+	switch t {
+	case intOrStringType:
+		return map[string]interface{}{"type": "string", "format": "int-or-string"}
+	case quantityType:
+		return map[string]interface{}{"type": "string", "format": "quantity"}
+	case timeType, microTimeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]interface{}{"type": "string"}
+	case rawExtension:
+		return map[string]interface{}{"type": "object"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaForType(t.Elem(), definitions)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem(), definitions)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaForType(t.Elem(), definitions)}
+	case reflect.Struct:
+		name := definitionName(t)
+		if _, ok := definitions[name]; !ok {
+			// reserve the name first for types that refer to themselves
+			definitions[name] = nil
+			definitions[name] = schemaForStruct(t, definitions)
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + name}
+	}
+
+	return map[string]interface{}{}
+}
+
+func schemaForStruct(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+
+	properties := map[string]interface{}{}
+	required := []string{}
+	addStructFields(t, properties, &required, definitions)
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+func addStructFields(t reflect.Type, properties map[string]interface{}, required *[]string, definitions map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		options := strings.Join(parts[1:], ",")
+
+		if strings.Contains(options, "inline") || (field.Anonymous && name == "") {
+			addStructFields(field.Type, properties, required, definitions)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = schemaForType(field.Type, definitions)
+		// like the kubernetes openapi generator fields without omitempty are required, except for status which is set by the server
+		if !strings.Contains(options, "omitempty") && name != "status" {
+			*required = append(*required, name)
+		}
+	}
+}
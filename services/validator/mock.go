@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service.go
+
+// Package validator is a generated GoMock package.
+package validator
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockService is a mock of Service interface.
+type MockService struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceMockRecorder
+}
+
+// MockServiceMockRecorder is the mock recorder for MockService.
+type MockServiceMockRecorder struct {
+	mock *MockService
+}
+
+// NewMockService creates a new mock instance.
+func NewMockService(ctrl *gomock.Controller) *MockService {
+	mock := &MockService{ctrl: ctrl}
+	mock.recorder = &MockServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockService) EXPECT() *MockServiceMockRecorder {
+	return m.recorder
+}
+
+// ValidateManifest mocks base method.
+func (m *MockService) ValidateManifest(manifest []byte, kubernetesVersion string) ([]ManifestError, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateManifest", manifest, kubernetesVersion)
+	ret0, _ := ret[0].([]ManifestError)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateManifest indicates an expected call of ValidateManifest.
+func (mr *MockServiceMockRecorder) ValidateManifest(manifest, kubernetesVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateManifest", reflect.TypeOf((*MockService)(nil).ValidateManifest), manifest, kubernetesVersion)
+}
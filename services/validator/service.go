@@ -0,0 +1,367 @@
+package validator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/estafette/estafette-extension-gke/api"
+	"github.com/rs/zerolog/log"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+//go:generate go run gen_schemas.go
+
+//go:embed schemas/*.json
+var schemasFS embed.FS
+
+//go:generate mockgen -package=validator -destination ./mock.go -source=service.go
+type Service interface {
+	ValidateManifest(manifest []byte, kubernetesVersion string) (manifestErrors []ManifestError, err error)
+}
+
+// ManifestError is a problem with a single field of a document in a rendered manifest
+type ManifestError struct {
+	Document int
+	Kind     string
+	Name     string
+	Field    string
+	Message  string
+}
+
+func (e ManifestError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("document %v (%v %v): %v", e.Document, e.Kind, e.Name, e.Message)
+	}
+
+	return fmt.Sprintf("document %v (%v %v) field %v: %v", e.Document, e.Kind, e.Name, e.Field, e.Message)
+}
+
+// NewService returns a new validator.Service
+func NewService(ctx context.Context) (Service, error) {
+
+	entries, err := schemasFS.ReadDir("schemas")
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := map[int]*openAPISchema{}
+	builtinGroups := map[string]bool{}
+	for _, entry := range entries {
+		data, err := schemasFS.ReadFile("schemas/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		var schema openAPISchema
+		err = json.Unmarshal(data, &schema)
+		if err != nil {
+			return nil, fmt.Errorf("Failed unmarshalling schema %v: %w", entry.Name(), err)
+		}
+		minor, err := api.ParseKubernetesMinorVersion(schema.Info.Version)
+		if err != nil {
+			return nil, fmt.Errorf("Schema %v has invalid version: %w", entry.Name(), err)
+		}
+		if schema.Info.TypesVersion != "" {
+			typesMinor, err := api.ParseKubernetesMinorVersion(schema.Info.TypesVersion)
+			if err != nil {
+				return nil, fmt.Errorf("Schema %v has invalid types version: %w", entry.Name(), err)
+			}
+			// fields added after the version of the types aren't in the definitions, so an unknown field may well be valid
+			schema.allowUnknownFields = minor > typesMinor
+		}
+
+		schema.definitionsByGVK = map[string]*openAPIDefinition{}
+		schema.versionsByGroupKind = map[string][]string{}
+		for _, definition := range schema.Definitions {
+			for _, gvk := range definition.GroupVersionKinds {
+				schema.definitionsByGVK[apiVersion(gvk.Group, gvk.Version)+"/"+gvk.Kind] = definition
+				schema.versionsByGroupKind[gvk.Group+"/"+gvk.Kind] = append(schema.versionsByGroupKind[gvk.Group+"/"+gvk.Kind], apiVersion(gvk.Group, gvk.Version))
+				builtinGroups[gvk.Group] = true
+			}
+		}
+		for _, versions := range schema.versionsByGroupKind {
+			sort.Strings(versions)
+		}
+		schemas[minor] = &schema
+	}
+
+	minors := []int{}
+	for minor := range schemas {
+		minors = append(minors, minor)
+	}
+	sort.Ints(minors)
+
+	return &service{
+		schemas:       schemas,
+		minors:        minors,
+		builtinGroups: builtinGroups,
+	}, nil
+}
+
+type service struct {
+	schemas map[int]*openAPISchema
+	minors  []int
+	// builtinGroups are the api groups served by kubernetes itself, as opposed to custom resources
+	builtinGroups map[string]bool
+}
+
+type openAPISchema struct {
+	Info struct {
+		Version      string `json:"version"`
+		TypesVersion string `json:"x-types-version"`
+	} `json:"info"`
+	Definitions map[string]*openAPIDefinition `json:"definitions"`
+
+	definitionsByGVK    map[string]*openAPIDefinition
+	versionsByGroupKind map[string][]string
+	allowUnknownFields  bool
+}
+
+type openAPIDefinition struct {
+	Type                 string                        `json:"type,omitempty"`
+	Format               string                        `json:"format,omitempty"`
+	Ref                  string                        `json:"$ref,omitempty"`
+	Properties           map[string]*openAPIDefinition `json:"properties,omitempty"`
+	AdditionalProperties *openAPIDefinition            `json:"additionalProperties,omitempty"`
+	Items                *openAPIDefinition            `json:"items,omitempty"`
+	Required             []string                      `json:"required,omitempty"`
+	GroupVersionKinds    []groupVersionKind            `json:"x-kubernetes-group-version-kind,omitempty"`
+}
+
+type groupVersionKind struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+// ValidateManifest checks every document in the manifest against the schema for the kubernetes version, like 1.21 or 1.21.14-gke.2100;
+// custom resources aren't checked since their schema lives in the cluster, and unknown fields only for versions the bundled types cover
+func (s *service) ValidateManifest(manifest []byte, kubernetesVersion string) (manifestErrors []ManifestError, err error) {
+
+	schema, err := s.getSchema(kubernetesVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestErrors = []ManifestError{}
+	reader := k8syaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(manifest)))
+	documentIndex := 0
+	for {
+		document, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed reading manifest: %w", err)
+		}
+		if strings.TrimSpace(string(document)) == "" {
+			continue
+		}
+		documentIndex++
+
+		manifestErrors = append(manifestErrors, s.validateDocument(schema, documentIndex, document)...)
+	}
+
+	return manifestErrors, nil
+}
+
+func (s *service) getSchema(kubernetesVersion string) (*openAPISchema, error) {
+
+	minor, err := api.ParseKubernetesMinorVersion(kubernetesVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	oldest, newest := s.minors[0], s.minors[len(s.minors)-1]
+	if minor < oldest {
+		log.Warn().Msgf("Kubernetes %v is older than the oldest bundled schema, validating manifests against 1.%v", kubernetesVersion, oldest)
+		minor = oldest
+	}
+	if minor > newest {
+		log.Info().Msgf("Kubernetes %v is newer than the newest bundled schema, validating manifests against 1.%v", kubernetesVersion, newest)
+		minor = newest
+	}
+
+	return s.schemas[minor], nil
+}
+
+func (s *service) validateDocument(schema *openAPISchema, documentIndex int, document []byte) []ManifestError {
+
+	jsonDocument, err := yaml.YAMLToJSON(document)
+	if err != nil {
+		return []ManifestError{{Document: documentIndex, Message: fmt.Sprintf("document isn't valid yaml: %v", err)}}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(jsonDocument))
+	decoder.UseNumber()
+	var object map[string]interface{}
+	err = decoder.Decode(&object)
+	if err != nil || object == nil {
+		return []ManifestError{{Document: documentIndex, Message: "document isn't a kubernetes object"}}
+	}
+
+	documentAPIVersion, _ := object["apiVersion"].(string)
+	kind, _ := object["kind"].(string)
+	name := ""
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		name, _ = metadata["name"].(string)
+	}
+	if documentAPIVersion == "" || kind == "" {
+		return []ManifestError{{Document: documentIndex, Kind: kind, Name: name, Message: "apiVersion and kind are required"}}
+	}
+
+	definition, ok := schema.definitionsByGVK[documentAPIVersion+"/"+kind]
+	if !ok {
+		group := ""
+		if i := strings.LastIndex(documentAPIVersion, "/"); i >= 0 {
+			group = documentAPIVersion[:i]
+		}
+		if !s.builtinGroups[group] {
+			// custom resources are validated by the cluster
+			return nil
+		}
+
+		message := fmt.Sprintf("apiVersion %v isn't served for %v by Kubernetes %v", documentAPIVersion, kind, strings.TrimPrefix(schema.Info.Version, "v"))
+		if servedVersions := schema.versionsByGroupKind[group+"/"+kind]; len(servedVersions) > 0 {
+			message += fmt.Sprintf("; use %v instead", strings.Join(servedVersions, " or "))
+		}
+		return []ManifestError{{Document: documentIndex, Kind: kind, Name: name, Field: "apiVersion", Message: message}}
+	}
+
+	validationErrors := []ManifestError{}
+	validateValue(schema, definition, object, "", func(field, message string) {
+		validationErrors = append(validationErrors, ManifestError{Document: documentIndex, Kind: kind, Name: name, Field: field, Message: message})
+	})
+
+	return validationErrors
+}
+
+func validateValue(schema *openAPISchema, definition *openAPIDefinition, value interface{}, field string, addError func(field, message string)) {
+
+	if definition.Ref != "" {
+		referenced, ok := schema.Definitions[strings.TrimPrefix(definition.Ref, "#/definitions/")]
+		if !ok {
+			return
+		}
+		definition = referenced
+	}
+	if value == nil {
+		// null is the same as not setting the field
+		return
+	}
+
+	switch definition.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			addError(field, fmt.Sprintf("expected an object, got %v", describeValue(value)))
+			return
+		}
+
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if property, ok := definition.Properties[key]; ok {
+				validateValue(schema, property, object[key], joinField(field, key), addError)
+			} else if definition.AdditionalProperties != nil {
+				validateValue(schema, definition.AdditionalProperties, object[key], joinField(field, key), addError)
+			} else if len(definition.Properties) > 0 && !schema.allowUnknownFields {
+				addError(joinField(field, key), "unknown field")
+			}
+		}
+		for _, key := range definition.Required {
+			if object[key] == nil {
+				addError(joinField(field, key), "required field is missing")
+			}
+		}
+
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			addError(field, fmt.Sprintf("expected an array, got %v", describeValue(value)))
+			return
+		}
+		if definition.Items != nil {
+			for i, item := range array {
+				validateValue(schema, definition.Items, item, fmt.Sprintf("%v[%v]", field, i), addError)
+			}
+		}
+
+	case "string":
+		switch v := value.(type) {
+		case string:
+			if definition.Format == "byte" {
+				if _, err := base64.StdEncoding.DecodeString(v); err != nil {
+					addError(field, "expected a base64 encoded value")
+				}
+			}
+		case json.Number:
+			if definition.Format != "int-or-string" && definition.Format != "quantity" {
+				addError(field, fmt.Sprintf("expected a string, got %v", describeValue(value)))
+			}
+		default:
+			addError(field, fmt.Sprintf("expected a string, got %v", describeValue(value)))
+		}
+
+	case "integer":
+		number, ok := value.(json.Number)
+		if _, err := number.Int64(); !ok || err != nil {
+			addError(field, fmt.Sprintf("expected an integer, got %v", describeValue(value)))
+		}
+
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			addError(field, fmt.Sprintf("expected a number, got %v", describeValue(value)))
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			addError(field, fmt.Sprintf("expected a boolean, got %v", describeValue(value)))
+		}
+	}
+}
+
+func describeValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case json.Number:
+		return fmt.Sprintf("number %v", v)
+	case bool:
+		return fmt.Sprintf("boolean %v", v)
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
+func joinField(field, key string) string {
+	if field == "" {
+		return key
+	}
+
+	return field + "." + key
+}
+
+func apiVersion(group, version string) string {
+	if group == "" {
+		return version
+	}
+
+	return group + "/" + version
+}
@@ -0,0 +1,198 @@
+package validator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const validDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myapp
+  namespace: mynamespace
+spec:
+  replicas: 3
+  selector:
+    matchLabels:
+      app: myapp
+  template:
+    metadata:
+      labels:
+        app: myapp
+    spec:
+      containers:
+      - name: myapp
+        image: extensions/myapp:1.0.0
+        ports:
+        - containerPort: 5000
+        resources:
+          requests:
+            cpu: 100m
+            memory: 128Mi
+        readinessProbe:
+          httpGet:
+            path: /readiness
+            port: 5000
+`
+
+func TestValidateManifest(t *testing.T) {
+
+	t.Run("ReturnsNoErrorsForValidManifest", func(t *testing.T) {
+
+		service, err := NewService(context.Background())
+		assert.Nil(t, err)
+
+		// act
+		manifestErrors, err := service.ValidateManifest([]byte(validDeployment+"---\napiVersion: v1\nkind: Service\nmetadata:\n  name: myapp\nspec:\n  ports:\n  - port: 80\n    targetPort: http\n"), "1.21")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(manifestErrors))
+	})
+
+	t.Run("ReturnsErrorWithDocumentAndFieldForUnknownField", func(t *testing.T) {
+
+		service, err := NewService(context.Background())
+		assert.Nil(t, err)
+		manifest := "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: mynamespace\n---\n" + validDeployment + "        imagePullPolicyy: Always\n"
+
+		// act
+		manifestErrors, err := service.ValidateManifest([]byte(manifest), "1.21")
+
+		assert.Nil(t, err)
+		assert.Equal(t, []ManifestError{
+			{
+				Document: 2,
+				Kind:     "Deployment",
+				Name:     "myapp",
+				Field:    "spec.template.spec.containers[0].imagePullPolicyy",
+				Message:  "unknown field",
+			},
+		}, manifestErrors)
+		assert.EqualError(t, manifestErrors[0], "document 2 (Deployment myapp) field spec.template.spec.containers[0].imagePullPolicyy: unknown field")
+	})
+
+	t.Run("ReturnsNoErrorForFieldAddedAfterVersionOfBundledTypes", func(t *testing.T) {
+
+		service, err := NewService(context.Background())
+		assert.Nil(t, err)
+		manifest := "apiVersion: batch/v1\nkind: CronJob\nmetadata:\n  name: myapp\nspec:\n  schedule: '*/5 * * * *'\n  timeZone: Europe/Amsterdam\n  jobTemplate: {}\n"
+
+		// act
+		manifestErrors, err := service.ValidateManifest([]byte(manifest), "1.25")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(manifestErrors))
+	})
+
+	t.Run("ReturnsErrorForUnknownFieldForVersionOfBundledTypes", func(t *testing.T) {
+
+		service, err := NewService(context.Background())
+		assert.Nil(t, err)
+		manifest := "apiVersion: batch/v1\nkind: CronJob\nmetadata:\n  name: myapp\nspec:\n  schedule: '*/5 * * * *'\n  timeZone: Europe/Amsterdam\n  jobTemplate: {}\n"
+
+		// act
+		manifestErrors, err := service.ValidateManifest([]byte(manifest), "1.21")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(manifestErrors))
+		assert.Equal(t, "spec.timeZone", manifestErrors[0].Field)
+	})
+
+	t.Run("ReturnsErrorForMissingRequiredField", func(t *testing.T) {
+
+		service, err := NewService(context.Background())
+		assert.Nil(t, err)
+		manifest := "apiVersion: v1\nkind: Service\nmetadata:\n  name: myapp\nspec:\n  ports:\n  - name: http\n"
+
+		// act
+		manifestErrors, err := service.ValidateManifest([]byte(manifest), "1.21")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(manifestErrors))
+		assert.Equal(t, "spec.ports[0].port", manifestErrors[0].Field)
+		assert.Equal(t, "required field is missing", manifestErrors[0].Message)
+	})
+
+	t.Run("ReturnsErrorForWrongType", func(t *testing.T) {
+
+		service, err := NewService(context.Background())
+		assert.Nil(t, err)
+		manifest := "apiVersion: v1\nkind: Secret\nmetadata:\n  name: myapp\ndata:\n  password: not base64!\n"
+
+		// act
+		manifestErrors, err := service.ValidateManifest([]byte(manifest), "1.21")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(manifestErrors))
+		assert.Equal(t, "data.password", manifestErrors[0].Field)
+		assert.Equal(t, "expected a base64 encoded value", manifestErrors[0].Message)
+	})
+
+	t.Run("ReturnsErrorForAPIVersionRemovedInKubernetesVersion", func(t *testing.T) {
+
+		service, err := NewService(context.Background())
+		assert.Nil(t, err)
+		manifest := "apiVersion: policy/v1beta1\nkind: PodDisruptionBudget\nmetadata:\n  name: myapp\nspec:\n  maxUnavailable: 1\n"
+
+		// act
+		manifestErrors, err := service.ValidateManifest([]byte(manifest), "1.25.8-gke.1000")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(manifestErrors))
+		assert.Equal(t, "apiVersion", manifestErrors[0].Field)
+		assert.Equal(t, "apiVersion policy/v1beta1 isn't served for PodDisruptionBudget by Kubernetes 1.25; use policy/v1 instead", manifestErrors[0].Message)
+	})
+
+	t.Run("AcceptsAPIVersionBeforeItIsRemoved", func(t *testing.T) {
+
+		service, err := NewService(context.Background())
+		assert.Nil(t, err)
+		manifest := "apiVersion: policy/v1beta1\nkind: PodDisruptionBudget\nmetadata:\n  name: myapp\nspec:\n  maxUnavailable: 1\n"
+
+		// act
+		manifestErrors, err := service.ValidateManifest([]byte(manifest), "1.24.12-gke.500")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(manifestErrors))
+	})
+
+	t.Run("SkipsCustomResources", func(t *testing.T) {
+
+		service, err := NewService(context.Background())
+		assert.Nil(t, err)
+		manifest := "apiVersion: cloud.google.com/v1beta1\nkind: BackendConfig\nmetadata:\n  name: myapp\nspec:\n  anything: goes\n"
+
+		// act
+		manifestErrors, err := service.ValidateManifest([]byte(manifest), "1.21")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(manifestErrors))
+	})
+
+	t.Run("UsesNewestSchemaForNewerKubernetesVersion", func(t *testing.T) {
+
+		service, err := NewService(context.Background())
+		assert.Nil(t, err)
+		manifest := "apiVersion: batch/v1beta1\nkind: CronJob\nmetadata:\n  name: myapp\n"
+
+		// act
+		manifestErrors, err := service.ValidateManifest([]byte(manifest), "1.99")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(manifestErrors))
+		assert.Equal(t, "apiVersion", manifestErrors[0].Field)
+	})
+
+	t.Run("ReturnsErrorForInvalidKubernetesVersion", func(t *testing.T) {
+
+		service, err := NewService(context.Background())
+		assert.Nil(t, err)
+
+		// act
+		_, err = service.ValidateManifest([]byte(validDeployment), "latest")
+
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,8 @@
+package templates
+
+import "embed"
+
+// FS holds the kubernetes manifest templates, so the binary doesn't depend on them being copied alongside it
+//
+//go:embed *.yaml
+var FS embed.FS